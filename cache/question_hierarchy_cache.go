@@ -0,0 +1,214 @@
+// In-process cache of the read-only question hierarchy responses. The hierarchy changes rarely but is read by
+// every student opening the practice page, so the responses are kept in memory with an ETag and invalidated
+// whenever the hierarchy (or the questions under it) is written, on this instance or, through Postgres
+// LISTEN/NOTIFY, on any other.
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// QuestionHierarchyChannel is the Postgres NOTIFY channel the hierarchy triggers publish on
+	QuestionHierarchyChannel = "question_hierarchy_changed"
+
+	// defaultQuestionHierarchyCacheTTL bounds the staleness if a notification is ever missed
+	defaultQuestionHierarchyCacheTTL = 10 * time.Minute
+
+	// maxQuestionHierarchyCacheEntries bounds the memory used by the query string variations
+	maxQuestionHierarchyCacheEntries = 1024
+)
+
+// cachedResponse is a response body along with what is needed to replay it
+type cachedResponse struct {
+	body        []byte
+	contentType string
+	etag        string
+	storedAt    time.Time
+}
+
+// questionHierarchyCache holds the cached responses by request URI.
+// generation changes on every invalidation so that responses computed across an invalidation aren't stored.
+type questionHierarchyCache struct {
+	mu         sync.RWMutex
+	entries    map[string]cachedResponse
+	generation uint64
+	ttl        time.Duration
+}
+
+var hierarchyCache = &questionHierarchyCache{
+	entries: make(map[string]cachedResponse),
+	ttl:     questionHierarchyCacheTTLFromEnv(),
+}
+
+// questionHierarchyCacheTTLFromEnv reads QUESTION_HIERARCHY_CACHE_TTL_MINUTES, falling back to the default
+func questionHierarchyCacheTTLFromEnv() time.Duration {
+	value := os.Getenv("QUESTION_HIERARCHY_CACHE_TTL_MINUTES")
+	if value == "" {
+		return defaultQuestionHierarchyCacheTTL
+	}
+
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes <= 0 {
+		log.Printf("Invalid value %q for QUESTION_HIERARCHY_CACHE_TTL_MINUTES, using default of %s", value, defaultQuestionHierarchyCacheTTL)
+		return defaultQuestionHierarchyCacheTTL
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// InvalidateQuestionHierarchy drops every cached hierarchy response of this instance.
+// Call it after committing a write to the hierarchy or to the questions, the other instances
+// are told by the database triggers.
+func InvalidateQuestionHierarchy() {
+	hierarchyCache.mu.Lock()
+	defer hierarchyCache.mu.Unlock()
+
+	hierarchyCache.entries = make(map[string]cachedResponse)
+	hierarchyCache.generation++
+}
+
+func (cache *questionHierarchyCache) get(key string) (cachedResponse, bool) {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	entry, exists := cache.entries[key]
+	if !exists || time.Since(entry.storedAt) > cache.ttl {
+		return cachedResponse{}, false
+	}
+	return entry, true
+}
+
+func (cache *questionHierarchyCache) currentGeneration() uint64 {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	return cache.generation
+}
+
+// store keeps the response unless the cache was invalidated since the request started
+func (cache *questionHierarchyCache) store(key string, entry cachedResponse, generation uint64) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.generation != generation {
+		return
+	}
+	if _, exists := cache.entries[key]; !exists && len(cache.entries) >= maxQuestionHierarchyCacheEntries {
+		return
+	}
+	cache.entries[key] = entry
+}
+
+// etagFor returns a strong ETag for the body
+func etagFor(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether the If-None-Match header names the ETag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCachedResponse replays a response, or answers 304 if the client already has it
+func writeCachedResponse(c *gin.Context, entry cachedResponse) {
+	c.Header("ETag", entry.etag)
+	c.Header("Cache-Control", "no-cache") // Clients revalidate with If-None-Match every time
+	if etagMatches(c.GetHeader("If-None-Match"), entry.etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, entry.contentType, entry.body)
+}
+
+// bufferedResponseWriter holds the response of the handler back so that its ETag can be sent first
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (w *bufferedResponseWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedResponseWriter) WriteHeaderNow() {}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponseWriter) WriteString(data string) (int, error) {
+	return w.body.WriteString(data)
+}
+
+func (w *bufferedResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedResponseWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+func (w *bufferedResponseWriter) Size() int {
+	return w.body.Len()
+}
+
+// QuestionHierarchyCacheMiddleware serves the GET hierarchy routes from the cache with ETag/If-None-Match support.
+// Only successful responses are cached, errors (and 404s of empty levels) always reach the database.
+func QuestionHierarchyCacheMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		key := c.Request.URL.RequestURI()
+		if entry, cached := hierarchyCache.get(key); cached {
+			writeCachedResponse(c, entry)
+			c.Abort()
+			return
+		}
+
+		generation := hierarchyCache.currentGeneration()
+		original := c.Writer
+		buffered := &bufferedResponseWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = buffered
+		c.Next()
+		c.Writer = original
+
+		if buffered.status != http.StatusOK {
+			c.Status(buffered.status)
+			c.Writer.WriteHeaderNow()
+			if buffered.body.Len() > 0 {
+				_, _ = c.Writer.Write(buffered.body.Bytes())
+			}
+			return
+		}
+
+		entry := cachedResponse{
+			body:        buffered.body.Bytes(),
+			contentType: original.Header().Get("Content-Type"),
+			etag:        etagFor(buffered.body.Bytes()),
+			storedAt:    time.Now(),
+		}
+		hierarchyCache.store(key, entry, generation)
+		writeCachedResponse(c, entry)
+	}
+}
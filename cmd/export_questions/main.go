@@ -0,0 +1,70 @@
+// Command export_questions writes the questions under a hierarchy node to a file (or stdout)
+// in one of the formats the importers read back, for backups and moves between environments.
+//
+// Usage:
+//
+//	go run ./cmd/export_questions -format markdown -level niche -id 4 -out niche_4.md
+//
+// The zip format bundles the images of the questions, read from the S3 bucket.
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"server/config"
+	questionBankIO "server/question_bank_io"
+)
+
+func main() {
+	format := flag.String("format", questionBankIO.ExportFormatJSONL, "export format: "+strings.Join(questionBankIO.ExportFormats, ", "))
+	level := flag.String("level", "", "hierarchy level of the exported node: domain, subDomain, niche, difficulty or format (everything if empty)")
+	id := flag.Uint("id", 0, "ID of the exported node")
+	out := flag.String("out", "", "output file (stdout if empty)")
+	flag.Parse()
+
+	scope := questionBankIO.ExportScope{Level: *level, ID: uint32(*id)}
+	if err := scope.Validate(); err != nil {
+		log.Fatalf("Invalid export scope: %v", err)
+	}
+
+	// Load .env variables
+	if err := config.LoadEnvVariables(); err != nil {
+		log.Fatalf("Error loading environment variables: %v", err)
+	}
+
+	// Connect to PostgresDB
+	if err := config.ConnectPostgresDB(); err != nil {
+		log.Fatalf("Error connecting to the PostgresDB: %v", err)
+	}
+
+	// The images of the zip exports are read from the cloud storage
+	if *format == questionBankIO.ExportFormatZip {
+		if err := config.InitializeAWSSession(); err != nil {
+			log.Fatalf("Error initializing the AWS session: %v", err)
+		}
+	}
+
+	if err := questionBankIO.CheckExportScope(config.GetPostgresDBConnection(), scope, *format); err != nil {
+		log.Fatalf("Can't export as %s: %v", *format, err)
+	}
+
+	var output io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("Error creating the output file: %v", err)
+		}
+		defer file.Close()
+		output = file
+	}
+
+	exported, err := questionBankIO.ExportQuestions(config.GetPostgresDBConnection(), scope, *format, config.DownloadFromCloud, output)
+	if err != nil {
+		log.Fatalf("Export failed after %d questions: %v", exported, err)
+	}
+	log.Printf("Exported %d questions (%s)", exported, *format)
+}
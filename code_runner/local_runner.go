@@ -0,0 +1,294 @@
+package codeRunner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxOutputBytes        = 1 << 20 // Output kept from a test, a submission printing more gets a wrong answer
+	maxErrorBytes         = 4 << 10 // End of stderr kept to explain a runtime error
+	compileTimeLimit      = 30 * time.Second
+	compileMemoryLimitMB  = 2048
+	maxFileSizeKB         = 16 << 10 // Largest file a submission can write in its working directory
+	defaultMaxConcurrency = 2
+)
+
+// language tells how to compile and run the submissions of a language. {memory} in the commands is
+// replaced by the memory limit in MB.
+type language struct {
+	SourceFile string
+	Compile    []string // Empty for the interpreted languages
+	Run        []string
+	// LimitAddressSpace caps the virtual memory of the process. Runtimes that reserve a large address space
+	// up front (JVM, V8) are held by their own heap flag instead.
+	LimitAddressSpace bool
+}
+
+var languages = map[string]language{
+	"c": {
+		SourceFile:        "main.c",
+		Compile:           []string{"gcc", "-O2", "-std=c17", "-o", "main", "main.c", "-lm"},
+		Run:               []string{"./main"},
+		LimitAddressSpace: true,
+	},
+	"cpp": {
+		SourceFile:        "main.cpp",
+		Compile:           []string{"g++", "-O2", "-std=c++17", "-o", "main", "main.cpp"},
+		Run:               []string{"./main"},
+		LimitAddressSpace: true,
+	},
+	"java": {
+		SourceFile: "Main.java",
+		Compile:    []string{"javac", "Main.java"},
+		Run:        []string{"java", "-Xmx{memory}m", "-Xss64m", "-XX:+UseSerialGC", "Main"},
+	},
+	"javascript": {
+		SourceFile: "main.js",
+		Run:        []string{"node", "--max-old-space-size={memory}", "main.js"},
+	},
+	"python": {
+		SourceFile:        "main.py",
+		Run:               []string{"python3", "main.py"},
+		LimitAddressSpace: true,
+	},
+}
+
+// Languages lists the languages the submissions can be written in
+func Languages() []string {
+	names := make([]string, 0, len(languages))
+	for name := range languages {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// IsSupportedLanguage reports whether submissions in the language can be run
+func IsSupportedLanguage(name string) bool {
+	_, exists := languages[name]
+	return exists
+}
+
+// LocalRunner runs the submissions in subprocesses of the server, each isolated by the sandbox in its own
+// temporary directory with an empty environment, in its own process group, with its time, memory and file sizes
+// limited. The toolchains of the languages have to be installed on the server.
+type LocalRunner struct {
+	slots   chan struct{} // Submissions evaluated at the same time
+	sandbox sandbox
+}
+
+// NewLocalRunner returns a local runner evaluating at most maxConcurrent submissions at a time in the sandbox
+func NewLocalRunner(maxConcurrent int, sandbox sandbox) *LocalRunner {
+	return &LocalRunner{slots: make(chan struct{}, max(1, maxConcurrent)), sandbox: sandbox}
+}
+
+// maxConcurrentFromEnv reads the number of submissions evaluated at the same time from the environment
+func maxConcurrentFromEnv() int {
+	value := os.Getenv("CODE_RUNNER_MAX_CONCURRENT")
+	if value == "" {
+		return defaultMaxConcurrency
+	}
+	maxConcurrent, err := strconv.Atoi(value)
+	if err != nil || maxConcurrent <= 0 {
+		log.Printf("Invalid value %q for CODE_RUNNER_MAX_CONCURRENT, using default of %d", value, defaultMaxConcurrency)
+		return defaultMaxConcurrency
+	}
+	return maxConcurrent
+}
+
+func (r *LocalRunner) Run(ctx context.Context, submission Submission, tests []TestCase, limits Limits, keepOutput bool) (Result, error) {
+	lang, exists := languages[submission.Language]
+	if !exists {
+		return Result{}, fmt.Errorf("%w %q, expected one of %s", ErrUnsupportedLanguage, submission.Language, strings.Join(Languages(), ", "))
+	}
+	limits = NormalizeLimits(limits)
+	if err := r.sandbox.check(); err != nil {
+		return Result{}, err
+	}
+
+	select {
+	case r.slots <- struct{}{}:
+		defer func() { <-r.slots }()
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+
+	dir, err := os.MkdirTemp("", "code-runner-")
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to create the sandbox directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, lang.SourceFile), []byte(submission.Code), 0o600); err != nil {
+		return Result{}, fmt.Errorf("failed to write the submission: %w", err)
+	}
+
+	result := Result{Tests: make([]TestResult, 0, len(tests))}
+
+	// A submission that doesn't compile fails every test with the compiler output
+	if len(lang.Compile) > 0 {
+		compiled := r.runSandboxed(ctx, dir, lang.Compile, "", compileTimeLimit, compileMemoryLimitMB, false)
+		if compiled.err != nil {
+			return Result{}, compiled.err
+		}
+		if compiled.timedOut || compiled.exitErr != nil {
+			compileError := TestResult{Verdict: VerdictRuntimeError, Error: "compilation failed: " + compiled.stderr}
+			if compiled.timedOut {
+				compileError.Error = "compilation timed out"
+			}
+			for range tests {
+				result.Tests = append(result.Tests, compileError)
+			}
+			result.summarize()
+			return result, nil
+		}
+	}
+
+	command := make([]string, len(lang.Run))
+	for i, arg := range lang.Run {
+		command[i] = strings.ReplaceAll(arg, "{memory}", strconv.Itoa(limits.MemoryLimitMB))
+	}
+
+	for _, test := range tests {
+		run := r.runSandboxed(ctx, dir, command, test.Input, limits.TimeLimit, limits.MemoryLimitMB, lang.LimitAddressSpace)
+		if run.err != nil {
+			return Result{}, run.err
+		}
+
+		testResult := TestResult{TimeMs: run.duration.Milliseconds()}
+		switch {
+		case run.timedOut:
+			testResult.Verdict = VerdictTimeLimitExceeded
+		case run.exitErr != nil:
+			testResult.Verdict, testResult.Error = VerdictRuntimeError, strings.TrimSpace(run.exitErr.Error()+"\n"+run.stderr)
+		case !run.outputTruncated && OutputMatches(run.stdout, test.ExpectedOutput):
+			testResult.Verdict = VerdictAccepted
+		default:
+			testResult.Verdict = VerdictWrongAnswer
+		}
+		if keepOutput {
+			testResult.Output = run.stdout
+		}
+		result.Tests = append(result.Tests, testResult)
+	}
+
+	result.summarize()
+	return result, nil
+}
+
+// sandboxedRun is the outcome of a sandboxed command
+type sandboxedRun struct {
+	stdout          string
+	stderr          string // The end of stderr
+	outputTruncated bool
+	duration        time.Duration
+	timedOut        bool
+	exitErr         error // The command ran but failed
+	err             error // The command couldn't be run
+}
+
+// runSandboxed runs a command in the sandbox directory with the input on stdin. The limits are applied by the
+// shell (ulimit) before the command replaces it, the whole process group is killed once the time runs out.
+func (r *LocalRunner) runSandboxed(ctx context.Context, dir string, command []string, input string, timeLimit time.Duration, memoryLimitMB int, limitAddressSpace bool) sandboxedRun {
+	limitsScript := fmt.Sprintf("ulimit -f %d; ulimit -t %d", maxFileSizeKB, int(timeLimit.Seconds())+1)
+	if limitAddressSpace {
+		limitsScript += fmt.Sprintf("; ulimit -v %d", memoryLimitMB*1024)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeLimit)
+	defer cancel()
+
+	sandboxed := r.sandbox.wrap(dir, append([]string{"/bin/sh", "-c", limitsScript + `; exec "$@"`, "sh"}, command...))
+	cmd := exec.Command(sandboxed[0], sandboxed[1:]...)
+	cmd.Dir = dir
+	cmd.Env = sandboxEnv(dir)
+	cmd.Stdin = strings.NewReader(input)
+	stdout := &limitedBuffer{limit: maxOutputBytes}
+	stderr := &limitedBuffer{limit: maxErrorBytes, keepTail: true}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	isolateProcessGroup(cmd)
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return sandboxedRun{err: fmt.Errorf("failed to start %s: %w", command[0], err)}
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var waitErr error
+	timedOut := false
+	select {
+	case waitErr = <-done:
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		waitErr = <-done
+		timedOut = true
+	}
+
+	run := sandboxedRun{
+		stdout:          stdout.String(),
+		stderr:          stderr.String(),
+		outputTruncated: stdout.truncated,
+		duration:        time.Since(start),
+		timedOut:        timedOut,
+	}
+	if timedOut && ctx.Err() != context.DeadlineExceeded {
+		// The request was cancelled, the test wasn't really run to the end
+		return sandboxedRun{err: ctx.Err()}
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(waitErr, &exitErr) {
+		run.exitErr = exitErr
+		// The CPU time limit of ulimit kills with SIGXCPU, it's a time limit too
+		if exitErr.String() == "signal: CPU time limit exceeded" {
+			run.timedOut = true
+		}
+	} else if waitErr != nil && !timedOut {
+		run.err = fmt.Errorf("failed to run %s: %w", command[0], waitErr)
+	}
+	return run
+}
+
+// limitedBuffer keeps the first (or last, with keepTail) limit bytes written to it
+type limitedBuffer struct {
+	buffer    bytes.Buffer
+	limit     int
+	keepTail  bool
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	written := len(p)
+	if b.keepTail {
+		b.buffer.Write(p)
+		if overflow := b.buffer.Len() - b.limit; overflow > 0 {
+			b.buffer.Next(overflow)
+			b.truncated = true
+		}
+		return written, nil
+	}
+
+	if room := b.limit - b.buffer.Len(); len(p) > room {
+		p = p[:max(0, room)]
+		b.truncated = true
+	}
+	b.buffer.Write(p)
+	return written, nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buffer.String()
+}
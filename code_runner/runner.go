@@ -0,0 +1,117 @@
+// Package codeRunner evaluates the submissions to coding questions against their test cases.
+// Evaluation goes through the Runner interface so that the local sandbox can be swapped for a remote judge.
+package codeRunner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Verdict is the outcome of a submission on a test case
+type Verdict string
+
+const (
+	VerdictAccepted          Verdict = "AC"  // Output matches the expected output
+	VerdictWrongAnswer       Verdict = "WA"  // Output differs from the expected output
+	VerdictTimeLimitExceeded Verdict = "TLE" // Still running when the time limit ran out
+	VerdictRuntimeError      Verdict = "RE"  // Crashed, ran out of memory, exited with an error or didn't compile
+)
+
+// Default and highest limits of a test
+const (
+	DefaultTimeLimit     = 2 * time.Second
+	DefaultMemoryLimitMB = 256
+	MaxTimeLimit         = 10 * time.Second
+	MaxMemoryLimitMB     = 1024
+)
+
+var (
+	// ErrUnsupportedLanguage is returned for the submissions in a language the runner can't run
+	ErrUnsupportedLanguage = errors.New("unsupported language")
+	// ErrSandboxUnavailable is returned when the submissions can't be isolated from the server, they aren't run then
+	ErrSandboxUnavailable = errors.New("code sandbox unavailable")
+)
+
+// Submission is the code submitted for a coding question
+type Submission struct {
+	Language string // One of Languages()
+	Code     string
+}
+
+// TestCase is an input fed to the submission on stdin along with the output expected on stdout
+type TestCase struct {
+	Input          string
+	ExpectedOutput string
+}
+
+// Limits bound the resources of every run of a submission
+type Limits struct {
+	TimeLimit     time.Duration // Wall-clock time of a single test
+	MemoryLimitMB int           // Memory of a single test
+}
+
+// TestResult is the verdict of a submission on a test case
+type TestResult struct {
+	Verdict Verdict `json:"verdict"`
+	TimeMs  int64   `json:"timeMs"`
+	Output  string  `json:"output,omitempty"` // What the submission printed, only kept when asked for
+	Error   string  `json:"error,omitempty"`  // The end of stderr, or the compiler output, for runtime errors
+}
+
+// Result is the outcome of a submission on all the test cases, in the order they were given
+type Result struct {
+	Verdict Verdict      `json:"verdict"` // AC when every test passed, otherwise the verdict of the first failed test
+	Passed  int          `json:"passed"`
+	Tests   []TestResult `json:"tests"`
+}
+
+// Runner evaluates submissions. Run returns an error only when the submission couldn't be evaluated,
+// a submission that fails its tests is a result with the verdict of each test. keepOutput keeps what the
+// submission printed on each test, for the sample tests shown to the students.
+type Runner interface {
+	Run(ctx context.Context, submission Submission, tests []TestCase, limits Limits, keepOutput bool) (Result, error)
+}
+
+// DefaultRunner is the runner used to evaluate the coding questions
+var DefaultRunner Runner = NewLocalRunner(maxConcurrentFromEnv(), sandboxFromEnv())
+
+// NormalizeLimits falls back to the default limits and caps them
+func NormalizeLimits(limits Limits) Limits {
+	if limits.TimeLimit <= 0 {
+		limits.TimeLimit = DefaultTimeLimit
+	}
+	limits.TimeLimit = min(limits.TimeLimit, MaxTimeLimit)
+	if limits.MemoryLimitMB <= 0 {
+		limits.MemoryLimitMB = DefaultMemoryLimitMB
+	}
+	limits.MemoryLimitMB = min(limits.MemoryLimitMB, MaxMemoryLimitMB)
+	return limits
+}
+
+// OutputMatches compares an output with the expected one, ignoring the trailing whitespace of the lines
+// and the trailing blank lines
+func OutputMatches(output, expected string) bool {
+	return normalizeOutput(output) == normalizeOutput(expected)
+}
+
+func normalizeOutput(output string) string {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// summarize sets the overall verdict and the number of passed tests of a result
+func (r *Result) summarize() {
+	r.Verdict, r.Passed = VerdictAccepted, 0
+	for _, test := range r.Tests {
+		if test.Verdict == VerdictAccepted {
+			r.Passed++
+		} else if r.Verdict == VerdictAccepted {
+			r.Verdict = test.Verdict
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package codeRunner
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOutputMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+		want     bool
+	}{
+		{"same output", "3\n", "3\n", true},
+		{"missing final newline", "3", "3\n", true},
+		{"trailing blank lines", "1\n2\n\n\n", "1\n2", true},
+		{"trailing spaces and tabs", "1 2 \t\n3  ", "1 2\n3", true},
+		{"windows line endings", "1\r\n2\r\n", "1\n2\n", true},
+		{"leading spaces count", " 3", "3", false},
+		{"blank line between lines counts", "1\n\n2", "1\n2", false},
+		{"different output", "4", "3", false},
+		{"nothing printed", "", "3", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := OutputMatches(test.output, test.expected); got != test.want {
+				t.Errorf("OutputMatches(%q, %q) = %v, want %v", test.output, test.expected, got, test.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeLimits(t *testing.T) {
+	tests := []struct {
+		limits Limits
+		want   Limits
+	}{
+		{Limits{}, Limits{TimeLimit: DefaultTimeLimit, MemoryLimitMB: DefaultMemoryLimitMB}},
+		{Limits{TimeLimit: -time.Second, MemoryLimitMB: -1}, Limits{TimeLimit: DefaultTimeLimit, MemoryLimitMB: DefaultMemoryLimitMB}},
+		{Limits{TimeLimit: time.Second, MemoryLimitMB: 64}, Limits{TimeLimit: time.Second, MemoryLimitMB: 64}},
+		{Limits{TimeLimit: time.Minute, MemoryLimitMB: 4096}, Limits{TimeLimit: MaxTimeLimit, MemoryLimitMB: MaxMemoryLimitMB}},
+	}
+	for _, test := range tests {
+		if got := NormalizeLimits(test.limits); got != test.want {
+			t.Errorf("NormalizeLimits(%+v) = %+v, want %+v", test.limits, got, test.want)
+		}
+	}
+}
+
+func TestResultSummarize(t *testing.T) {
+	tests := []struct {
+		name        string
+		verdicts    []Verdict
+		wantVerdict Verdict
+		wantPassed  int
+	}{
+		{"every test passed", []Verdict{VerdictAccepted, VerdictAccepted}, VerdictAccepted, 2},
+		{"first failed test gives the verdict", []Verdict{VerdictAccepted, VerdictTimeLimitExceeded, VerdictWrongAnswer}, VerdictTimeLimitExceeded, 1},
+		{"no tests", nil, VerdictAccepted, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := Result{Verdict: VerdictRuntimeError, Passed: 7}
+			for _, verdict := range test.verdicts {
+				result.Tests = append(result.Tests, TestResult{Verdict: verdict})
+			}
+			result.summarize()
+			if result.Verdict != test.wantVerdict || result.Passed != test.wantPassed {
+				t.Errorf("summarize() = %s, %d passed, want %s, %d passed", result.Verdict, result.Passed, test.wantVerdict, test.wantPassed)
+			}
+		})
+	}
+}
+
+func TestSandboxWrap(t *testing.T) {
+	args := []string{"python3", "main.py"}
+
+	if got := sandboxNone.wrap("/tmp/run", args); !reflect.DeepEqual(got, args) {
+		t.Errorf("sandboxNone.wrap() = %q, want the command unchanged %q", got, args)
+	}
+
+	command := sandboxBubblewrap.wrap("/tmp/run", args)
+	if command[0] != "bwrap" || !reflect.DeepEqual(command[len(command)-len(args):], args) {
+		t.Fatalf("sandboxBubblewrap.wrap() = %q, want bwrap running %q", command, args)
+	}
+	for _, flag := range []string{"--unshare-all", "--clearenv", "--die-with-parent"} {
+		if !slices.Contains(command, flag) {
+			t.Errorf("sandboxBubblewrap.wrap() = %q, missing %s", command, flag)
+		}
+	}
+	if i := slices.Index(command, "--bind"); i < 0 || command[i+1] != "/tmp/run" || command[i+2] != sandboxWorkDir {
+		t.Errorf("sandboxBubblewrap.wrap() = %q, want the submission directory bound to %s", command, sandboxWorkDir)
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	tests := []struct {
+		name          string
+		keepTail      bool
+		writes        []string
+		want          string
+		wantTruncated bool
+	}{
+		{"within the limit", false, []string{"ab", "cd"}, "abcd", false},
+		{"keeps the head", false, []string{"abc", "def"}, "abcd", true},
+		{"keeps the tail", true, []string{"abc", "def"}, "cdef", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buffer := &limitedBuffer{limit: 4, keepTail: test.keepTail}
+			for _, write := range test.writes {
+				if n, err := buffer.Write([]byte(write)); n != len(write) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v, want %d, nil", write, n, err, len(write))
+				}
+			}
+			if got := buffer.String(); got != test.want || buffer.truncated != test.wantTruncated {
+				t.Errorf("buffer = %q (truncated %v), want %q (truncated %v)", got, buffer.truncated, test.want, test.wantTruncated)
+			}
+		})
+	}
+}
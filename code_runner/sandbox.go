@@ -0,0 +1,96 @@
+package codeRunner
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// sandbox isolates the processes of the submissions from the server, the CODE_RUNNER_SANDBOX environment
+// variable picks it
+type sandbox string
+
+const (
+	// sandboxBubblewrap runs the submissions with bubblewrap as nobody, in their own user, mount, pid, IPC, UTS
+	// and network namespaces: no network, no capabilities, and only the toolchains (read-only) and their own
+	// directory in sight. The server needs bubblewrap installed and user namespaces allowed.
+	sandboxBubblewrap sandbox = "bwrap"
+	// sandboxNone runs the submissions as the server user with the filesystem and the network in reach,
+	// for the development machines without bubblewrap only
+	sandboxNone sandbox = "none"
+)
+
+// sandboxWorkDir is where the directory of a submission is mounted in the sandbox
+const sandboxWorkDir = "/sandbox"
+
+// nobodyID is the user and group the submissions run as in the sandbox
+const nobodyID = "65534"
+
+// toolchainPaths are mounted read-only in the sandbox, the ones missing on the server are skipped.
+// Nothing else of the server filesystem (configuration, secrets, sources) is visible to a submission.
+var toolchainPaths = []string{"/usr", "/bin", "/lib", "/lib64", "/etc/alternatives", "/etc/ld.so.cache"}
+
+// sandboxFromEnv reads the sandbox of the submissions from the environment, bubblewrap unless told otherwise
+func sandboxFromEnv() sandbox {
+	value := os.Getenv("CODE_RUNNER_SANDBOX")
+	switch sandbox(value) {
+	case "", sandboxBubblewrap:
+		return sandboxBubblewrap
+	case sandboxNone:
+		log.Println("CODE_RUNNER_SANDBOX=none: submissions to coding questions run unisolated, as the server user")
+		return sandboxNone
+	default:
+		log.Printf("Invalid value %q for CODE_RUNNER_SANDBOX, using %s", value, sandboxBubblewrap)
+		return sandboxBubblewrap
+	}
+}
+
+// check returns ErrSandboxUnavailable when the submissions can't be isolated on this server, they aren't run then
+func (s sandbox) check() error {
+	if s != sandboxBubblewrap {
+		return nil
+	}
+	if _, err := exec.LookPath("bwrap"); err != nil {
+		return fmt.Errorf("%w: bubblewrap isn't installed: %v", ErrSandboxUnavailable, err)
+	}
+	return nil
+}
+
+// wrap returns the command running args in the sandbox, with the submission directory dir as working directory
+func (s sandbox) wrap(dir string, args []string) []string {
+	if s == sandboxNone {
+		return args
+	}
+
+	command := []string{
+		"bwrap",
+		"--unshare-all", "--unshare-user", // --unshare-all takes the network away too
+		"--uid", nobodyID, "--gid", nobodyID,
+		"--cap-drop", "ALL",
+		"--die-with-parent",
+		"--new-session",
+		"--clearenv",
+	}
+	for _, path := range toolchainPaths {
+		command = append(command, "--ro-bind-try", path, path)
+	}
+	command = append(command,
+		"--proc", "/proc",
+		"--dev", "/dev",
+		"--tmpfs", "/tmp",
+		"--bind", dir, sandboxWorkDir,
+		"--chdir", sandboxWorkDir,
+	)
+	for _, variable := range sandboxEnv(sandboxWorkDir) {
+		name, value, _ := strings.Cut(variable, "=")
+		command = append(command, "--setenv", name, value)
+	}
+	return append(command, args...)
+}
+
+// sandboxEnv is the whole environment of a submission whose home is the directory
+func sandboxEnv(home string) []string {
+	return []string{"PATH=/usr/local/bin:/usr/bin:/bin", "HOME=" + home, "LANG=C.UTF-8"}
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package codeRunner
+
+import "os/exec"
+
+// isolateProcessGroup has no process groups to rely on outside of unix, only the command itself is killed
+func isolateProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the command
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
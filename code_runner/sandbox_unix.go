@@ -0,0 +1,20 @@
+//go:build unix
+
+package codeRunner
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// isolateProcessGroup starts the command in its own process group so that the processes it forks can be killed with it
+func isolateProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills the command along with every process it forked
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -2,17 +2,25 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
 var AWSClient *s3.Client
 
+// ErrCloudStorageUnavailable is returned by the cloud storage helpers while the AWS session isn't initialized
+var ErrCloudStorageUnavailable = errors.New("cloud storage is not configured")
+
 // InitializeAWSSession initializes the AWS session
 func InitializeAWSSession() error {
 	ctx := context.TODO()
@@ -25,18 +33,92 @@ func InitializeAWSSession() error {
 		// config.WithSharedConfigProfile("customProfile"),
 	)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	// Check the credentials before the S3 client is handed out
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return fmt.Errorf("failed to verify AWS credentials: %w", err)
+	}
+
+	AWSClient = s3.NewFromConfig(cfg)
+
+	log.Printf("AWS session initialized successfully (account: %s, arn: %s)", aws.ToString(identity.Account), aws.ToString(identity.Arn))
+	return nil
+}
+
+// UploadToCloud stores the content under the key in the S3 bucket and returns its location
+func UploadToCloud(ctx context.Context, body io.Reader, key, contentType string) (string, error) {
+	if AWSClient == nil {
+		return "", ErrCloudStorageUnavailable
 	}
 
-	AWSClient := sts.NewFromConfig(cfg)
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(os.Getenv("S3_BUCKET_NAME")),
+		Key:    aws.String(key),
+		Body:   body,
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
 
-	identity, err := AWSClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	result, err := manager.NewUploader(AWSClient).Upload(ctx, input)
 	if err != nil {
-		log.Fatal(err)
+		// Handle multi-part upload-specific errors if applicable
+		var multiUploadError manager.MultiUploadFailure
+		if errors.As(err, &multiUploadError) {
+			log.Printf("MultiUpload failure detected, UploadID: %s, Error: %s", multiUploadError.UploadID(), err)
+		}
+		return "", fmt.Errorf("failed to upload file to S3: %w", err)
 	}
+	return result.Location, nil
+}
 
-	fmt.Printf("Account: %s, Arn: %s", aws.ToString(identity.Account), aws.ToString(identity.Arn))
+// DownloadFromCloud reads the object stored under the key in the S3 bucket, the caller closes the returned body
+func DownloadFromCloud(ctx context.Context, key string) (io.ReadCloser, error) {
+	if AWSClient == nil {
+		return nil, ErrCloudStorageUnavailable
+	}
 
-	log.Println("AWS session initialized successfully")
+	result, err := AWSClient.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(os.Getenv("S3_BUCKET_NAME")),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file from S3: %w", err)
+	}
+	return result.Body, nil
+}
+
+// DeleteFromCloud removes the object stored under the key from the S3 bucket
+func DeleteFromCloud(ctx context.Context, key string) error {
+	if AWSClient == nil {
+		return ErrCloudStorageUnavailable
+	}
+
+	if _, err := AWSClient.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(os.Getenv("S3_BUCKET_NAME")),
+		Key:    aws.String(key),
+	}); err != nil {
+		return fmt.Errorf("failed to delete file from S3: %w", err)
+	}
 	return nil
 }
+
+// PresignCloudURL returns a URL that reads the object stored under the key until it expires,
+// so that private files can be served without making the bucket public
+func PresignCloudURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if AWSClient == nil {
+		return "", ErrCloudStorageUnavailable
+	}
+
+	request, err := s3.NewPresignClient(AWSClient).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(os.Getenv("S3_BUCKET_NAME")),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign S3 URL: %w", err)
+	}
+	return request.URL, nil
+}
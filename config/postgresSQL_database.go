@@ -9,13 +9,16 @@ import (
 	"os"
 	"time"
 
+	"server/cache"
 	common_tables "server/models/common"
 	question_hierarchy "server/models/question_bank/question_hierarchy"
 	question_type "server/models/question_bank/question_type"
 	student_tables "server/models/student_psql"
+	"server/postgresql_database/triggers"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -108,6 +111,28 @@ func grantDefaultTablePrivileges(tx *gorm.DB, schemaName string) error {
 	return nil
 }
 
+// refreshCheckConstraints drops and recreates the check constraints of a model.
+// AutoMigrate never updates an existing check constraint, so this applies changed `check:` tags
+// (e.g. a newly allowed status) to tables that already exist.
+func refreshCheckConstraints(tx *gorm.DB, model interface{}) error {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return fmt.Errorf("failed to parse model: %w", err)
+	}
+
+	for name := range stmt.Schema.ParseCheckConstraints() {
+		if err := tx.Exec("ALTER TABLE ? DROP CONSTRAINT IF EXISTS ?",
+			clause.Table{Name: stmt.Schema.Table}, clause.Column{Name: name}).Error; err != nil {
+			return fmt.Errorf("failed to drop check constraint %s: %w", name, err)
+		}
+		if err := tx.Migrator().CreateConstraint(model, name); err != nil {
+			return fmt.Errorf("failed to create check constraint %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
 // // TODO: Update and include for partitioning hierarchy
 // // setupPartitioning creates partitioned tables for the question hierarchy
 // func setupPartitioning(db *gorm.DB) error {
@@ -140,6 +165,29 @@ func grantDefaultTablePrivileges(tx *gorm.DB, schemaName string) error {
 // 	return nil
 // }
 
+// questionHierarchyTables lists the tables whose writes change the cached question hierarchy
+func questionHierarchyTables() []string {
+	tables := []string{
+		question_hierarchy.QuestionDomainsTable{}.TableName(),
+		question_hierarchy.QuestionSubDomainsTable{}.TableName(),
+		question_hierarchy.QuestionNicheTable{}.TableName(),
+		question_hierarchy.QuestionDifficultyLevelTable{}.TableName(),
+		question_hierarchy.QuestionFormatTable{}.TableName(),
+	}
+	return append(tables, questionTables()...)
+}
+
+// questionTables lists the table of every question format
+func questionTables() []string {
+	var tables []string
+	for _, format := range question_type.QuestionFormats {
+		if questionTable, err := question_type.QuestionTableForFormat(format); err == nil {
+			tables = append(tables, questionTable)
+		}
+	}
+	return tables
+}
+
 // runAutoMigrations runs auto migrations to create or update the database schema based on the models
 func runAutoMigrations() error {
 	// Define the schemas you need to ensure exist
@@ -180,6 +228,8 @@ func runAutoMigrations() error {
 			&student_tables.StudentScholarshipDetailsTable{},
 			&student_tables.StudentLeaderboardRecordTable{},
 			&student_tables.StudentPracticeSessionRecordTable{},
+			&student_tables.PracticeSessionReaperRunTable{},
+			&student_tables.StudentNicheAbilityTable{},
 		); err != nil {
 			return fmt.Errorf("failed to auto migrate student models: %w", err)
 		}
@@ -199,10 +249,16 @@ func runAutoMigrations() error {
 			&student_tables.StudentCertificationLookup{},
 			&student_tables.EnrollmentMasterLookupTable{},
 			&student_tables.StudentPracticeSessionLookupTable{},
+			&student_tables.StudentPracticeSessionQuestionTable{},
+			&student_tables.StudentPracticeSessionResponseTable{},
 			&student_tables.StudentLeaderboardLookupTable{},
 		); err != nil {
 			return fmt.Errorf("failed to auto migrate dependent student models: %w", err)
 		}
+		// Apply the statuses added to the practice session lookup table after it was created
+		if err := refreshCheckConstraints(tx, &student_tables.StudentPracticeSessionLookupTable{}); err != nil {
+			return fmt.Errorf("failed to refresh practice session lookup constraints: %w", err)
+		}
 		// The transaction will be committed automatically if no error occurs
 		return nil
 	})
@@ -221,6 +277,10 @@ func runAutoMigrations() error {
 		); err != nil {
 			return fmt.Errorf("failed to auto migrate question hierarchy models: %w", err)
 		}
+		// Apply the formats added to the format nodes after they were created
+		if err := refreshCheckConstraints(tx, &question_hierarchy.QuestionFormatTable{}); err != nil {
+			return fmt.Errorf("failed to refresh question format constraints: %w", err)
+		}
 		// The transaction will be committed automatically if no error occurs
 		return nil
 	})
@@ -230,14 +290,100 @@ func runAutoMigrations() error {
 
 	// Migrate question type schema tables
 	err = postgresDBConnection.Transaction(func(tx *gorm.DB) error {
-		if err := tx.AutoMigrate(
+		// Questions stored before the review workflow were already live, they are published once the
+		// status column is added instead of falling back to its DRAFT default
+		questionModels := []interface{}{
 			&question_type.TextBasedQuestion{},
 			&question_type.TrueFalseQuestion{},
 			&question_type.FillInTheBlankQuestion{},
 			&question_type.MCQQuestion{},
-		); err != nil {
+			&question_type.NumericQuestion{},
+			&question_type.CodingQuestion{},
+			&question_type.MatchingQuestion{},
+			&question_type.OrderingQuestion{},
+		}
+		var unpublishedModels []interface{}
+		for _, model := range questionModels {
+			if tx.Migrator().HasTable(model) && !tx.Migrator().HasColumn(model, "Status") {
+				unpublishedModels = append(unpublishedModels, model)
+			}
+		}
+
+		// MCQ questions stored before their correct options were kept get them from their answer text
+		backfillMCQCorrectOptions := tx.Migrator().HasTable(&question_type.MCQQuestion{}) &&
+			!tx.Migrator().HasColumn(&question_type.MCQQuestion{}, "CorrectOptionIndices")
+
+		if err := tx.AutoMigrate(append(questionModels,
+			&question_type.QuestionRevision{},
+			&question_type.QuestionStatusChange{},
+			&question_type.QuestionTag{},
+			&question_type.QuestionTagLink{},
+			&question_type.QuestionPassage{},
+			&question_type.QuestionPassageItem{},
+			&question_type.QuestionAttachment{},
+			&question_type.QuestionStatistics{},
+		)...); err != nil {
 			return fmt.Errorf("failed to auto migrate question type models: %w", err)
 		}
+
+		for _, model := range unpublishedModels {
+			if err := tx.Model(model).Where("1 = 1").UpdateColumn("status", question_type.QuestionStatusPublished).Error; err != nil {
+				return fmt.Errorf("failed to publish the existing questions: %w", err)
+			}
+		}
+		if backfillMCQCorrectOptions {
+			unmatched, err := question_type.BackfillMCQCorrectOptions(tx)
+			if err != nil {
+				return err
+			}
+			if unmatched > 0 {
+				log.Printf("%d MCQ questions have an answer that matches none of their options, fix them before they are saved again", unmatched)
+			}
+		}
+		// Questions written before revisions were tracked get their BASELINE revision now rather than when first served
+		backfilled, err := question_type.BackfillBaselineRevisions(tx)
+		if err != nil {
+			return err
+		}
+		if backfilled > 0 {
+			log.Printf("Recorded the BASELINE revision of %d questions", backfilled)
+		}
+		// The transaction will be committed automatically if no error occurs
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Keep the full-text search vectors of the questions up to date
+	err = postgresDBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := triggers.MaintainQuestionSearchVectors(tx, questionTables()); err != nil {
+			return fmt.Errorf("failed to create question search vector triggers: %w", err)
+		}
+		// The transaction will be committed automatically if no error occurs
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Index the question texts for the duplicate lookups of the imports
+	err = postgresDBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := triggers.MaintainQuestionDuplicateIndexes(tx, questionTables()); err != nil {
+			return fmt.Errorf("failed to create question duplicate indexes: %w", err)
+		}
+		// The transaction will be committed automatically if no error occurs
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Tell the server instances to drop their cached hierarchy whenever it (or its questions) is written
+	err = postgresDBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := triggers.NotifyQuestionHierarchyChanges(tx, cache.QuestionHierarchyChannel, questionHierarchyTables()); err != nil {
+			return fmt.Errorf("failed to create question hierarchy notification triggers: %w", err)
+		}
 		// The transaction will be committed automatically if no error occurs
 		return nil
 	})
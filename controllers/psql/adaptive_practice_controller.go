@@ -0,0 +1,543 @@
+package controllersNew
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"server/config"
+	question_hierarchy "server/models/question_bank/question_hierarchy"
+	question_type "server/models/question_bank/question_type"
+	requests "server/models/requests"
+	"server/models/response"
+	student_psql "server/models/student_psql"
+	"server/utils"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Adaptive practice sessions serve one question at a time from a niche. Each question is taken from the difficulty
+// level whose rating is the closest to the ability of the student in the niche (the next closest ones when it has
+// no question left), and every graded answer updates the ability before the next question is selected.
+// Questions of passages are left out, their groups are served whole by the fixed sessions only.
+
+var (
+	errNoAdaptiveQuestions        = errors.New("no question left to serve in the niche for the format")
+	errAdaptiveNicheNotFound      = errors.New("niche not found under the domain and sub-domain")
+	errPracticeSessionNotAdaptive = errors.New("the practice session isn't an adaptive session")
+	errNotCurrentQuestion         = errors.New("only the question the session is waiting on can be answered")
+)
+
+// adaptiveQuestionCounts are the lengths an adaptive practice session can have
+var adaptiveQuestionCounts = []int{10, 30, 60}
+
+// adaptiveQuestion is a question selected for an adaptive practice session along with the nodes it comes from
+type adaptiveQuestion struct {
+	Question          response.PracticeQuestionResponse
+	DifficultyLevel   string
+	DifficultyLevelID uint32
+	FormatID          uint32
+}
+
+// fetchNicheAbility returns the ability of the student in the niche, the initial one if they never answered
+// a question of it. lock holds the row of the ability until the end of the transaction.
+func fetchNicheAbility(tx *gorm.DB, enrollmentNo string, nicheID uint32, lock bool) (student_psql.StudentNicheAbilityTable, error) {
+	ability := student_psql.StudentNicheAbilityTable{
+		EnrollmentNo:    enrollmentNo,
+		QuestionNicheID: nicheID,
+		Rating:          utils.InitialAbilityRating,
+	}
+	query := tx
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	if err := query.Where("enrollment_no = ? AND question_niche_id = ?", enrollmentNo, nicheID).
+		Limit(1).
+		Find(&ability).Error; err != nil {
+		return ability, fmt.Errorf("failed to fetch ability estimate: %w", err)
+	}
+	return ability, nil
+}
+
+// toNicheAbilityResponse builds the ability DTO along with the difficulty level that matches it
+func toNicheAbilityResponse(ability student_psql.StudentNicheAbilityTable, nicheName string) response.NicheAbilityResponse {
+	return response.NicheAbilityResponse{
+		QuestionNicheID: ability.QuestionNicheID,
+		NicheName:       nicheName,
+		Rating:          ability.Rating,
+		GradedAnswers:   ability.GradedAnswers,
+		DifficultyLevel: utils.DifficultyLevelsForAbility(ability.Rating)[0],
+		UpdatedAt:       ability.UpdatedAt,
+	}
+}
+
+// selectAdaptiveQuestion selects the next question of the format for a student of the ability in the niche, from the
+// difficulty level that matches the ability best that still has a question to serve. The selection request gives
+// the student and the exclusions, errNoAdaptiveQuestions is returned when every level ran out.
+func selectAdaptiveQuestion(tx *gorm.DB, selection questionSelectionRequest, nicheID uint32, format string, ability float64) (adaptiveQuestion, error) {
+	for _, level := range utils.DifficultyLevelsForAbility(ability) {
+		// Sibling nodes have distinct names, the niche has at most one format node per level and format
+		var node struct {
+			QuestionFormatID          uint32
+			QuestionDifficultyLevelID uint32
+		}
+		result := tx.Table(question_hierarchy.QuestionFormatTable{}.TableName()+" f").
+			Select("f.question_format_id, f.question_difficulty_level_id").
+			Joins(fmt.Sprintf("JOIN %s dl ON dl.question_difficulty_level_id = f.question_difficulty_level_id", question_hierarchy.QuestionDifficultyLevelTable{}.TableName())).
+			Where("dl.question_niche_id = ? AND dl.difficulty_level = ? AND f.format = ?", nicheID, level, format).
+			Limit(1).
+			Scan(&node)
+		if result.Error != nil {
+			return adaptiveQuestion{}, fmt.Errorf("failed to fetch the %s format node: %w", level, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		selection.QuestionFormat = format
+		selection.FormatID = node.QuestionFormatID
+		selection.Count = 1
+		questions, err := selectPracticeQuestions(tx, selection)
+		if err != nil {
+			return adaptiveQuestion{}, err
+		}
+		if len(questions) == 0 {
+			continue
+		}
+		return adaptiveQuestion{
+			Question:          questions[0],
+			DifficultyLevel:   level,
+			DifficultyLevelID: node.QuestionDifficultyLevelID,
+			FormatID:          node.QuestionFormatID,
+		}, nil
+	}
+	return adaptiveQuestion{}, errNoAdaptiveQuestions
+}
+
+// serveAdaptiveQuestion records the question as served at the position in the session and attaches its images
+func serveAdaptiveQuestion(ctx context.Context, tx *gorm.DB, practiceSessionID uint32, serveOrder int, next *adaptiveQuestion) error {
+	questions := []response.PracticeQuestionResponse{next.Question}
+	if err := storeServedQuestions(tx, practiceSessionID, serveOrder, questions); err != nil {
+		return err
+	}
+	if err := attachPracticeQuestionAttachments(ctx, tx, questions); err != nil {
+		return err
+	}
+	next.Question = questions[0]
+	return nil
+}
+
+// questionDifficultyRating returns the rating of a question: the one of its empirical difficulty once its
+// statistics calibrated it (QuestionStatistics), the one of the difficulty level of its node otherwise
+func questionDifficultyRating(tx *gorm.DB, formatID, questionID uint32) (float64, error) {
+	var statistics question_type.QuestionStatistics
+	if err := tx.Select("empirical_difficulty").
+		Where("question_format_id = ? AND question_id = ?", formatID, questionID).
+		Limit(1).
+		Find(&statistics).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch question statistics: %w", err)
+	}
+	if rating, exists := utils.DifficultyRatings[statistics.EmpiricalDifficulty]; exists {
+		return rating, nil
+	}
+
+	var levels []string
+	if err := tx.Table(question_hierarchy.QuestionFormatTable{}.TableName()+" f").
+		Joins(fmt.Sprintf("JOIN %s dl ON dl.question_difficulty_level_id = f.question_difficulty_level_id", question_hierarchy.QuestionDifficultyLevelTable{}.TableName())).
+		Where("f.question_format_id = ?", formatID).
+		Pluck("dl.difficulty_level", &levels).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch question difficulty level: %w", err)
+	}
+	if len(levels) == 0 {
+		return utils.DifficultyRatings["MEDIUM"], nil // Node deleted since the question was served
+	}
+	return utils.DifficultyRatings[levels[0]], nil
+}
+
+// pendingSessionQuestions returns the served questions of a session that have no response yet
+func pendingSessionQuestions(tx *gorm.DB, sessionQuestions []student_psql.StudentPracticeSessionQuestionTable) ([]student_psql.StudentPracticeSessionQuestionTable, error) {
+	if len(sessionQuestions) == 0 {
+		return nil, nil
+	}
+
+	var answeredServeOrders []int
+	if err := tx.Model(&student_psql.StudentPracticeSessionResponseTable{}).
+		Where("practice_session_id = ?", sessionQuestions[0].PracticeSessionID).
+		Pluck("serve_order", &answeredServeOrders).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch practice session responses: %w", err)
+	}
+
+	var pending []student_psql.StudentPracticeSessionQuestionTable
+	for _, sessionQuestion := range sessionQuestions {
+		if !slices.Contains(answeredServeOrders, sessionQuestion.ServeOrder) {
+			pending = append(pending, sessionQuestion)
+		}
+	}
+	return pending, nil
+}
+
+// closeAdaptivePracticeSession records the questions of an adaptive session left unanswered as skipped and tallies
+// the result of the session from its responses, the answers were graded as they were given.
+func closeAdaptivePracticeSession(tx *gorm.DB, record student_psql.StudentPracticeSessionRecordTable) (gradedPracticeSession, error) {
+	sessionQuestions, err := fetchSessionQuestions(tx, record.PracticeSessionID)
+	if err != nil {
+		return gradedPracticeSession{}, err
+	}
+	pending, err := pendingSessionQuestions(tx, sessionQuestions)
+	if err != nil {
+		return gradedPracticeSession{}, err
+	}
+
+	if len(pending) > 0 {
+		details, formats, err := fetchServedQuestionDetails(tx, pending)
+		if err != nil {
+			return gradedPracticeSession{}, err
+		}
+		skipped := make([]student_psql.StudentPracticeSessionResponseTable, 0, len(pending))
+		for _, sessionQuestion := range pending {
+			detail, exists := details[servedQuestionKey{sessionQuestion.QuestionFormatID, sessionQuestion.QuestionID}]
+			if !exists {
+				continue // Question was deleted since it was served, before revisions were tracked
+			}
+			sessionResponse, err := gradeServedQuestion(sessionQuestion, formats[sessionQuestion.QuestionFormatID], detail, requests.PracticeSessionAnswer{}, nil)
+			if err != nil {
+				return gradedPracticeSession{}, err
+			}
+			skipped = append(skipped, sessionResponse)
+		}
+		if len(skipped) > 0 {
+			if err := tx.Create(&skipped).Error; err != nil {
+				return gradedPracticeSession{}, fmt.Errorf("failed to store practice session responses: %w", err)
+			}
+		}
+	}
+
+	var responses []student_psql.StudentPracticeSessionResponseTable
+	if err := tx.Where("practice_session_id = ?", record.PracticeSessionID).
+		Find(&responses).Error; err != nil {
+		return gradedPracticeSession{}, fmt.Errorf("failed to fetch practice session responses: %w", err)
+	}
+	return tallyPracticeSession(responses), nil
+}
+
+// CloseExpiredAdaptivePracticeSession closes an adaptive session expired by the practice session reaper,
+// as a workers.AdaptiveSessionCloser
+func CloseExpiredAdaptivePracticeSession(tx *gorm.DB, record student_psql.StudentPracticeSessionRecordTable) (int, int, float64, error) {
+	result, err := closeAdaptivePracticeSession(tx, record)
+	return result.QuestionsAttempted, result.QuestionsCorrect, result.ScoreEarned, err
+}
+
+// respondAdaptivePracticeError writes the response of a failed adaptive practice session request
+func respondAdaptivePracticeError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, errNoAdaptiveQuestions):
+		c.JSON(http.StatusNotFound, gin.H{"message": "No questions found", "details": err.Error()})
+	case errors.Is(err, errAdaptiveNicheNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question niche", "details": err.Error()})
+	case errors.Is(err, errPracticeSessionNotAdaptive), errors.Is(err, errNotCurrentQuestion):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
+
+// StartAdaptivePracticeSessionHandler starts an adaptive practice session in a niche and serves its first question,
+// at the difficulty matching the ability of the student in the niche
+func StartAdaptivePracticeSessionHandler(c *gin.Context) {
+	var request requests.StartAdaptivePracticeSessionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	renderHTML, ok := renderHTMLFromQuery(c)
+	if !ok {
+		return
+	}
+
+	enrollmentNo, ok := requestStudent(c)
+	if !ok {
+		return
+	}
+	if !slices.Contains(adaptiveQuestionCounts, request.QuestionCount) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid QuestionCount. Must be 10, 30, or 60"})
+		return
+	}
+
+	var sessionResponse response.AdaptivePracticeSessionResponse
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		var niches int64
+		if err := tx.Table(question_hierarchy.QuestionNicheTable{}.TableName()+" n").
+			Joins(fmt.Sprintf("JOIN %s sd ON sd.question_sub_domain_id = n.question_sub_domain_id", question_hierarchy.QuestionSubDomainsTable{}.TableName())).
+			Where("n.question_niche_id = ? AND sd.question_sub_domain_id = ? AND sd.question_domain_id = ?",
+				request.QuestionNicheID, request.QuestionSubDomainID, request.QuestionDomainID).
+			Count(&niches).Error; err != nil {
+			return fmt.Errorf("failed to fetch question niche: %w", err)
+		}
+		if niches == 0 {
+			return errAdaptiveNicheNotFound
+		}
+
+		ability, err := fetchNicheAbility(tx, enrollmentNo, request.QuestionNicheID, false)
+		if err != nil {
+			return err
+		}
+		next, err := selectAdaptiveQuestion(tx, questionSelectionRequest{
+			EnrollmentNo: enrollmentNo,
+			SkipPassages: true,
+		}, request.QuestionNicheID, request.QuestionFormat, ability.Rating)
+		if err != nil {
+			return err
+		}
+
+		// The difficulty and format nodes of the session are the ones of its first question
+		practiceSessionRecord := student_psql.StudentPracticeSessionRecordTable{
+			DomainID:           request.QuestionDomainID,
+			SubDomainID:        request.QuestionSubDomainID,
+			QuestionNicheID:    request.QuestionNicheID,
+			DifficultyLevelID:  next.DifficultyLevelID,
+			QuestionFormatID:   next.FormatID,
+			Mode:               student_psql.PracticeSessionModeAdaptive,
+			QuestionsServed:    request.QuestionCount,
+			QuestionsAttempted: -1, // This will be updated after the session is completed
+			QuestionsCorrect:   -1, // This will be updated after the session is completed
+			ScoreEarned:        -1, // This will be updated after the session is completed
+			StartTime:          time.Now(),
+			EndTime:            time.Time{}, // Default value indicating the end time is not set yet
+		}
+		if err := tx.Create(&practiceSessionRecord).Error; err != nil {
+			return fmt.Errorf("failed to store practice session record: %w", err)
+		}
+		if err := tx.Create(&student_psql.StudentPracticeSessionLookupTable{
+			EnrollmentNo:      enrollmentNo,
+			PracticeSessionID: practiceSessionRecord.PracticeSessionID,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to store practice session record in lookup: %w", err)
+		}
+
+		if err := serveAdaptiveQuestion(c.Request.Context(), tx, practiceSessionRecord.PracticeSessionID, 1, &next); err != nil {
+			return err
+		}
+
+		sessionResponse = response.AdaptivePracticeSessionResponse{
+			PracticeSessionID: practiceSessionRecord.PracticeSessionID,
+			Question:          &next.Question,
+			ServeOrder:        1,
+			DifficultyLevel:   next.DifficultyLevel,
+			QuestionCount:     request.QuestionCount,
+			Ability:           toNicheAbilityResponse(ability, ""),
+			Message:           "Practice session started successfully",
+		}
+		return nil
+	})
+	if err != nil {
+		respondAdaptivePracticeError(c, "Failed to start practice session", err)
+		return
+	}
+	if renderHTML {
+		questions := []response.PracticeQuestionResponse{*sessionResponse.Question}
+		renderPracticeContent(questions, nil)
+		sessionResponse.Question = &questions[0]
+	}
+
+	c.JSON(http.StatusOK, sessionResponse)
+}
+
+// AnswerAdaptivePracticeQuestionHandler grades the answer to the question an adaptive session is waiting on,
+// updates the ability of the student in the niche and serves the next question at the updated ability.
+// The session is submitted once all its questions are answered or the niche has no question left to serve.
+func AnswerAdaptivePracticeQuestionHandler(c *gin.Context) {
+	var request requests.AdaptivePracticeAnswerRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+	enrollmentNo, ok := requestStudent(c)
+	if !ok {
+		return
+	}
+	renderHTML, ok := renderHTMLFromQuery(c)
+	if !ok {
+		return
+	}
+
+	// Run the program of a CODE answer before locking the session
+	db := config.GetPostgresDBConnection()
+	codeGrades, err := runPracticeSessionCode(c.Request.Context(), db, enrollmentNo, request.PracticeSessionID, []requests.PracticeSessionAnswer{request.Answer})
+	if err != nil {
+		respondAdaptivePracticeError(c, "Failed to answer practice question", err)
+		return
+	}
+
+	var sessionResponse response.AdaptivePracticeSessionResponse
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Lock the session so that two answers, a submission or the reaper don't race
+		var practiceSessionLookupRecord student_psql.StudentPracticeSessionLookupTable
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("practice_session_id = ? AND enrollment_no = ? AND status = ?", request.PracticeSessionID, enrollmentNo, "Active").
+			First(&practiceSessionLookupRecord).Error; err != nil {
+			return fmt.Errorf("no active practice session found: %w", err)
+		}
+
+		var practiceSessionRecord student_psql.StudentPracticeSessionRecordTable
+		if err := tx.Where("practice_session_id = ?", request.PracticeSessionID).
+			First(&practiceSessionRecord).Error; err != nil {
+			return fmt.Errorf("practice session not found: %w", err)
+		}
+		if practiceSessionRecord.Mode != student_psql.PracticeSessionModeAdaptive {
+			return errPracticeSessionNotAdaptive
+		}
+
+		sessionQuestions, err := fetchSessionQuestions(tx, practiceSessionRecord.PracticeSessionID)
+		if err != nil {
+			return err
+		}
+		pending, err := pendingSessionQuestions(tx, sessionQuestions)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			return errNotCurrentQuestion
+		}
+		current := pending[0]
+		if request.Answer.QuestionFormatID != current.QuestionFormatID || request.Answer.QuestionID != current.QuestionID {
+			return fmt.Errorf("%w: question %d of format node %d", errNotCurrentQuestion, current.QuestionID, current.QuestionFormatID)
+		}
+
+		// Grade the answer against the revision that was served
+		details, formats, err := fetchServedQuestionDetails(tx, []student_psql.StudentPracticeSessionQuestionTable{current})
+		if err != nil {
+			return err
+		}
+		detail, exists := details[servedQuestionKey{current.QuestionFormatID, current.QuestionID}]
+		if !exists {
+			return fmt.Errorf("served question %d of format node %d no longer exists", current.QuestionID, current.QuestionFormatID)
+		}
+		questionFormat := formats[current.QuestionFormatID]
+		sessionResponse.LastAnswer = &response.AdaptiveAnswerResult{ServeOrder: current.ServeOrder}
+
+		answer, err := gradeServedQuestion(current, questionFormat, detail, request.Answer, codeGrades)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&answer).Error; err != nil {
+			return fmt.Errorf("failed to store practice session response: %w", err)
+		}
+		sessionResponse.LastAnswer.IsCorrect = answer.IsCorrect
+		sessionResponse.LastAnswer.Credit = answer.Credit
+
+		// Only the graded answers move the ability, a skipped question counts as a wrong answer
+		ability, err := fetchNicheAbility(tx, enrollmentNo, practiceSessionRecord.QuestionNicheID, true)
+		if err != nil {
+			return err
+		}
+		if answer.Credit != nil {
+			difficultyRating, err := questionDifficultyRating(tx, current.QuestionFormatID, current.QuestionID)
+			if err != nil {
+				return err
+			}
+			ability.Rating = utils.UpdateAbility(ability.Rating, difficultyRating, *answer.Credit, ability.GradedAnswers)
+			ability.GradedAnswers++
+			if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&ability).Error; err != nil {
+				return fmt.Errorf("failed to store ability estimate: %w", err)
+			}
+		}
+
+		sessionResponse.PracticeSessionID = practiceSessionRecord.PracticeSessionID
+		sessionResponse.QuestionCount = practiceSessionRecord.QuestionsServed
+		sessionResponse.Ability = toNicheAbilityResponse(ability, "")
+
+		if len(sessionQuestions) < practiceSessionRecord.QuestionsServed {
+			next, err := selectAdaptiveQuestion(tx, questionSelectionRequest{
+				EnrollmentNo:     enrollmentNo,
+				ExcludeSessionID: practiceSessionRecord.PracticeSessionID,
+				SkipPassages:     true,
+			}, practiceSessionRecord.QuestionNicheID, questionFormat, ability.Rating)
+			switch {
+			case err == nil:
+				serveOrder := sessionQuestions[len(sessionQuestions)-1].ServeOrder + 1
+				if err := serveAdaptiveQuestion(c.Request.Context(), tx, practiceSessionRecord.PracticeSessionID, serveOrder, &next); err != nil {
+					return err
+				}
+				sessionResponse.Question = &next.Question
+				sessionResponse.ServeOrder = serveOrder
+				sessionResponse.DifficultyLevel = next.DifficultyLevel
+				sessionResponse.Message = "Answer recorded successfully"
+				return nil
+			case !errors.Is(err, errNoAdaptiveQuestions):
+				return err
+			}
+			// The niche ran out of questions, the session ends early
+		}
+
+		result, err := closeAdaptivePracticeSession(tx, practiceSessionRecord)
+		if err != nil {
+			return err
+		}
+
+		practiceSessionLookupRecord.Status = "Submitted"
+		if err := tx.Save(&practiceSessionLookupRecord).Error; err != nil {
+			return fmt.Errorf("failed to update practice session status: %w", err)
+		}
+		practiceSessionRecord.QuestionsAttempted = result.QuestionsAttempted
+		practiceSessionRecord.QuestionsCorrect = result.QuestionsCorrect
+		practiceSessionRecord.ScoreEarned = result.ScoreEarned
+		practiceSessionRecord.EndTime = time.Now()
+		if request.Feedbacks != "" {
+			practiceSessionRecord.Feedbacks = request.Feedbacks
+		}
+		if err := tx.Save(&practiceSessionRecord).Error; err != nil {
+			return fmt.Errorf("failed to submit practice session: %w", err)
+		}
+
+		sessionResponse.Finished = true
+		sessionResponse.Message = "Practice session submitted successfully"
+		return nil
+	})
+	if err != nil {
+		respondAdaptivePracticeError(c, "Failed to answer practice question", err)
+		return
+	}
+	if renderHTML && sessionResponse.Question != nil {
+		questions := []response.PracticeQuestionResponse{*sessionResponse.Question}
+		renderPracticeContent(questions, nil)
+		sessionResponse.Question = &questions[0]
+	}
+
+	c.JSON(http.StatusOK, sessionResponse)
+}
+
+// GetNicheAbilitiesHandler returns the ability estimates of the student of the token in every niche they practiced adaptively,
+// along with the difficulty level each of them matches
+func GetNicheAbilitiesHandler(c *gin.Context) {
+	enrollmentNo, ok := requestStudent(c)
+	if !ok {
+		return
+	}
+
+	var rows []struct {
+		student_psql.StudentNicheAbilityTable
+		NicheName string
+	}
+	if err := config.GetPostgresDBConnection().
+		Table(student_psql.StudentNicheAbilityTable{}.TableName()+" a").
+		Select("a.*, n.niche_name").
+		Joins(fmt.Sprintf("JOIN %s n ON n.question_niche_id = a.question_niche_id", question_hierarchy.QuestionNicheTable{}.TableName())).
+		Where("a.enrollment_no = ?", enrollmentNo).
+		Order("n.niche_name").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ability estimates", "details": err.Error()})
+		return
+	}
+
+	abilities := make([]response.NicheAbilityResponse, 0, len(rows))
+	for _, row := range rows {
+		abilities = append(abilities, toNicheAbilityResponse(row.StudentNicheAbilityTable, row.NicheName))
+	}
+	c.JSON(http.StatusOK, gin.H{"abilities": abilities})
+}
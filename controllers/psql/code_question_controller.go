@@ -0,0 +1,265 @@
+package controllersNew
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	codeRunner "server/code_runner"
+	"server/config"
+	question_type "server/models/question_bank/question_type"
+	requests "server/models/requests"
+	student_psql "server/models/student_psql"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errNotCodingQuestion = errors.New("only CODE questions can be run")
+
+// runCode runs a program against test cases of a coding question with its limits.
+// The language defaults to the one of the reference solution.
+func runCode(ctx context.Context, detail questionDetail, language, code string, testCases question_type.CodeTestCases, keepOutput bool) (codeRunner.Result, error) {
+	if language = strings.ToLower(strings.TrimSpace(language)); language == "" {
+		language = detail.Language
+	}
+	return codeRunner.DefaultRunner.Run(
+		ctx,
+		codeRunner.Submission{Language: language, Code: code},
+		testCases.RunnerTests(),
+		question_type.CodeRunnerLimits(detail.TimeLimitMs, detail.MemoryLimitMB),
+		keepOutput,
+	)
+}
+
+// codeGrade is the outcome of the program submitted for a CODE question, run ahead of the grading
+type codeGrade struct {
+	Credit       float64 // Share of the test cases passed
+	Language     string
+	TestVerdicts []string // Verdict of each test case, empty for a language that can't be run
+}
+
+// runSubmittedCode runs the program submitted for a CODE question against all its test cases.
+// A program in a language that can't be run gets no credit.
+func runSubmittedCode(ctx context.Context, detail questionDetail, givenAnswer requests.PracticeSessionAnswer) (codeGrade, error) {
+	grade := codeGrade{Language: strings.ToLower(strings.TrimSpace(givenAnswer.Language))}
+	if grade.Language == "" {
+		grade.Language = detail.Language
+	}
+	result, err := runCode(ctx, detail, grade.Language, strings.TrimSpace(givenAnswer.Answer), detail.TestCases, false)
+	if errors.Is(err, codeRunner.ErrUnsupportedLanguage) {
+		return grade, nil
+	}
+	if err != nil {
+		return grade, fmt.Errorf("failed to run the program submitted for question %d/%d: %w", detail.Base.QuestionFormatID, detail.Base.QuestionID, err)
+	}
+
+	for _, test := range result.Tests {
+		grade.TestVerdicts = append(grade.TestVerdicts, string(test.Verdict))
+	}
+	grade.Credit = float64(result.Passed) / float64(len(result.Tests))
+	return grade, nil
+}
+
+// runPracticeSessionCode runs the programs submitted for the CODE questions served in a practice session of the
+// student, only the first answer per question counts. It runs before the transaction of the grading so that no lock
+// is held and no transaction is open while the programs run: the served questions and their revisions don't change
+// once served, the session being submitted meanwhile only wastes the runs.
+func runPracticeSessionCode(ctx context.Context, db *gorm.DB, enrollmentNo string, practiceSessionID uint32, answers []requests.PracticeSessionAnswer) (map[servedQuestionKey]codeGrade, error) {
+	codeGrades := make(map[servedQuestionKey]codeGrade)
+	givenAnswers := firstPracticeAnswers(answers)
+
+	// Nothing is run unless the session is an active session of the student
+	var activeSessions int64
+	if err := db.Model(&student_psql.StudentPracticeSessionLookupTable{}).
+		Where("practice_session_id = ? AND enrollment_no = ? AND status = ?", practiceSessionID, enrollmentNo, "Active").
+		Count(&activeSessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch practice session: %w", err)
+	}
+	if activeSessions == 0 {
+		return nil, fmt.Errorf("no active practice session found: %w", gorm.ErrRecordNotFound)
+	}
+
+	sessionQuestions, err := fetchSessionQuestions(db, practiceSessionID)
+	if err != nil {
+		return nil, err
+	}
+	answered := make([]student_psql.StudentPracticeSessionQuestionTable, 0, len(givenAnswers))
+	for _, sessionQuestion := range sessionQuestions {
+		answer, exists := givenAnswers[servedQuestionKey{sessionQuestion.QuestionFormatID, sessionQuestion.QuestionID}]
+		if exists && strings.TrimSpace(answer.Answer) != "" {
+			answered = append(answered, sessionQuestion)
+		}
+	}
+	if len(answered) == 0 {
+		return codeGrades, nil
+	}
+
+	details, formats, err := fetchServedQuestionDetails(db, answered)
+	if err != nil {
+		return nil, err
+	}
+	for _, sessionQuestion := range answered {
+		key := servedQuestionKey{sessionQuestion.QuestionFormatID, sessionQuestion.QuestionID}
+		detail, exists := details[key]
+		if !exists || formats[sessionQuestion.QuestionFormatID] != "CODE" || len(detail.TestCases) == 0 {
+			continue
+		}
+		grade, err := runSubmittedCode(ctx, detail, givenAnswers[key])
+		if err != nil {
+			return nil, err
+		}
+		codeGrades[key] = grade
+	}
+	return codeGrades, nil
+}
+
+// gradeSubmittedCode grades the program submitted for a CODE question with the outcome of its run, as the share
+// of the test cases passed, and records the language and the verdict of each test case in its response
+func gradeSubmittedCode(detail questionDetail, codeGrades map[servedQuestionKey]codeGrade, sessionResponse *student_psql.StudentPracticeSessionResponseTable) (float64, error) {
+	if sessionResponse.ChosenAnswer == "" || len(detail.TestCases) == 0 {
+		return 0, nil // Skipped question
+	}
+
+	grade, exists := codeGrades[servedQuestionKey{detail.Base.QuestionFormatID, detail.Base.QuestionID}]
+	if !exists {
+		return 0, fmt.Errorf("the program submitted for question %d/%d wasn't run", detail.Base.QuestionFormatID, detail.Base.QuestionID)
+	}
+	sessionResponse.Language = grade.Language
+	sessionResponse.TestVerdicts = grade.TestVerdicts
+	return grade.Credit, nil
+}
+
+// respondCodeRunError maps the errors of the code run handlers to their status codes
+func respondCodeRunError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, codeRunner.ErrUnsupportedLanguage), errors.Is(err, errNotCodingQuestion):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, codeRunner.ErrSandboxUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Programs can't be run on this server", "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to run the program", "details": err.Error()})
+	}
+}
+
+// RunQuestionCodeHandler runs a program against every test case of a CODE question, hidden ones included,
+// and returns the verdict and output of each test case. Without code the reference solution is run, to check
+// the test cases of the question before it is published.
+func RunQuestionCodeHandler(c *gin.Context) {
+	formatID, questionID, ok := questionKeyFromParams(c)
+	if !ok {
+		return
+	}
+	var request requests.RunCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	var question *question_type.CodingQuestion
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		format, stored, err := fetchStoredQuestion(tx, formatID, questionID)
+		if err != nil {
+			return err
+		}
+		if format != "CODE" {
+			return fmt.Errorf("%w, question %d/%d is %s", errNotCodingQuestion, formatID, questionID, format)
+		}
+		question = stored.(*question_type.CodingQuestion)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = fmt.Errorf("question not found: %w", err)
+		}
+		respondCodeRunError(c, err)
+		return
+	}
+
+	// The program runs outside of the transaction, it can take up to the time limit per test case
+	detail := questionDetail{Language: question.Language, TimeLimitMs: question.TimeLimitMs, MemoryLimitMB: question.MemoryLimitMB}
+	language, code := request.Language, request.Code
+	if strings.TrimSpace(code) == "" {
+		language, code = question.Language, question.Answer
+	}
+	result, err := runCode(c.Request.Context(), detail, language, code, question.TestCases, true)
+	if err != nil {
+		respondCodeRunError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": result})
+}
+
+// RunPracticeCodeHandler runs a student's program against the sample test cases of a CODE question served in
+// their active practice session, so that they can check it before submitting. Hidden test cases are never run.
+func RunPracticeCodeHandler(c *gin.Context) {
+	practiceSessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid practice session ID"})
+		return
+	}
+	var request requests.RunPracticeCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	enrollmentNo, ok := requestStudent(c)
+	if !ok {
+		return
+	}
+
+	var detail questionDetail
+	err = config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		// Only the student who started the session can run code in it, and only while it is active
+		var practiceSessionLookupRecord student_psql.StudentPracticeSessionLookupTable
+		if err := tx.Where("practice_session_id = ? AND enrollment_no = ? AND status = ?", practiceSessionID, enrollmentNo, "Active").
+			First(&practiceSessionLookupRecord).Error; err != nil {
+			return fmt.Errorf("no active practice session found: %w", err)
+		}
+
+		var sessionQuestions []student_psql.StudentPracticeSessionQuestionTable
+		if err := tx.Where("practice_session_id = ? AND question_format_id = ? AND question_id = ?", practiceSessionID, request.QuestionFormatID, request.QuestionID).
+			Find(&sessionQuestions).Error; err != nil {
+			return fmt.Errorf("failed to fetch served questions: %w", err)
+		}
+		if len(sessionQuestions) == 0 {
+			return fmt.Errorf("question not served in the practice session: %w", gorm.ErrRecordNotFound)
+		}
+
+		// The sample test cases are the ones of the revision that was served
+		details, formats, err := fetchServedQuestionDetails(tx, sessionQuestions)
+		if err != nil {
+			return err
+		}
+		if format := formats[request.QuestionFormatID]; format != "CODE" {
+			return fmt.Errorf("%w, question %d/%d is %s", errNotCodingQuestion, request.QuestionFormatID, request.QuestionID, format)
+		}
+		var exists bool
+		if detail, exists = details[servedQuestionKey{request.QuestionFormatID, request.QuestionID}]; !exists {
+			return fmt.Errorf("question not found: %w", gorm.ErrRecordNotFound)
+		}
+		return nil
+	})
+	if err != nil {
+		respondCodeRunError(c, err)
+		return
+	}
+
+	sampleTests := detail.TestCases.Samples()
+	if len(sampleTests) == 0 {
+		c.JSON(http.StatusOK, gin.H{"result": codeRunner.Result{Verdict: codeRunner.VerdictAccepted, Tests: []codeRunner.TestResult{}}})
+		return
+	}
+	result, err := runCode(c.Request.Context(), detail, request.Language, request.Code, sampleTests, true)
+	if err != nil {
+		respondCodeRunError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": result})
+}
@@ -0,0 +1,84 @@
+package controllersNew
+
+import (
+	"math/rand/v2"
+	question_type "server/models/question_bank/question_type"
+	requests "server/models/requests"
+	"server/models/response"
+	student_psql "server/models/student_psql"
+	"server/utils"
+	"slices"
+)
+
+// shuffleItems shuffles the column B items of an MTC question or the items of an ORD question to serve them.
+// Several items are never served in their own order. Returns the shuffled items along with the permutation
+// that is stored with the served question, the item served at position i is the item permutation[i].
+func shuffleItems(items []string) ([]string, []int32) {
+	permutation := make([]int32, len(items))
+	for {
+		for i, position := range rand.Perm(len(items)) {
+			permutation[i] = int32(position)
+		}
+		if len(items) < 2 || !slices.IsSorted(permutation) {
+			break
+		}
+	}
+	return permuteItems(items, permutation), permutation
+}
+
+// permuteItems returns the items in the order of the permutation, in their own order if the permutation doesn't fit them
+func permuteItems(items []string, permutation []int32) []string {
+	if len(permutation) != len(items) {
+		return items
+	}
+	permuted := make([]string, 0, len(items))
+	for _, position := range permutation {
+		permuted = append(permuted, items[position])
+	}
+	return permuted
+}
+
+// servedArrangedItems fills the items of a served MTC or ORD question in the order they were shuffled into
+func servedArrangedItems(servedQuestion *response.PracticeQuestionResponse, detail questionDetail, permutation []int32) {
+	if len(detail.Pairs) > 0 {
+		servedQuestion.ColumnA = detail.Pairs.ColumnA()
+		servedQuestion.ColumnB = permuteItems(detail.Pairs.ColumnB(), permutation)
+	}
+	if len(detail.Sequence) > 0 {
+		servedQuestion.Items = permuteItems(detail.Sequence, permutation)
+	}
+}
+
+// gradeArrangedItems grades the answer to an MTC or ORD question and records it in its response, as positions
+// in the question. The answer is given as the positions the items were served at, the ones that weren't served
+// count as wrong. Every correct pair (MTC) or position (ORD) earns its share of the credit.
+func gradeArrangedItems(detail questionDetail, permutation []int32, givenAnswer requests.PracticeSessionAnswer, sessionResponse *student_psql.StudentPracticeSessionResponseTable) float64 {
+	size := len(detail.Sequence)
+	if len(detail.Pairs) > 0 {
+		size = len(detail.Pairs)
+	}
+	sessionResponse.ChosenAnswer = ""
+	if len(givenAnswer.Arrangement) == 0 || size == 0 {
+		return 0 // Skipped question
+	}
+
+	arrangement := make([]int32, 0, min(len(givenAnswer.Arrangement), size))
+	for _, served := range givenAnswer.Arrangement[:min(len(givenAnswer.Arrangement), size)] {
+		position := int32(-1)
+		if served >= 0 && int(served) < size {
+			position = served
+			if len(permutation) == size {
+				position = permutation[served]
+			}
+		}
+		arrangement = append(arrangement, position)
+	}
+
+	sessionResponse.Arrangement = arrangement
+	if len(detail.Pairs) > 0 {
+		sessionResponse.ChosenAnswer = detail.Pairs.MatchedText(arrangement)
+	} else {
+		sessionResponse.ChosenAnswer = question_type.SequenceText(detail.Sequence, arrangement)
+	}
+	return utils.GradeArrangement(arrangement, size)
+}
@@ -0,0 +1,102 @@
+package controllersNew
+
+import (
+	"reflect"
+	"testing"
+
+	question_type "server/models/question_bank/question_type"
+	requests "server/models/requests"
+	student_psql "server/models/student_psql"
+)
+
+func TestGradeArrangedItems(t *testing.T) {
+	ordering := questionDetail{Sequence: []string{"The alarm rang.", "She got up.", "She made coffee."}}
+	matching := questionDetail{Pairs: question_type.MatchPairs{{Left: "France", Right: "Paris"}, {Left: "Japan", Right: "Tokyo"}}}
+
+	tests := []struct {
+		name            string
+		detail          questionDetail
+		permutation     []int32 // Item served at each position
+		served          []int32 // Positions the items were served at, as answered
+		wantCredit      float64
+		wantArrangement []int32
+		wantAnswer      string
+	}{
+		{
+			name:   "ORD correct order",
+			detail: ordering, permutation: []int32{2, 0, 1}, served: []int32{1, 2, 0},
+			wantCredit: 1, wantArrangement: []int32{0, 1, 2}, wantAnswer: "The alarm rang.\nShe got up.\nShe made coffee.",
+		},
+		{
+			name:   "ORD served order kept",
+			detail: ordering, permutation: []int32{2, 0, 1}, served: []int32{0, 1, 2},
+			wantCredit: 0, wantArrangement: []int32{2, 0, 1}, wantAnswer: "She made coffee.\nThe alarm rang.\nShe got up.",
+		},
+		{
+			name:   "ORD one item in place",
+			detail: ordering, permutation: []int32{2, 0, 1}, served: []int32{1, 0, 2},
+			wantCredit: 1.0 / 3, wantArrangement: []int32{0, 2, 1}, wantAnswer: "The alarm rang.\nShe made coffee.\nShe got up.",
+		},
+		{
+			name:   "position that wasn't served counts as wrong",
+			detail: ordering, permutation: []int32{2, 0, 1}, served: []int32{1, 5, 0},
+			wantCredit: 2.0 / 3, wantArrangement: []int32{0, -1, 2}, wantAnswer: "The alarm rang.\nShe made coffee.",
+		},
+		{
+			name:   "extra positions are ignored",
+			detail: ordering, permutation: []int32{2, 0, 1}, served: []int32{1, 2, 0, 1},
+			wantCredit: 1, wantArrangement: []int32{0, 1, 2}, wantAnswer: "The alarm rang.\nShe got up.\nShe made coffee.",
+		},
+		{
+			name:   "items served in their own order without a permutation",
+			detail: ordering, served: []int32{0, 1, 2},
+			wantCredit: 1, wantArrangement: []int32{0, 1, 2}, wantAnswer: "The alarm rang.\nShe got up.\nShe made coffee.",
+		},
+		{
+			name:   "skipped question",
+			detail: ordering, permutation: []int32{2, 0, 1},
+			wantCredit: 0,
+		},
+		{
+			name:   "MTC correct pairs",
+			detail: matching, permutation: []int32{1, 0}, served: []int32{1, 0},
+			wantCredit: 1, wantArrangement: []int32{0, 1}, wantAnswer: "France => Paris\nJapan => Tokyo",
+		},
+		{
+			name:   "MTC swapped pairs",
+			detail: matching, permutation: []int32{1, 0}, served: []int32{0, 1},
+			wantCredit: 0, wantArrangement: []int32{1, 0}, wantAnswer: "France => Tokyo\nJapan => Paris",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sessionResponse := student_psql.StudentPracticeSessionResponseTable{ChosenAnswer: "stale"}
+			credit := gradeArrangedItems(test.detail, test.permutation, requests.PracticeSessionAnswer{Arrangement: test.served}, &sessionResponse)
+
+			if credit != test.wantCredit {
+				t.Errorf("credit = %v, want %v", credit, test.wantCredit)
+			}
+			if got := []int32(sessionResponse.Arrangement); !reflect.DeepEqual(got, test.wantArrangement) {
+				t.Errorf("arrangement = %v, want %v", got, test.wantArrangement)
+			}
+			if sessionResponse.ChosenAnswer != test.wantAnswer {
+				t.Errorf("chosen answer = %q, want %q", sessionResponse.ChosenAnswer, test.wantAnswer)
+			}
+		})
+	}
+}
+
+func TestPermuteItems(t *testing.T) {
+	items := []string{"a", "b", "c"}
+	if got, want := permuteItems(items, []int32{2, 0, 1}), []string{"c", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("permuteItems() = %q, want %q", got, want)
+	}
+	if got := permuteItems(items, []int32{1, 0}); !reflect.DeepEqual(got, items) {
+		t.Errorf("permuteItems() with a permutation that doesn't fit = %q, want the items in their order", got)
+	}
+
+	shuffled, permutation := shuffleItems(items)
+	if !reflect.DeepEqual(shuffled, permuteItems(items, permutation)) || reflect.DeepEqual(shuffled, items) {
+		t.Errorf("shuffleItems() = %q, %v, want the items out of their order along with the permutation", shuffled, permutation)
+	}
+}
@@ -5,18 +5,195 @@ import (
 	"fmt"
 	"net/http"
 	"server/config"
+	"server/middlewares"
+	"slices"
+	"strconv"
+	"strings"
 
+	question_type "server/models/question_bank/question_type"
 	requests "server/models/requests"
+	"server/models/response"
 	student_psql "server/models/student_psql"
+	"server/utils"
+	"server/workers"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // NOTE: Session Start is taken care of by the GetQuestions handler in the question_controller.go
 
-// SubmitPracticeSessionHandler submits the results of a practice session
+// requestStudent returns the enrollment number of the student whose token authorized the request, the practice
+// sessions of other students are out of their reach. Responds with 403 for the tokens that aren't a student's.
+func requestStudent(c *gin.Context) (string, bool) {
+	enrollmentNo := middlewares.RequestEnrollmentNo(c)
+	if enrollmentNo == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "The token doesn't belong to a student, log in again"})
+		return "", false
+	}
+	return enrollmentNo, true
+}
+
+// errPracticeSessionNotSubmitted is returned when the answers of a session that isn't submitted are requested
+var errPracticeSessionNotSubmitted = errors.New("answers are revealed only after the practice session is submitted")
+
+// gradedPracticeSession holds the result of grading a submitted practice session
+type gradedPracticeSession struct {
+	QuestionsAttempted int     `json:"questionsAttempted"`
+	QuestionsCorrect   int     `json:"questionsCorrect"`
+	ScoreEarned        float64 `json:"scoreEarned"`
+}
+
+// firstPracticeAnswers keeps the first answer given for each question
+func firstPracticeAnswers(answers []requests.PracticeSessionAnswer) map[servedQuestionKey]requests.PracticeSessionAnswer {
+	givenAnswers := make(map[servedQuestionKey]requests.PracticeSessionAnswer, len(answers))
+	for _, answer := range answers {
+		key := servedQuestionKey{answer.QuestionFormatID, answer.QuestionID}
+		if _, exists := givenAnswers[key]; !exists {
+			givenAnswers[key] = answer
+		}
+	}
+	return givenAnswers
+}
+
+// gradePracticeSession grades the submitted answers against the questions that were really served in the session
+// and builds the response record of every served question. Answers to questions that weren't served are ignored
+// and only the first answer per question counts. The programs submitted for CODE questions were run ahead by
+// runPracticeSessionCode, codeGrades holds their outcome.
+func gradePracticeSession(tx *gorm.DB, record student_psql.StudentPracticeSessionRecordTable, answers []requests.PracticeSessionAnswer, codeGrades map[servedQuestionKey]codeGrade) (gradedPracticeSession, []student_psql.StudentPracticeSessionResponseTable, error) {
+	var result gradedPracticeSession
+
+	sessionQuestions, err := fetchSessionQuestions(tx, record.PracticeSessionID)
+	if err != nil {
+		return result, nil, err
+	}
+	if len(sessionQuestions) == 0 {
+		return result, nil, fmt.Errorf("no served questions recorded for practice session %d", record.PracticeSessionID)
+	}
+
+	// Fetch the served questions along with their answer key
+	details, formats, err := fetchServedQuestionDetails(tx, sessionQuestions)
+	if err != nil {
+		return result, nil, err
+	}
+
+	givenAnswers := firstPracticeAnswers(answers)
+
+	responses := make([]student_psql.StudentPracticeSessionResponseTable, 0, len(sessionQuestions))
+	for _, sessionQuestion := range sessionQuestions {
+		key := servedQuestionKey{sessionQuestion.QuestionFormatID, sessionQuestion.QuestionID}
+		detail, exists := details[key]
+		if !exists {
+			continue // Question was deleted since it was served, before revisions were tracked
+		}
+
+		sessionResponse, err := gradeServedQuestion(sessionQuestion, formats[sessionQuestion.QuestionFormatID], detail, givenAnswers[key], codeGrades)
+		if err != nil {
+			return result, nil, err
+		}
+		responses = append(responses, sessionResponse)
+	}
+
+	return tallyPracticeSession(responses), responses, nil
+}
+
+// gradeServedQuestion grades the answer given to a served question and builds its response record.
+// IsCorrect and Credit are left NULL for the formats that can't be graded automatically (TXT).
+// Multi-select MCQ, CODE, MTC and ORD questions earn partial credit, only full credit counts as correct.
+// codeGrades holds the outcome of the programs submitted for the CODE questions, run ahead of the grading.
+func gradeServedQuestion(sessionQuestion student_psql.StudentPracticeSessionQuestionTable, questionFormat string, detail questionDetail, givenAnswer requests.PracticeSessionAnswer, codeGrades map[servedQuestionKey]codeGrade) (student_psql.StudentPracticeSessionResponseTable, error) {
+	sessionResponse := student_psql.StudentPracticeSessionResponseTable{
+		PracticeSessionID: sessionQuestion.PracticeSessionID,
+		ServeOrder:        sessionQuestion.ServeOrder,
+		ChosenAnswer:      strings.TrimSpace(givenAnswer.Answer),
+		TimeSpentSeconds:  givenAnswer.TimeSpentSeconds,
+	}
+
+	var correct, gradable bool
+	var credit float64
+	var err error
+	switch questionFormat {
+	case "MCQ":
+		credit = gradeChosenOptions(detail, givenAnswer, &sessionResponse)
+		correct, gradable = credit == 1, true
+	case "NUM":
+		key := question_type.NumericAnswerKey(detail.Base.Answer, detail.Tolerance, detail.RelativeTolerance, detail.Unit)
+		correct, gradable = sessionResponse.ChosenAnswer != "" && utils.GradeNumericAnswer(key, sessionResponse.ChosenAnswer), true
+	case "CODE":
+		if credit, err = gradeSubmittedCode(detail, codeGrades, &sessionResponse); err != nil {
+			return sessionResponse, err
+		}
+		correct, gradable = credit == 1, true
+	case "MTC", "ORD":
+		credit = gradeArrangedItems(detail, sessionQuestion.Permutation, givenAnswer, &sessionResponse)
+		correct, gradable = credit == 1, true
+	default:
+		correct, gradable = utils.GradeAnswer(questionFormat, detail.Base.Answer, givenAnswer.Answer)
+	}
+	if correct {
+		credit = 1
+	}
+	if gradable {
+		sessionResponse.IsCorrect = &correct
+		sessionResponse.Credit = &credit
+	}
+	return sessionResponse, nil
+}
+
+// tallyPracticeSession computes the result of a session from the responses to its served questions.
+// Unanswered questions count against the score. Formats that can't be graded
+// automatically (TXT) are left out of the score until they are reviewed.
+func tallyPracticeSession(responses []student_psql.StudentPracticeSessionResponseTable) gradedPracticeSession {
+	var result gradedPracticeSession
+	gradableQuestions := 0
+	earnedCredit := 0.0
+	for _, sessionResponse := range responses {
+		if sessionResponse.IsCorrect != nil {
+			gradableQuestions++
+			if sessionResponse.Credit != nil {
+				earnedCredit += *sessionResponse.Credit
+			}
+		}
+
+		if sessionResponse.ChosenAnswer == "" && len(sessionResponse.ChosenOptions) == 0 {
+			continue // Skipped question
+		}
+		result.QuestionsAttempted++
+		if sessionResponse.IsCorrect != nil && *sessionResponse.IsCorrect {
+			result.QuestionsCorrect++
+		}
+	}
+
+	if gradableQuestions > 0 {
+		result.ScoreEarned = earnedCredit / float64(gradableQuestions) * 100
+	}
+	return result
+}
+
+// gradeChosenOptions grades the answer to an MCQ question and records the chosen options in its response.
+// The options are taken by position, or from the answer text (one option per line) when no position is given.
+// Options that don't exist, by position or by text, are counted as wrong choices.
+func gradeChosenOptions(detail questionDetail, givenAnswer requests.PracticeSessionAnswer, sessionResponse *student_psql.StudentPracticeSessionResponseTable) float64 {
+	chosenOptions := givenAnswer.ChosenOptions
+	if len(chosenOptions) == 0 && sessionResponse.ChosenAnswer != "" {
+		indices, err := question_type.MCQOptionIndices(detail.Options, sessionResponse.ChosenAnswer)
+		if err != nil {
+			return 0
+		}
+		chosenOptions = indices
+	}
+	if len(chosenOptions) == 0 {
+		return 0 // Skipped question
+	}
+
+	sessionResponse.ChosenOptions = chosenOptions
+	sessionResponse.ChosenAnswer = question_type.MCQAnswerText(detail.Options, chosenOptions)
+	return utils.GradeOptionSelection(detail.CorrectOptions, chosenOptions, detail.MultiSelect)
+}
+
+// SubmitPracticeSessionHandler grades and submits the results of a practice session
 func SubmitPracticeSessionHandler(c *gin.Context) {
 
 	var request requests.SuccessfullyEndPracticeSessionRequest
@@ -26,12 +203,33 @@ func SubmitPracticeSessionHandler(c *gin.Context) {
 		return
 	}
 
+	enrollmentNo, ok := requestStudent(c)
+	if !ok {
+		return
+	}
+
+	// Run the programs submitted for the CODE questions before opening the transaction, it only holds the writes
+	db := config.GetPostgresDBConnection()
+	codeGrades, err := runPracticeSessionCode(c.Request.Context(), db, enrollmentNo, request.PracticeSessionID, request.Answers)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to run the submitted programs", "details": err.Error()})
+		}
+		return
+	}
+
+	var result gradedPracticeSession
+
 	// Use the transaction method
-	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+	err = db.Transaction(func(tx *gorm.DB) error {
 
-		// Fetch and update the lookup table record
+		// Fetch and update the lookup table record, only the student who started the session can submit it
+		// Lock the row so that the session reaper can't expire the session while it is being submitted
 		var practiceSessionLookupRecord student_psql.StudentPracticeSessionLookupTable
-		if err := tx.Where("practice_session_id = ? AND status = ?", request.PracticeSessionID, "Active").
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("practice_session_id = ? AND enrollment_no = ? AND status = ?", request.PracticeSessionID, enrollmentNo, "Active").
 			First(&practiceSessionLookupRecord).Error; err != nil {
 			return fmt.Errorf("no active practice session found: %w", err)
 		}
@@ -49,9 +247,32 @@ func SubmitPracticeSessionHandler(c *gin.Context) {
 			return fmt.Errorf("practice session not found: %w", err)
 		}
 
-		practiceSessionRecord.QuestionsAttempted = request.QuestionsAttempted
-		practiceSessionRecord.QuestionsCorrect = request.QuestionsCorrect
-		practiceSessionRecord.ScoreEarned = request.ScoreEarnedPercentage
+		if practiceSessionRecord.Mode == student_psql.PracticeSessionModeAdaptive {
+			// Adaptive sessions were graded answer by answer, submitting ends them early and skips the pending question
+			gradedResult, err := closeAdaptivePracticeSession(tx, practiceSessionRecord)
+			if err != nil {
+				return fmt.Errorf("failed to grade practice session: %w", err)
+			}
+			result = gradedResult
+		} else {
+			// Grade the answers on the server, the client never sends its own score
+			gradedResult, responses, err := gradePracticeSession(tx, practiceSessionRecord, request.Answers, codeGrades)
+			if err != nil {
+				return fmt.Errorf("failed to grade practice session: %w", err)
+			}
+			result = gradedResult
+
+			// Keep the response to every served question for the post-session review
+			if len(responses) > 0 {
+				if err := tx.Create(&responses).Error; err != nil {
+					return fmt.Errorf("failed to store practice session responses: %w", err)
+				}
+			}
+		}
+
+		practiceSessionRecord.QuestionsAttempted = result.QuestionsAttempted
+		practiceSessionRecord.QuestionsCorrect = result.QuestionsCorrect
+		practiceSessionRecord.ScoreEarned = result.ScoreEarned
 		practiceSessionRecord.EndTime = time.Now()
 		practiceSessionRecord.Feedbacks = request.Feedbacks
 
@@ -72,10 +293,10 @@ func SubmitPracticeSessionHandler(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Practice session submitted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Practice session submitted successfully", "result": result})
 }
 
-// ForcefullyEndPracticeSessionHandler forcefully ends a practice session
+// ForcefullyEndPracticeSessionHandler forcefully ends a practice session without grading it, its record is closed unscored
 func ForcefullyEndPracticeSessionHandler(c *gin.Context) {
 	var request requests.ForcefullyEndPracticeSessionRequest
 
@@ -89,7 +310,8 @@ func ForcefullyEndPracticeSessionHandler(c *gin.Context) {
 	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
 		// Find the practice session record
 		var practiceSessionRecord student_psql.StudentPracticeSessionLookupTable
-		if err := tx.Where("practice_session_id = ? AND status = ?", request.PracticeSessionID, "Active").
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("practice_session_id = ? AND status = ?", request.PracticeSessionID, "Active").
 			First(&practiceSessionRecord).Error; err != nil {
 			return fmt.Errorf("no active practice session found: %w", err)
 		}
@@ -100,10 +322,17 @@ func ForcefullyEndPracticeSessionHandler(c *gin.Context) {
 			return fmt.Errorf("failed to update practice session status: %w", err)
 		}
 
-		// Delete the corresponding record in the StudentPracticeSessionRecordTable
-		if err := tx.Where("practice_session_id = ?", practiceSessionRecord.PracticeSessionID).
-			Delete(&student_psql.StudentPracticeSessionRecordTable{}).Error; err != nil {
-			return fmt.Errorf("failed to delete practice session record: %w", err)
+		// Close the record of the session, nothing was submitted so nothing was scored. The record is kept,
+		// along with the questions served and the responses saved, as for the sessions ended by the reaper.
+		if err := tx.Model(&student_psql.StudentPracticeSessionRecordTable{}).
+			Where("practice_session_id = ?", practiceSessionRecord.PracticeSessionID).
+			Updates(map[string]interface{}{
+				"questions_attempted": 0,
+				"questions_correct":   0,
+				"score_earned":        0,
+				"end_time":            time.Now(),
+			}).Error; err != nil {
+			return fmt.Errorf("failed to close practice session record: %w", err)
 		}
 
 		return nil
@@ -121,3 +350,245 @@ func ForcefullyEndPracticeSessionHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Practice session forcefully ended successfully"})
 }
+
+// ResumePracticeSessionHandler returns the questions served in an active practice session, in serve order,
+// so that a student can continue the session after a reload.
+func ResumePracticeSessionHandler(c *gin.Context) {
+	practiceSessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid practice session ID"})
+		return
+	}
+
+	enrollmentNo, ok := requestStudent(c)
+	if !ok {
+		return
+	}
+	renderHTML, ok := renderHTMLFromQuery(c)
+	if !ok {
+		return
+	}
+
+	var questions []response.PracticeQuestionResponse
+	var passages []response.PracticePassageResponse
+	err = config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		// Only the student who started the session can resume it, and only while it is active
+		var practiceSessionLookupRecord student_psql.StudentPracticeSessionLookupTable
+		if err := tx.Where("practice_session_id = ? AND enrollment_no = ? AND status = ?", practiceSessionID, enrollmentNo, "Active").
+			First(&practiceSessionLookupRecord).Error; err != nil {
+			return fmt.Errorf("no active practice session found: %w", err)
+		}
+
+		sessionQuestions, err := fetchSessionQuestions(tx, practiceSessionLookupRecord.PracticeSessionID)
+		if err != nil {
+			return err
+		}
+
+		// Adaptive sessions resume on the question they are waiting on, the answered ones are over
+		var modes []string
+		if err := tx.Model(&student_psql.StudentPracticeSessionRecordTable{}).
+			Where("practice_session_id = ?", practiceSessionLookupRecord.PracticeSessionID).
+			Pluck("mode", &modes).Error; err != nil {
+			return fmt.Errorf("failed to fetch practice session record: %w", err)
+		}
+		if slices.Contains(modes, student_psql.PracticeSessionModeAdaptive) {
+			if sessionQuestions, err = pendingSessionQuestions(tx, sessionQuestions); err != nil {
+				return err
+			}
+		}
+
+		if questions, err = fetchServedQuestions(tx, sessionQuestions); err != nil {
+			return err
+		}
+		if passages, err = fetchPracticePassages(tx, servedPassageIDs(questions)); err != nil {
+			return err
+		}
+		return attachPracticeQuestionAttachments(c.Request.Context(), tx, questions)
+	})
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resume practice session", "details": err.Error()})
+		}
+		return
+	}
+	if renderHTML {
+		renderPracticeContent(questions, passages)
+	}
+
+	c.JSON(http.StatusOK, response.GetQuestionsResponse{
+		Questions:         questions,
+		Passages:          passages,
+		PracticeSessionID: uint32(practiceSessionID),
+		Message:           "Practice session resumed successfully",
+	})
+}
+
+// ReviewPracticeSessionHandler returns every served question of a submitted practice session along with
+// the answer given by the student, the correct answer and the explanation.
+func ReviewPracticeSessionHandler(c *gin.Context) {
+	practiceSessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid practice session ID"})
+		return
+	}
+
+	enrollmentNo, ok := requestStudent(c)
+	if !ok {
+		return
+	}
+	renderHTML, ok := renderHTMLFromQuery(c)
+	if !ok {
+		return
+	}
+
+	var review response.PracticeSessionReviewResponse
+	err = config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		var practiceSessionLookupRecord student_psql.StudentPracticeSessionLookupTable
+		if err := tx.Where("practice_session_id = ? AND enrollment_no = ?", practiceSessionID, enrollmentNo).
+			First(&practiceSessionLookupRecord).Error; err != nil {
+			return fmt.Errorf("practice session not found: %w", err)
+		}
+
+		// The answers are revealed only after the session is submitted
+		if practiceSessionLookupRecord.Status != "Submitted" {
+			return errPracticeSessionNotSubmitted
+		}
+
+		var practiceSessionRecord student_psql.StudentPracticeSessionRecordTable
+		if err := tx.Where("practice_session_id = ?", practiceSessionID).
+			First(&practiceSessionRecord).Error; err != nil {
+			return fmt.Errorf("practice session not found: %w", err)
+		}
+
+		sessionQuestions, err := fetchSessionQuestions(tx, practiceSessionRecord.PracticeSessionID)
+		if err != nil {
+			return err
+		}
+
+		details, formats, err := fetchServedQuestionDetails(tx, sessionQuestions)
+		if err != nil {
+			return err
+		}
+
+		var responses []student_psql.StudentPracticeSessionResponseTable
+		if err := tx.Where("practice_session_id = ?", practiceSessionRecord.PracticeSessionID).
+			Find(&responses).Error; err != nil {
+			return fmt.Errorf("failed to fetch practice session responses: %w", err)
+		}
+		responsesByServeOrder := make(map[int]student_psql.StudentPracticeSessionResponseTable, len(responses))
+		for _, sessionResponse := range responses {
+			responsesByServeOrder[sessionResponse.ServeOrder] = sessionResponse
+		}
+
+		review = response.PracticeSessionReviewResponse{
+			PracticeSessionID:  practiceSessionRecord.PracticeSessionID,
+			QuestionsAttempted: practiceSessionRecord.QuestionsAttempted,
+			QuestionsCorrect:   practiceSessionRecord.QuestionsCorrect,
+			ScoreEarned:        practiceSessionRecord.ScoreEarned,
+			Questions:          make([]response.PracticeSessionReviewQuestion, 0, len(sessionQuestions)),
+		}
+
+		var passageIDs []uint32
+		for _, sessionQuestion := range sessionQuestions {
+			detail, exists := details[servedQuestionKey{sessionQuestion.QuestionFormatID, sessionQuestion.QuestionID}]
+			if !exists {
+				continue // Question was deleted since it was served, before revisions were tracked
+			}
+			sessionResponse := responsesByServeOrder[sessionQuestion.ServeOrder]
+
+			// Responses graded before partial credit only have their result
+			credit := sessionResponse.Credit
+			if credit == nil && sessionResponse.IsCorrect != nil {
+				fullCredit := 0.0
+				if *sessionResponse.IsCorrect {
+					fullCredit = 1
+				}
+				credit = &fullCredit
+			}
+
+			reviewQuestion := response.PracticeSessionReviewQuestion{
+				ServeOrder:       sessionQuestion.ServeOrder,
+				QuestionFormatID: sessionQuestion.QuestionFormatID,
+				QuestionID:       sessionQuestion.QuestionID,
+				RevisionID:       detail.RevisionID,
+				Format:           formats[sessionQuestion.QuestionFormatID],
+				QuestionText:     detail.Base.QuestionText,
+				Options:          detail.Options,
+				IsMultiSelect:    detail.MultiSelect,
+				Unit:             detail.Unit,
+				Language:         sessionResponse.Language,
+				TestVerdicts:     sessionResponse.TestVerdicts,
+				ColumnA:          detail.Pairs.ColumnA(),
+				ColumnB:          detail.Pairs.ColumnB(),
+				Items:            detail.Sequence,
+				Arrangement:      sessionResponse.Arrangement,
+				ChosenOptions:    sessionResponse.ChosenOptions,
+				CorrectOptions:   detail.CorrectOptions,
+				StudentAnswer:    sessionResponse.ChosenAnswer,
+				CorrectAnswer:    detail.Base.Answer,
+				Explanation:      detail.Explanation,
+				IsCorrect:        sessionResponse.IsCorrect,
+				Credit:           credit,
+				TimeSpentSeconds: sessionResponse.TimeSpentSeconds,
+			}
+			if sessionQuestion.PassageID != nil {
+				reviewQuestion.PassageID = *sessionQuestion.PassageID
+				if !slices.Contains(passageIDs, reviewQuestion.PassageID) {
+					passageIDs = append(passageIDs, reviewQuestion.PassageID)
+				}
+			}
+			review.Questions = append(review.Questions, reviewQuestion)
+		}
+
+		if review.Passages, err = fetchPracticePassages(tx, passageIDs); err != nil {
+			return err
+		}
+
+		questionKeys := make([]servedQuestionKey, 0, len(review.Questions))
+		optionCounts := make(map[servedQuestionKey]int, len(review.Questions))
+		for _, reviewQuestion := range review.Questions {
+			key := servedQuestionKey{reviewQuestion.QuestionFormatID, reviewQuestion.QuestionID}
+			questionKeys = append(questionKeys, key)
+			optionCounts[key] = len(reviewQuestion.Options)
+		}
+		attachments, err := servedAttachments(c.Request.Context(), tx, questionKeys, optionCounts)
+		if err != nil {
+			return err
+		}
+		for i := range review.Questions {
+			review.Questions[i].Attachments = attachments[servedQuestionKey{review.Questions[i].QuestionFormatID, review.Questions[i].QuestionID}]
+		}
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, errPracticeSessionNotSubmitted):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review practice session", "details": err.Error()})
+		}
+		return
+	}
+	if renderHTML {
+		renderReviewContent(&review)
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// GetPracticeSessionReaperStatusHandler returns the last run and the counts of the session reaper
+func GetPracticeSessionReaperStatusHandler(c *gin.Context) {
+	status, err := workers.GetPracticeSessionReaperStatus()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch session reaper status", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,63 @@
+package controllersNew
+
+import (
+	"testing"
+
+	student_psql "server/models/student_psql"
+)
+
+func TestTallyPracticeSession(t *testing.T) {
+	graded := func(chosenAnswer string, correct bool, credit float64) student_psql.StudentPracticeSessionResponseTable {
+		return student_psql.StudentPracticeSessionResponseTable{ChosenAnswer: chosenAnswer, IsCorrect: &correct, Credit: &credit}
+	}
+
+	tests := []struct {
+		name      string
+		responses []student_psql.StudentPracticeSessionResponseTable
+		want      gradedPracticeSession
+	}{
+		{
+			name:      "every answer correct",
+			responses: []student_psql.StudentPracticeSessionResponseTable{graded("Paris", true, 1), graded("12.5", true, 1)},
+			want:      gradedPracticeSession{QuestionsAttempted: 2, QuestionsCorrect: 2, ScoreEarned: 100},
+		},
+		{
+			name:      "partial credit counts towards the score",
+			responses: []student_psql.StudentPracticeSessionResponseTable{graded("Neon", false, 0.5), graded("Rome", false, 0)},
+			want:      gradedPracticeSession{QuestionsAttempted: 2, ScoreEarned: 25},
+		},
+		{
+			name: "skipped questions count against the score",
+			responses: []student_psql.StudentPracticeSessionResponseTable{
+				graded("Paris", true, 1),
+				graded("", false, 0),
+				graded("Rome", false, 0),
+				{ChosenOptions: []int32{1}, IsCorrect: new(bool), Credit: new(float64)},
+			},
+			want: gradedPracticeSession{QuestionsAttempted: 3, QuestionsCorrect: 1, ScoreEarned: 25},
+		},
+		{
+			name: "ungraded answers are left out of the score",
+			responses: []student_psql.StudentPracticeSessionResponseTable{
+				graded("Paris", true, 1),
+				{ChosenAnswer: "An essay"},
+			},
+			want: gradedPracticeSession{QuestionsAttempted: 2, QuestionsCorrect: 1, ScoreEarned: 100},
+		},
+		{
+			name:      "only ungraded answers",
+			responses: []student_psql.StudentPracticeSessionResponseTable{{ChosenAnswer: "An essay"}},
+			want:      gradedPracticeSession{QuestionsAttempted: 1},
+		},
+		{
+			name: "no responses",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := tallyPracticeSession(test.responses); got != test.want {
+				t.Errorf("tallyPracticeSession() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package controllersNew
+
+import (
+	"fmt"
+	question_hierarchy "server/models/question_bank/question_hierarchy"
+	question_type "server/models/question_bank/question_type"
+	"server/models/response"
+	student_psql "server/models/student_psql"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// servedQuestionKey identifies a question across all the question tables
+type servedQuestionKey struct {
+	QuestionFormatID uint32
+	QuestionID       uint32
+}
+
+// storeServedQuestions records the questions served in a practice session in serve order from firstServeOrder, along with the
+// revision of each question that was served and the order its items were shuffled into. The revision IDs are filled in on the given questions.
+func storeServedQuestions(tx *gorm.DB, practiceSessionID uint32, firstServeOrder int, questions []response.PracticeQuestionResponse) error {
+	if len(questions) == 0 {
+		return nil
+	}
+
+	var formatIDs []uint32
+	questionIDsByFormat := make(map[uint32][]uint32)
+	for _, question := range questions {
+		if _, exists := questionIDsByFormat[question.QuestionFormatID]; !exists {
+			formatIDs = append(formatIDs, question.QuestionFormatID)
+		}
+		questionIDsByFormat[question.QuestionFormatID] = append(questionIDsByFormat[question.QuestionFormatID], question.QuestionID)
+	}
+	formats, err := fetchFormatsByID(tx, formatIDs)
+	if err != nil {
+		return err
+	}
+
+	revisionIDs := make(map[servedQuestionKey]uint32, len(questions))
+	for _, formatID := range formatIDs {
+		latest, err := question_type.LatestQuestionRevisionIDs(tx, formats[formatID], formatID, questionIDsByFormat[formatID])
+		if err != nil {
+			return err
+		}
+		for questionID, revisionID := range latest {
+			revisionIDs[servedQuestionKey{formatID, questionID}] = revisionID
+		}
+	}
+
+	sessionQuestions := make([]student_psql.StudentPracticeSessionQuestionTable, 0, len(questions))
+	for i := range questions {
+		sessionQuestion := student_psql.StudentPracticeSessionQuestionTable{
+			PracticeSessionID: practiceSessionID,
+			ServeOrder:        firstServeOrder + i,
+			QuestionFormatID:  questions[i].QuestionFormatID,
+			QuestionID:        questions[i].QuestionID,
+			Permutation:       questions[i].Permutation,
+		}
+		if revisionID, exists := revisionIDs[servedQuestionKey{questions[i].QuestionFormatID, questions[i].QuestionID}]; exists {
+			sessionQuestion.QuestionRevisionID = &revisionID
+			questions[i].RevisionID = revisionID
+		}
+		if questions[i].PassageID != 0 {
+			passageID := questions[i].PassageID
+			sessionQuestion.PassageID = &passageID
+		}
+		sessionQuestions = append(sessionQuestions, sessionQuestion)
+	}
+
+	if err := tx.Create(&sessionQuestions).Error; err != nil {
+		return fmt.Errorf("failed to store served questions: %w", err)
+	}
+	return nil
+}
+
+// servedPassageIDs returns the passages the given questions were served under, each once in serve order
+func servedPassageIDs(questions []response.PracticeQuestionResponse) []uint32 {
+	var passageIDs []uint32
+	for _, question := range questions {
+		if question.PassageID != 0 && !slices.Contains(passageIDs, question.PassageID) {
+			passageIDs = append(passageIDs, question.PassageID)
+		}
+	}
+	return passageIDs
+}
+
+// fetchPracticePassages returns the passages served in a session, in the given order
+func fetchPracticePassages(tx *gorm.DB, passageIDs []uint32) ([]response.PracticePassageResponse, error) {
+	if len(passageIDs) == 0 {
+		return nil, nil
+	}
+
+	var passages []question_type.QuestionPassage
+	if err := tx.Where("passage_id IN ?", passageIDs).Find(&passages).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch passages: %w", err)
+	}
+	passagesByID := make(map[uint32]question_type.QuestionPassage, len(passages))
+	for _, passage := range passages {
+		passagesByID[passage.PassageID] = passage
+	}
+
+	// Passages deleted since the session was served are left out
+	practicePassages := make([]response.PracticePassageResponse, 0, len(passageIDs))
+	for _, passageID := range passageIDs {
+		if passage, exists := passagesByID[passageID]; exists {
+			practicePassages = append(practicePassages, response.PracticePassageResponse{
+				PassageID: passage.PassageID,
+				Title:     passage.Title,
+				Body:      passage.Body,
+			})
+		}
+	}
+	return practicePassages, nil
+}
+
+// fetchSessionQuestions returns the questions served in a practice session in serve order
+func fetchSessionQuestions(tx *gorm.DB, practiceSessionID uint32) ([]student_psql.StudentPracticeSessionQuestionTable, error) {
+	var sessionQuestions []student_psql.StudentPracticeSessionQuestionTable
+	if err := tx.Where("practice_session_id = ?", practiceSessionID).
+		Order("serve_order").
+		Find(&sessionQuestions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch served questions: %w", err)
+	}
+	return sessionQuestions, nil
+}
+
+// fetchFormatsByID returns the format (MCQ, TF, FIB, TXT, NUM, CODE, MTC, ORD) of each of the given format nodes
+func fetchFormatsByID(tx *gorm.DB, formatIDs []uint32) (map[uint32]string, error) {
+	var formats []question_hierarchy.QuestionFormatTable
+	if err := tx.Select("question_format_id", "format").
+		Where("question_format_id IN ?", formatIDs).
+		Find(&formats).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch question formats: %w", err)
+	}
+
+	formatsByID := make(map[uint32]string, len(formats))
+	for _, format := range formats {
+		formatsByID[format.QuestionFormatID] = format.Format
+	}
+	return formatsByID, nil
+}
+
+// groupSessionQuestionsByFormat groups the served question IDs by their format node
+func groupSessionQuestionsByFormat(sessionQuestions []student_psql.StudentPracticeSessionQuestionTable) (formatIDs []uint32, questionIDsByFormat map[uint32][]uint32) {
+	questionIDsByFormat = make(map[uint32][]uint32)
+	for _, sessionQuestion := range sessionQuestions {
+		if _, exists := questionIDsByFormat[sessionQuestion.QuestionFormatID]; !exists {
+			formatIDs = append(formatIDs, sessionQuestion.QuestionFormatID)
+		}
+		questionIDsByFormat[sessionQuestion.QuestionFormatID] = append(questionIDsByFormat[sessionQuestion.QuestionFormatID], sessionQuestion.QuestionID)
+	}
+	return formatIDs, questionIDsByFormat
+}
+
+// Generic helper function to fetch specific questions of any type
+func fetchQuestionsOfTypeByIDs[T any](tx *gorm.DB, formatId uint32, questionIDs []uint32) ([]T, error) {
+	var questions []T
+	if err := tx.Where("question_format_id = ? AND question_id IN ?", formatId, questionIDs).
+		Find(&questions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch questions: %w", err)
+	}
+	return questions, nil
+}
+
+// Helper function to fetch specific questions based on format
+func fetchQuestionsByIDs(tx *gorm.DB, questionFormat string, formatId uint32, questionIDs []uint32) (interface{}, error) {
+	switch questionFormat {
+	case "MCQ":
+		return fetchQuestionsOfTypeByIDs[question_type.MCQQuestion](tx, formatId, questionIDs)
+	case "TF":
+		return fetchQuestionsOfTypeByIDs[question_type.TrueFalseQuestion](tx, formatId, questionIDs)
+	case "FIB":
+		return fetchQuestionsOfTypeByIDs[question_type.FillInTheBlankQuestion](tx, formatId, questionIDs)
+	case "TXT":
+		return fetchQuestionsOfTypeByIDs[question_type.TextBasedQuestion](tx, formatId, questionIDs)
+	case "NUM":
+		return fetchQuestionsOfTypeByIDs[question_type.NumericQuestion](tx, formatId, questionIDs)
+	case "CODE":
+		return fetchQuestionsOfTypeByIDs[question_type.CodingQuestion](tx, formatId, questionIDs)
+	case "MTC":
+		return fetchQuestionsOfTypeByIDs[question_type.MatchingQuestion](tx, formatId, questionIDs)
+	case "ORD":
+		return fetchQuestionsOfTypeByIDs[question_type.OrderingQuestion](tx, formatId, questionIDs)
+	default:
+		return nil, fmt.Errorf("invalid question format")
+	}
+}
+
+// questionDetail holds the parts of a question that are only revealed after the session is submitted
+type questionDetail struct {
+	Base              question_type.BaseQuestion
+	Options           []string
+	CorrectOptions    []int32                     // MCQ only
+	MultiSelect       bool                        // MCQ only
+	Tolerance         float64                     // NUM only
+	RelativeTolerance bool                        // NUM only
+	Unit              string                      // NUM only
+	Language          string                      // CODE only, language of the reference solution
+	StarterCode       question_type.StarterCode   // CODE only
+	TestCases         question_type.CodeTestCases // CODE only
+	TimeLimitMs       int                         // CODE only
+	MemoryLimitMB     int                         // CODE only
+	Pairs             question_type.MatchPairs    // MTC only
+	Sequence          []string                    // ORD only, in the correct order
+	Explanation       string
+	RevisionID        uint32 // Revision the content comes from, 0 for the current content of a question served before revisions were tracked
+}
+
+// toQuestionDetails indexes the fetched questions by their key along with their answer and explanation
+func toQuestionDetails(questions interface{}) map[servedQuestionKey]questionDetail {
+	details := make(map[servedQuestionKey]questionDetail)
+
+	addQuestion := func(base question_type.BaseQuestion, options []string, explanation string) {
+		details[servedQuestionKey{base.QuestionFormatID, base.QuestionID}] = questionDetail{
+			Base:        base,
+			Options:     options,
+			Explanation: explanation,
+		}
+	}
+
+	switch q := questions.(type) {
+	case []question_type.MCQQuestion:
+		for _, question := range q {
+			details[servedQuestionKey{question.QuestionFormatID, question.QuestionID}] = questionDetail{
+				Base:           question.BaseQuestion,
+				Options:        question.Options,
+				CorrectOptions: question.CorrectOptionIndices,
+				MultiSelect:    question.IsMultiSelect,
+				Explanation:    question.Explanation,
+			}
+		}
+	case []question_type.TrueFalseQuestion:
+		for _, question := range q {
+			addQuestion(question.BaseQuestion, nil, question.Explanation)
+		}
+	case []question_type.FillInTheBlankQuestion:
+		for _, question := range q {
+			explanation := ""
+			if question.Explanation != nil {
+				explanation = *question.Explanation
+			}
+			addQuestion(question.BaseQuestion, nil, explanation)
+		}
+	case []question_type.TextBasedQuestion:
+		for _, question := range q {
+			addQuestion(question.BaseQuestion, nil, "")
+		}
+	case []question_type.NumericQuestion:
+		for _, question := range q {
+			details[servedQuestionKey{question.QuestionFormatID, question.QuestionID}] = questionDetail{
+				Base:              question.BaseQuestion,
+				Tolerance:         question.Tolerance,
+				RelativeTolerance: question.IsRelativeTolerance,
+				Unit:              question.Unit,
+				Explanation:       question.Explanation,
+			}
+		}
+	case []question_type.CodingQuestion:
+		for _, question := range q {
+			details[servedQuestionKey{question.QuestionFormatID, question.QuestionID}] = questionDetail{
+				Base:          question.BaseQuestion,
+				Language:      question.Language,
+				StarterCode:   question.StarterCode,
+				TestCases:     question.TestCases,
+				TimeLimitMs:   question.TimeLimitMs,
+				MemoryLimitMB: question.MemoryLimitMB,
+				Explanation:   question.Explanation,
+			}
+		}
+	case []question_type.MatchingQuestion:
+		for _, question := range q {
+			details[servedQuestionKey{question.QuestionFormatID, question.QuestionID}] = questionDetail{
+				Base:        question.BaseQuestion,
+				Pairs:       question.Pairs,
+				Explanation: question.Explanation,
+			}
+		}
+	case []question_type.OrderingQuestion:
+		for _, question := range q {
+			details[servedQuestionKey{question.QuestionFormatID, question.QuestionID}] = questionDetail{
+				Base:        question.BaseQuestion,
+				Sequence:    question.Sequence,
+				Explanation: question.Explanation,
+			}
+		}
+	}
+
+	return details
+}
+
+// fetchServedQuestionDetails fetches the full served questions, answers included, along with the
+// format (MCQ, TF, FIB, TXT, NUM, CODE, MTC, ORD) of each format node they belong to. The content is the one of the revision
+// that was served, questions edited or deleted since then are returned as they were served.
+func fetchServedQuestionDetails(tx *gorm.DB, sessionQuestions []student_psql.StudentPracticeSessionQuestionTable) (map[servedQuestionKey]questionDetail, map[uint32]string, error) {
+	formatIDs, questionIDsByFormat := groupSessionQuestionsByFormat(sessionQuestions)
+	formats, err := fetchFormatsByID(tx, formatIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	details := make(map[servedQuestionKey]questionDetail, len(sessionQuestions))
+	for _, formatID := range formatIDs {
+		questions, err := fetchQuestionsByIDs(tx, formats[formatID], formatID, questionIDsByFormat[formatID])
+		if err != nil {
+			return nil, nil, err
+		}
+		for key, detail := range toQuestionDetails(questions) {
+			details[key] = detail
+		}
+	}
+
+	// Replace the current content with the served revisions
+	var revisionIDs []uint32
+	for _, sessionQuestion := range sessionQuestions {
+		if sessionQuestion.QuestionRevisionID != nil {
+			revisionIDs = append(revisionIDs, *sessionQuestion.QuestionRevisionID)
+		}
+	}
+	if len(revisionIDs) == 0 {
+		return details, formats, nil
+	}
+
+	var revisions []question_type.QuestionRevision
+	if err := tx.Where("revision_id IN ?", revisionIDs).Find(&revisions).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch served question revisions: %w", err)
+	}
+	for _, revision := range revisions {
+		key := servedQuestionKey{revision.QuestionFormatID, revision.QuestionID}
+		detail := details[key]
+		detail.Base.QuestionFormatID = revision.QuestionFormatID
+		detail.Base.QuestionID = revision.QuestionID
+		detail.Base.QuestionText = revision.Snapshot.QuestionText
+		detail.Base.Answer = revision.Snapshot.Answer
+		detail.Options = revision.Snapshot.Options
+		detail.CorrectOptions = revision.Snapshot.CorrectOptions
+		detail.MultiSelect = revision.Snapshot.MultiSelect
+		detail.Tolerance = revision.Snapshot.Tolerance
+		detail.RelativeTolerance = revision.Snapshot.RelativeTolerance
+		detail.Unit = revision.Snapshot.Unit
+		detail.Language = revision.Snapshot.Language
+		detail.StarterCode = revision.Snapshot.StarterCode
+		detail.TestCases = revision.Snapshot.TestCases
+		detail.TimeLimitMs = revision.Snapshot.TimeLimitMs
+		detail.MemoryLimitMB = revision.Snapshot.MemoryLimitMB
+		detail.Pairs = revision.Snapshot.Pairs
+		detail.Sequence = revision.Snapshot.Sequence
+		detail.Explanation = revision.Snapshot.Explanation
+		if len(detail.Options) > 0 && len(detail.CorrectOptions) == 0 {
+			// Revisions taken before the correct MCQ options were kept only have the answer text
+			detail.CorrectOptions, _ = question_type.MCQOptionIndices(detail.Options, detail.Base.Answer)
+		}
+		detail.RevisionID = revision.RevisionID
+		details[key] = detail
+	}
+	return details, formats, nil
+}
+
+// fetchServedQuestions returns the answer-stripped DTOs of the questions served in a session, in serve order
+func fetchServedQuestions(tx *gorm.DB, sessionQuestions []student_psql.StudentPracticeSessionQuestionTable) ([]response.PracticeQuestionResponse, error) {
+	details, _, err := fetchServedQuestionDetails(tx, sessionQuestions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Restore the serve order, skipping questions deleted since the session started that have no served revision
+	servedQuestions := make([]response.PracticeQuestionResponse, 0, len(sessionQuestions))
+	for _, sessionQuestion := range sessionQuestions {
+		detail, exists := details[servedQuestionKey{sessionQuestion.QuestionFormatID, sessionQuestion.QuestionID}]
+		if !exists {
+			continue
+		}
+		servedQuestion := response.PracticeQuestionResponse{
+			QuestionFormatID: detail.Base.QuestionFormatID,
+			QuestionID:       detail.Base.QuestionID,
+			QuestionText:     detail.Base.QuestionText,
+			Options:          detail.Options,
+			IsMultiSelect:    detail.MultiSelect,
+			Unit:             detail.Unit,
+			StarterCode:      detail.StarterCode,
+			SampleTests:      toCodeSampleTests(detail.TestCases),
+			TimeLimitMs:      detail.TimeLimitMs,
+			MemoryLimitMB:    detail.MemoryLimitMB,
+			RevisionID:       detail.RevisionID,
+		}
+		servedArrangedItems(&servedQuestion, detail, sessionQuestion.Permutation)
+		if sessionQuestion.PassageID != nil {
+			servedQuestion.PassageID = *sessionQuestion.PassageID
+		}
+		servedQuestions = append(servedQuestions, servedQuestion)
+	}
+	return servedQuestions, nil
+}
+
+// toCodeSampleTests returns the sample test cases of a CODE question, the only ones shown to the students
+func toCodeSampleTests(testCases question_type.CodeTestCases) []response.CodeSampleTest {
+	var sampleTests []response.CodeSampleTest
+	for _, test := range testCases.Samples() {
+		sampleTests = append(sampleTests, response.CodeSampleTest{Input: test.Input, ExpectedOutput: test.ExpectedOutput})
+	}
+	return sampleTests
+}
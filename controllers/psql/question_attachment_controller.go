@@ -0,0 +1,341 @@
+package controllersNew
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"server/config"
+	"server/middlewares"
+	question_type "server/models/question_bank/question_type"
+	requests "server/models/requests"
+	"server/models/response"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// attachmentURLExpiry is how long the signed URL of a served attachment reads its image.
+// Resumed sessions and reloaded editors get their URLs signed anew.
+const attachmentURLExpiry = time.Hour
+
+var errAttachmentNotFound = errors.New("attachment not found")
+
+// checkAttachmentTarget checks that the question exists, that the option exists when the attachment belongs
+// to one, and that the question has room for another attachment
+func checkAttachmentTarget(tx *gorm.DB, formatID, questionID uint32, optionIndex *int32) error {
+	format, question, err := fetchStoredQuestion(tx, formatID, questionID)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	if optionIndex != nil {
+		if format != "MCQ" {
+			problems = append(problems, fmt.Sprintf("only MCQ options can have attachments, the question is %s", format))
+		} else if options := question.RevisionSnapshot().Options; int(*optionIndex) >= len(options) {
+			problems = append(problems, fmt.Sprintf("option %d doesn't exist, the question has %d options", *optionIndex, len(options)))
+		}
+	}
+
+	var count int64
+	if err := tx.Model(&question_type.QuestionAttachment{}).
+		Where("question_format_id = ? AND question_id = ?", formatID, questionID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count question attachments: %w", err)
+	}
+	if count >= question_type.MaxQuestionAttachments {
+		problems = append(problems, fmt.Sprintf("a question can't have more than %d attachments", question_type.MaxQuestionAttachments))
+	}
+
+	if len(problems) > 0 {
+		return questionValidationError{problems: problems}
+	}
+	return nil
+}
+
+// fetchQuestionAttachments returns the attachments of the given questions by question, the images of the
+// question first and then the ones of its options, in upload order
+func fetchQuestionAttachments(tx *gorm.DB, questionKeys []servedQuestionKey) (map[servedQuestionKey][]question_type.QuestionAttachment, error) {
+	if len(questionKeys) == 0 {
+		return nil, nil
+	}
+
+	var formatIDs, questionIDs []uint32
+	for _, key := range questionKeys {
+		formatIDs = append(formatIDs, key.QuestionFormatID)
+		questionIDs = append(questionIDs, key.QuestionID)
+	}
+
+	var attachments []question_type.QuestionAttachment
+	if err := tx.Where("question_format_id IN ? AND question_id IN ?", formatIDs, questionIDs).
+		Order("option_index NULLS FIRST, attachment_id").
+		Find(&attachments).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch question attachments: %w", err)
+	}
+
+	// The IN lists cross the formats and the questions, the attachments of other questions are left out
+	wanted := make(map[servedQuestionKey]bool, len(questionKeys))
+	for _, key := range questionKeys {
+		wanted[key] = true
+	}
+	attachmentsByQuestion := make(map[servedQuestionKey][]question_type.QuestionAttachment)
+	for _, attachment := range attachments {
+		key := servedQuestionKey{attachment.QuestionFormatID, attachment.QuestionID}
+		if wanted[key] {
+			attachmentsByQuestion[key] = append(attachmentsByQuestion[key], attachment)
+		}
+	}
+	return attachmentsByQuestion, nil
+}
+
+// toQuestionAttachmentResponse signs the URL of an attachment to serve it. The attachment is still served
+// without its URL when the cloud storage can't be reached, so that the questions can be answered anyway.
+func toQuestionAttachmentResponse(ctx context.Context, attachment question_type.QuestionAttachment) response.QuestionAttachmentResponse {
+	url, err := config.PresignCloudURL(ctx, attachment.StorageKey, attachmentURLExpiry)
+	if err != nil {
+		log.Printf("Failed to sign the URL of attachment %d: %v", attachment.AttachmentID, err)
+	}
+	return response.QuestionAttachmentResponse{
+		AttachmentID: attachment.AttachmentID,
+		OptionIndex:  attachment.OptionIndex,
+		URL:          url,
+		ContentType:  attachment.ContentType,
+		AltText:      attachment.AltText,
+	}
+}
+
+func toQuestionAttachmentItem(ctx context.Context, attachment question_type.QuestionAttachment) response.QuestionAttachmentItem {
+	return response.QuestionAttachmentItem{
+		QuestionAttachmentResponse: toQuestionAttachmentResponse(ctx, attachment),
+		FileName:                   attachment.FileName,
+		SizeBytes:                  attachment.SizeBytes,
+		UploadedBy:                 attachment.UploadedBy,
+		CreatedAt:                  attachment.CreatedAt,
+	}
+}
+
+// fetchQuestionAttachmentItems returns the attachments of a question as listed to the editors
+func fetchQuestionAttachmentItems(ctx context.Context, tx *gorm.DB, formatID, questionID uint32) ([]response.QuestionAttachmentItem, error) {
+	key := servedQuestionKey{formatID, questionID}
+	attachments, err := fetchQuestionAttachments(tx, []servedQuestionKey{key})
+	if err != nil {
+		return nil, err
+	}
+
+	items := []response.QuestionAttachmentItem{}
+	for _, attachment := range attachments[key] {
+		items = append(items, toQuestionAttachmentItem(ctx, attachment))
+	}
+	return items, nil
+}
+
+// servedAttachments returns the attachments of the served questions by question, the images of the options
+// a question no longer has are left out
+func servedAttachments(ctx context.Context, tx *gorm.DB, questionKeys []servedQuestionKey, optionCounts map[servedQuestionKey]int) (map[servedQuestionKey][]response.QuestionAttachmentResponse, error) {
+	attachments, err := fetchQuestionAttachments(tx, questionKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	served := make(map[servedQuestionKey][]response.QuestionAttachmentResponse, len(attachments))
+	for key, questionAttachments := range attachments {
+		for _, attachment := range questionAttachments {
+			if attachment.OptionIndex != nil && int(*attachment.OptionIndex) >= optionCounts[key] {
+				continue
+			}
+			served[key] = append(served[key], toQuestionAttachmentResponse(ctx, attachment))
+		}
+	}
+	return served, nil
+}
+
+// attachPracticeQuestionAttachments fills the attachments of the questions served in a practice session
+func attachPracticeQuestionAttachments(ctx context.Context, tx *gorm.DB, questions []response.PracticeQuestionResponse) error {
+	questionKeys := make([]servedQuestionKey, 0, len(questions))
+	optionCounts := make(map[servedQuestionKey]int, len(questions))
+	for _, question := range questions {
+		key := servedQuestionKey{question.QuestionFormatID, question.QuestionID}
+		questionKeys = append(questionKeys, key)
+		optionCounts[key] = len(question.Options)
+	}
+
+	attachments, err := servedAttachments(ctx, tx, questionKeys, optionCounts)
+	if err != nil {
+		return err
+	}
+	for i := range questions {
+		questions[i].Attachments = attachments[servedQuestionKey{questions[i].QuestionFormatID, questions[i].QuestionID}]
+	}
+	return nil
+}
+
+// deleteQuestionAttachments removes the attachments of the questions of the format nodes, the question tables
+// can't be referenced by the attachments so their questions are deleted along with them.
+// Returns the storage keys of the removed attachments, whose files are deleted once the transaction is committed.
+func deleteQuestionAttachments(tx *gorm.DB, formatIDs []uint32) ([]string, error) {
+	var storageKeys []string
+	if err := tx.Model(&question_type.QuestionAttachment{}).
+		Where("question_format_id IN ?", formatIDs).
+		Pluck("storage_key", &storageKeys).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch question attachments: %w", err)
+	}
+	if err := tx.Where("question_format_id IN ?", formatIDs).Delete(&question_type.QuestionAttachment{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete question attachments: %w", err)
+	}
+	return storageKeys, nil
+}
+
+// deleteAttachmentFiles removes the files of deleted attachments from the cloud storage.
+// The rows are already gone, a file that can't be deleted is only logged.
+func deleteAttachmentFiles(ctx context.Context, storageKeys []string) {
+	for _, key := range storageKeys {
+		if err := config.DeleteFromCloud(ctx, key); err != nil {
+			log.Printf("Failed to delete attachment file %s: %v", key, err)
+		}
+	}
+}
+
+// respondQuestionAttachmentError maps the errors of the question attachment handlers to their status codes
+func respondQuestionAttachmentError(c *gin.Context, message string, err error) {
+	var validationErr questionValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": validationErr.problems})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+	case errors.Is(err, errAttachmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+	case errors.Is(err, config.ErrCloudStorageUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": message, "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
+
+// ListQuestionAttachmentsHandler returns the images of a question and of its options, with URLs to read them
+func ListQuestionAttachmentsHandler(c *gin.Context) {
+	formatID, questionID, ok := questionKeyFromParams(c)
+	if !ok {
+		return
+	}
+
+	var attachments []response.QuestionAttachmentItem
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		if _, _, err := fetchStoredQuestion(tx, formatID, questionID); err != nil {
+			return err
+		}
+
+		var err error
+		attachments, err = fetchQuestionAttachmentItems(c.Request.Context(), tx, formatID, questionID)
+		return err
+	})
+	if err != nil {
+		respondQuestionAttachmentError(c, "Failed to fetch attachments", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attachments": attachments})
+}
+
+// UploadQuestionAttachmentHandler attaches an uploaded image to a question, or to one of its options with optionIndex.
+// The image is stored in the cloud storage along with the uploaded documents.
+func UploadQuestionAttachmentHandler(c *gin.Context) {
+	formatID, questionID, ok := questionKeyFromParams(c)
+	if !ok {
+		return
+	}
+	var request requests.UploadQuestionAttachmentRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	file, err := request.File.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to open the uploaded file", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	content, contentType, err := question_type.ReadAttachment(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment", "details": err.Error()})
+		return
+	}
+
+	attachment := question_type.QuestionAttachment{
+		QuestionFormatID: formatID,
+		QuestionID:       questionID,
+		OptionIndex:      request.OptionIndex,
+		FileName:         request.File.Filename,
+		ContentType:      contentType,
+		SizeBytes:        int64(len(content)),
+		AltText:          strings.TrimSpace(request.AltText),
+		UploadedBy:       middlewares.RequestUsername(c),
+	}
+
+	// The file is uploaded last, a failed upload rolls the attachment back
+	err = config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		if err := checkAttachmentTarget(tx, formatID, questionID, request.OptionIndex); err != nil {
+			return err
+		}
+
+		var err error
+		if attachment.StorageKey, err = question_type.NewAttachmentStorageKey(formatID, questionID, contentType); err != nil {
+			return err
+		}
+		if err := tx.Create(&attachment).Error; err != nil {
+			return fmt.Errorf("failed to store attachment: %w", err)
+		}
+
+		_, err = config.UploadToCloud(c.Request.Context(), bytes.NewReader(content), attachment.StorageKey, contentType)
+		return err
+	})
+	if err != nil {
+		respondQuestionAttachmentError(c, "Failed to upload attachment", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"attachment": toQuestionAttachmentItem(c.Request.Context(), attachment)})
+}
+
+// DeleteQuestionAttachmentHandler removes an image from a question along with its file
+func DeleteQuestionAttachmentHandler(c *gin.Context) {
+	formatID, questionID, ok := questionKeyFromParams(c)
+	if !ok {
+		return
+	}
+	attachmentID, err := strconv.ParseUint(c.Param("attachmentID"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID", "details": err.Error()})
+		return
+	}
+
+	var attachment question_type.QuestionAttachment
+	err = config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("attachment_id = ? AND question_format_id = ? AND question_id = ?", attachmentID, formatID, questionID).
+			Take(&attachment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errAttachmentNotFound
+			}
+			return fmt.Errorf("failed to fetch attachment: %w", err)
+		}
+		if err := tx.Delete(&attachment).Error; err != nil {
+			return fmt.Errorf("failed to delete attachment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		respondQuestionAttachmentError(c, "Failed to delete attachment", err)
+		return
+	}
+	deleteAttachmentFiles(c.Request.Context(), []string{attachment.StorageKey})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+}
@@ -275,51 +275,85 @@ func validateStudentPracticeSessionRecordTableInput(input requests.GetQuestionsR
 	return validate.Struct(input)
 }
 
-// Generic helper function to fetch questions of any type
-func fetchQuestionsOfType[T any](formatId, lastAttemptedQuestionID uint32, requiredQuestionCount int) ([]T, error) {
-	var questions []T
-	if err := config.GetPostgresDBConnection().
-		Where("question_format_id = ? AND id > ?", formatId, lastAttemptedQuestionID).
-		Limit(requiredQuestionCount).
-		Find(&questions).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch questions: %v", err)
+// toPracticeQuestions converts the fetched questions into DTOs that don't expose the answer key.
+// The items of the MTC and ORD questions are shuffled.
+func toPracticeQuestions(questions interface{}) []response.PracticeQuestionResponse {
+	var practiceQuestions []response.PracticeQuestionResponse
+
+	appendQuestion := func(base question_type.BaseQuestion, options []string, multiSelect bool) {
+		practiceQuestions = append(practiceQuestions, response.PracticeQuestionResponse{
+			QuestionFormatID: base.QuestionFormatID,
+			QuestionID:       base.QuestionID,
+			QuestionText:     base.QuestionText,
+			Options:          options,
+			IsMultiSelect:    multiSelect,
+		})
 	}
 
-	// If questions are less than required, fetch remaining questions
-	if len(questions) < requiredQuestionCount {
-		var remainingQuestions []T
-		remainingCount := requiredQuestionCount - len(questions)
-		if err := config.GetPostgresDBConnection().
-			Where("question_format_id = ? AND id <= ?", formatId, 1).
-			Limit(remainingCount).
-			Find(&remainingQuestions).Error; err != nil {
-			return nil, fmt.Errorf("failed to fetch additional questions: %v", err)
+	switch q := questions.(type) {
+	case []question_type.MCQQuestion:
+		for _, question := range q {
+			appendQuestion(question.BaseQuestion, question.Options, question.IsMultiSelect)
+		}
+	case []question_type.TrueFalseQuestion:
+		for _, question := range q {
+			appendQuestion(question.BaseQuestion, nil, false)
+		}
+	case []question_type.FillInTheBlankQuestion:
+		for _, question := range q {
+			appendQuestion(question.BaseQuestion, nil, false)
+		}
+	case []question_type.TextBasedQuestion:
+		for _, question := range q {
+			appendQuestion(question.BaseQuestion, nil, false)
+		}
+	case []question_type.NumericQuestion:
+		for _, question := range q {
+			practiceQuestions = append(practiceQuestions, response.PracticeQuestionResponse{
+				QuestionFormatID: question.QuestionFormatID,
+				QuestionID:       question.QuestionID,
+				QuestionText:     question.QuestionText,
+				Unit:             question.Unit,
+			})
+		}
+	case []question_type.CodingQuestion:
+		for _, question := range q {
+			practiceQuestions = append(practiceQuestions, response.PracticeQuestionResponse{
+				QuestionFormatID: question.QuestionFormatID,
+				QuestionID:       question.QuestionID,
+				QuestionText:     question.QuestionText,
+				StarterCode:      question.StarterCode,
+				SampleTests:      toCodeSampleTests(question.TestCases),
+				TimeLimitMs:      question.TimeLimitMs,
+				MemoryLimitMB:    question.MemoryLimitMB,
+			})
+		}
+	case []question_type.MatchingQuestion:
+		for _, question := range q {
+			columnB, permutation := shuffleItems(question.Pairs.ColumnB())
+			practiceQuestions = append(practiceQuestions, response.PracticeQuestionResponse{
+				QuestionFormatID: question.QuestionFormatID,
+				QuestionID:       question.QuestionID,
+				QuestionText:     question.QuestionText,
+				ColumnA:          question.Pairs.ColumnA(),
+				ColumnB:          columnB,
+				Permutation:      permutation,
+			})
+		}
+	case []question_type.OrderingQuestion:
+		for _, question := range q {
+			items, permutation := shuffleItems(question.Sequence)
+			practiceQuestions = append(practiceQuestions, response.PracticeQuestionResponse{
+				QuestionFormatID: question.QuestionFormatID,
+				QuestionID:       question.QuestionID,
+				QuestionText:     question.QuestionText,
+				Items:            items,
+				Permutation:      permutation,
+			})
 		}
-		questions = append(questions, remainingQuestions...)
-	}
-
-	return questions, nil
-}
-
-// Helper function to fetch questions based on format
-func fetchQuestionsByFormat(questionFormat string, formatId, lastAttemptedQuestionID uint32, requiredQuestionCount int) (interface{}, error) {
-	var questions interface{}
-	var err error
-
-	switch questionFormat {
-	case "MCQ":
-		questions, err = fetchQuestionsOfType[question_type.MCQQuestion](formatId, lastAttemptedQuestionID, requiredQuestionCount)
-	case "TF":
-		questions, err = fetchQuestionsOfType[question_type.TrueFalseQuestion](formatId, lastAttemptedQuestionID, requiredQuestionCount)
-	case "FIB":
-		questions, err = fetchQuestionsOfType[question_type.FillInTheBlankQuestion](formatId, lastAttemptedQuestionID, requiredQuestionCount)
-	case "TXT":
-		questions, err = fetchQuestionsOfType[question_type.TextBasedQuestion](formatId, lastAttemptedQuestionID, requiredQuestionCount)
-	default:
-		return nil, fmt.Errorf("invalid question format")
 	}
 
-	return questions, err
+	return practiceQuestions
 }
 
 // GetQuestions returns questions in a paginated way for practice sessions
@@ -332,6 +366,10 @@ func GetQuestions(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
 		return
 	}
+	renderHTML, ok := renderHTMLFromQuery(c)
+	if !ok {
+		return
+	}
 
 	// Validate the enrollment number
 	if err := validateStudentPracticeSessionRecordTableInput(request); err != nil {
@@ -342,21 +380,49 @@ func GetQuestions(c *gin.Context) {
 	// Extract values from the request
 	requiredQuestionCount := request.QuestionCount
 	formatId := request.QuestionFormatID
-	lastAttemptedQuestionID := request.LastAttemptedQuestionID
 
 	// Validate the number of questions to attempt limit
 	if requiredQuestionCount != 1 && requiredQuestionCount != 10 && requiredQuestionCount != 30 && requiredQuestionCount != 60 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid QuestionCount. Must be 10, 30, or 60"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid QuestionCount. Must be 1, 10, 30, or 60"})
+		return
+	}
+
+	// Resolve the format from the hierarchy instead of trusting the one sent by the client,
+	// it decides which answer key the session is graded against.
+	var questionFormat question_hierarchy.QuestionFormatTable
+	if err := config.GetPostgresDBConnection().
+		Where("question_format_id = ? AND question_difficulty_level_id = ?", formatId, request.QuestionDifficultyLevelID).
+		First(&questionFormat).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question format", "details": err.Error()})
 		return
 	}
 
-	// Fetch random questions from the appropriate table based on format
-	questions, err := fetchQuestionsByFormat(request.QuestionFormat, formatId, lastAttemptedQuestionID, requiredQuestionCount)
+	// Tags are matched regardless of case
+	tags, err := normalizeTagFilter(request.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tags", "details": err.Error()})
+		return
+	}
+
+	// Select random questions the student hasn't seen yet, stripped of their answers and explanations
+	questions, err := selectPracticeQuestions(config.GetPostgresDBConnection(), questionSelectionRequest{
+		EnrollmentNo:   request.EnrollmentNo,
+		QuestionFormat: questionFormat.Format,
+		FormatID:       formatId,
+		Count:          requiredQuestionCount,
+		Tags:           tags,
+		MatchAllTags:   request.TagMatch == "all",
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if len(questions) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"message": "No questions found"})
+		return
+	}
+
 	// No need to check for an active session because if user terminates the session halfway the front-end
 	// is required to forcefully end the session using the "/end-forcefully" route.
 
@@ -365,6 +431,9 @@ func GetQuestions(c *gin.Context) {
 		DomainID:           request.QuestionDomainID,
 		SubDomainID:        request.QuestionSubDomainID,
 		DifficultyLevelID:  request.QuestionDifficultyLevelID,
+		QuestionFormatID:   formatId,
+		Mode:               student_psql.PracticeSessionModeFixed,
+		QuestionsServed:    len(questions),
 		QuestionsAttempted: -1,                              // This will be updated after the session is completed
 		QuestionsCorrect:   -1,                              // This will be updated after the session is completed
 		ScoreEarned:        -1,                              // This will be updated after the session is completed
@@ -372,25 +441,45 @@ func GetQuestions(c *gin.Context) {
 		EndTime:            time.Time{},                     // Default value indicating the end time is not set yet
 	}
 
-	if err := config.GetPostgresDBConnection().Create(&practiceSessionRecord).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store practice session record", "details": err.Error()})
-		return
-	}
+	// Store the session, its lookup entry and the served questions together so that a session
+	// never exists without the questions it has to be graded against.
+	var passages []response.PracticePassageResponse
+	err = config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&practiceSessionRecord).Error; err != nil {
+			return fmt.Errorf("failed to store practice session record: %w", err)
+		}
 
-	// Insert the record in the practice session lookup table
-	practiceSessionLookupRecord := student_psql.StudentPracticeSessionLookupTable{
-		EnrollmentNo:      request.EnrollmentNo,
-		PracticeSessionID: practiceSessionRecord.PracticeSessionID,
-	}
+		// Insert the record in the practice session lookup table
+		practiceSessionLookupRecord := student_psql.StudentPracticeSessionLookupTable{
+			EnrollmentNo:      request.EnrollmentNo,
+			PracticeSessionID: practiceSessionRecord.PracticeSessionID,
+		}
 
-	if err := config.GetPostgresDBConnection().Create(&practiceSessionLookupRecord).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store practice session record in lookup", "details": err.Error()})
-		return
+		if err := tx.Create(&practiceSessionLookupRecord).Error; err != nil {
+			return fmt.Errorf("failed to store practice session record in lookup: %w", err)
+		}
+
+		// Record the exact questions served in this session, in serve order
+		if err := storeServedQuestions(tx, practiceSessionRecord.PracticeSessionID, 1, questions); err != nil {
+			return err
+		}
 
+		if passages, err = fetchPracticePassages(tx, servedPassageIDs(questions)); err != nil {
+			return err
+		}
+		return attachPracticeQuestionAttachments(c.Request.Context(), tx, questions)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start practice session", "details": err.Error()})
+		return
+	}
+	if renderHTML {
+		renderPracticeContent(questions, passages)
 	}
 
 	response := response.GetQuestionsResponse{
 		Questions:         questions,
+		Passages:          passages,
 		PracticeSessionID: practiceSessionRecord.PracticeSessionID,
 		Message:           "Practice session started successfully",
 	}
@@ -0,0 +1,544 @@
+package controllersNew
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"server/cache"
+	"server/config"
+	question_hierarchy "server/models/question_bank/question_hierarchy"
+	question_type "server/models/question_bank/question_type"
+	requests "server/models/requests"
+	"server/models/response"
+	student_psql "server/models/student_psql"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	errHierarchyNameTaken      = errors.New("a sibling node with the same name already exists")
+	errHierarchyParentNotFound = errors.New("parent node not found")
+	errFormatNodeHasQuestions  = errors.New("the format of a node with questions can't be changed, its questions live in the table of the format")
+	errDomainHasNoParent       = errors.New("domains are at the top of the hierarchy and can't be moved")
+)
+
+// hierarchyLevel describes the table of a level of the question hierarchy so that the admin
+// handlers work on every level alike
+type hierarchyLevel struct {
+	Slug         string // Route segment of the level, same as the read-only routes
+	Table        string
+	IDColumn     string
+	NameColumn   string
+	ParentColumn string // Column holding the ID of the parent node, empty for domains
+
+	// normalizeName validates a node name and brings it to the stored spelling
+	normalizeName func(name string) (string, error)
+}
+
+// normalizeNodeName is the name rule of the levels that hold free text names
+func normalizeNodeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("name can't be empty")
+	}
+	return name, nil
+}
+
+// hierarchyLevels lists the levels from the top (domains) to the bottom (formats)
+var hierarchyLevels = []hierarchyLevel{
+	{
+		Slug:          "domains",
+		Table:         question_hierarchy.QuestionDomainsTable{}.TableName(),
+		IDColumn:      "question_domain_id",
+		NameColumn:    "domain_name",
+		normalizeName: normalizeNodeName,
+	},
+	{
+		Slug:          "subdomains",
+		Table:         question_hierarchy.QuestionSubDomainsTable{}.TableName(),
+		IDColumn:      "question_sub_domain_id",
+		NameColumn:    "sub_domain_name",
+		ParentColumn:  "question_domain_id",
+		normalizeName: normalizeNodeName,
+	},
+	{
+		Slug:          "niches",
+		Table:         question_hierarchy.QuestionNicheTable{}.TableName(),
+		IDColumn:      "question_niche_id",
+		NameColumn:    "niche_name",
+		ParentColumn:  "question_sub_domain_id",
+		normalizeName: normalizeNodeName,
+	},
+	{
+		Slug:         "difficulty-levels",
+		Table:        question_hierarchy.QuestionDifficultyLevelTable{}.TableName(),
+		IDColumn:     "question_difficulty_level_id",
+		NameColumn:   "difficulty_level",
+		ParentColumn: "question_niche_id",
+		normalizeName: func(name string) (string, error) {
+			name = strings.ToUpper(strings.TrimSpace(name))
+			if !slices.Contains([]string{"EASY", "MEDIUM", "HARD"}, name) {
+				return "", errors.New("difficulty level must be EASY, MEDIUM or HARD")
+			}
+			return name, nil
+		},
+	},
+	{
+		Slug:         "formats",
+		Table:        question_hierarchy.QuestionFormatTable{}.TableName(),
+		IDColumn:     "question_format_id",
+		NameColumn:   "format",
+		ParentColumn: "question_difficulty_level_id",
+		normalizeName: func(name string) (string, error) {
+			name = strings.ToUpper(strings.TrimSpace(name))
+			if !slices.Contains(question_type.QuestionFormats, name) {
+				return "", fmt.Errorf("format must be one of %s", strings.Join(question_type.QuestionFormats, ", "))
+			}
+			return name, nil
+		},
+	},
+}
+
+// hierarchyLevelFromParam returns the index of the level named by the :level route parameter
+func hierarchyLevelFromParam(c *gin.Context) (int, bool) {
+	index := slices.IndexFunc(hierarchyLevels, func(level hierarchyLevel) bool {
+		return level.Slug == c.Param("level")
+	})
+	if index < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown hierarchy level", "details": c.Param("level")})
+		return 0, false
+	}
+	return index, true
+}
+
+// hierarchyNodeIDFromParam parses the :id route parameter
+func hierarchyNodeIDFromParam(c *gin.Context) (uint32, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid node ID", "details": err.Error()})
+		return 0, false
+	}
+	return uint32(id), true
+}
+
+// nodeColumns selects the columns of the level as the fields of a HierarchyNodeResponse
+func (level hierarchyLevel) nodeColumns() string {
+	parentColumn := "0"
+	if level.ParentColumn != "" {
+		parentColumn = level.ParentColumn
+	}
+	return fmt.Sprintf("%s AS id, %s AS name, %s AS parent_id", level.IDColumn, level.NameColumn, parentColumn)
+}
+
+// fetchHierarchyNode returns the node of the level, gorm.ErrRecordNotFound if it doesn't exist
+func fetchHierarchyNode(tx *gorm.DB, levelIndex int, id uint32) (response.HierarchyNodeResponse, error) {
+	level := hierarchyLevels[levelIndex]
+
+	node := response.HierarchyNodeResponse{Level: level.Slug}
+	result := tx.Table(level.Table).
+		Select(level.nodeColumns()).
+		Where(level.IDColumn+" = ?", id).
+		Scan(&node)
+	if result.Error != nil {
+		return node, fmt.Errorf("failed to fetch %s node: %w", level.Slug, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return node, gorm.ErrRecordNotFound
+	}
+	return node, nil
+}
+
+// checkHierarchySiblings makes sure that no other child of the parent has the name (case-insensitively)
+func checkHierarchySiblings(tx *gorm.DB, levelIndex int, parentID uint32, name string, excludeID uint32) error {
+	level := hierarchyLevels[levelIndex]
+	query := tx.Table(level.Table).
+		Where(fmt.Sprintf("LOWER(%s) = LOWER(?) AND %s <> ?", level.NameColumn, level.IDColumn), name, excludeID)
+	if level.ParentColumn != "" {
+		query = query.Where(level.ParentColumn+" = ?", parentID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check the sibling nodes: %w", err)
+	}
+	if count > 0 {
+		return errHierarchyNameTaken
+	}
+	return nil
+}
+
+// checkHierarchyParent makes sure that the parent of a node of the level exists
+func checkHierarchyParent(tx *gorm.DB, levelIndex int, parentID uint32) error {
+	if _, err := fetchHierarchyNode(tx, levelIndex-1, parentID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return errHierarchyParentNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// collectHierarchyDescendants returns the IDs of the node and of all its descendants, per level index
+func collectHierarchyDescendants(tx *gorm.DB, levelIndex int, id uint32) (map[int][]uint32, error) {
+	nodeIDs := map[int][]uint32{levelIndex: {id}}
+
+	for childIndex := levelIndex + 1; childIndex < len(hierarchyLevels); childIndex++ {
+		parentIDs := nodeIDs[childIndex-1]
+		if len(parentIDs) == 0 {
+			break
+		}
+
+		child := hierarchyLevels[childIndex]
+		var childIDs []uint32
+		if err := tx.Table(child.Table).
+			Where(child.ParentColumn+" IN ?", parentIDs).
+			Pluck(child.IDColumn, &childIDs).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", child.Slug, err)
+		}
+		nodeIDs[childIndex] = childIDs
+	}
+
+	return nodeIDs, nil
+}
+
+// countQuestionsOfFormatNodes counts the questions of the format nodes, per format
+func countQuestionsOfFormatNodes(tx *gorm.DB, formatIDs []uint32) (map[string]int64, error) {
+	counts := make(map[string]int64, len(question_type.QuestionFormats))
+	for _, format := range question_type.QuestionFormats {
+		counts[format] = 0
+		if len(formatIDs) == 0 {
+			continue
+		}
+
+		questionTable, err := question_type.QuestionTableForFormat(format)
+		if err != nil {
+			return nil, err
+		}
+		var count int64
+		if err := tx.Table(questionTable).Where("question_format_id IN ?", formatIDs).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to count %s questions: %w", format, err)
+		}
+		counts[format] = count
+	}
+	return counts, nil
+}
+
+// buildHierarchyDeletePreview lists what deleting the node takes along with it
+func buildHierarchyDeletePreview(tx *gorm.DB, levelIndex int, id uint32) (response.HierarchyDeletePreviewResponse, map[int][]uint32, error) {
+	var preview response.HierarchyDeletePreviewResponse
+
+	node, err := fetchHierarchyNode(tx, levelIndex, id)
+	if err != nil {
+		return preview, nil, err
+	}
+	preview.Node = node
+
+	nodeIDs, err := collectHierarchyDescendants(tx, levelIndex, id)
+	if err != nil {
+		return preview, nil, err
+	}
+	preview.Descendants = make(map[string]int)
+	for childIndex := levelIndex + 1; childIndex < len(hierarchyLevels); childIndex++ {
+		preview.Descendants[hierarchyLevels[childIndex].Slug] = len(nodeIDs[childIndex])
+	}
+
+	formatIDs := nodeIDs[len(hierarchyLevels)-1]
+	if preview.Questions, err = countQuestionsOfFormatNodes(tx, formatIDs); err != nil {
+		return preview, nil, err
+	}
+
+	if len(formatIDs) > 0 {
+		if err := tx.Model(&student_psql.StudentPracticeSessionQuestionTable{}).
+			Where("question_format_id IN ?", formatIDs).
+			Count(&preview.ServedInPracticeSessions).Error; err != nil {
+			return preview, nil, fmt.Errorf("failed to count served questions: %w", err)
+		}
+	}
+
+	return preview, nodeIDs, nil
+}
+
+// respondHierarchyError maps the errors of the hierarchy admin handlers to their status codes
+func respondHierarchyError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Node not found"})
+	case errors.Is(err, errHierarchyParentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Parent node not found"})
+	case errors.Is(err, errHierarchyNameTaken), errors.Is(err, errFormatNodeHasQuestions):
+		c.JSON(http.StatusConflict, gin.H{"error": message, "details": err.Error()})
+	case errors.Is(err, errDomainHasNoParent):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message, "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
+
+// CreateHierarchyNodeHandler creates a node of the level under the given parent
+func CreateHierarchyNodeHandler(c *gin.Context) {
+	levelIndex, ok := hierarchyLevelFromParam(c)
+	if !ok {
+		return
+	}
+	level := hierarchyLevels[levelIndex]
+
+	var request requests.CreateHierarchyNodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	name, err := level.normalizeName(request.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid name", "details": err.Error()})
+		return
+	}
+	if levelIndex > 0 && request.ParentID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required field: parentID"})
+		return
+	}
+
+	node := response.HierarchyNodeResponse{Level: level.Slug, Name: name, ParentID: request.ParentID}
+	err = config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		if levelIndex > 0 {
+			if err := checkHierarchyParent(tx, levelIndex, request.ParentID); err != nil {
+				return err
+			}
+		}
+		if err := checkHierarchySiblings(tx, levelIndex, request.ParentID, name, 0); err != nil {
+			return err
+		}
+
+		// Insert the node
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (?) RETURNING %s", level.Table, level.NameColumn, level.IDColumn)
+		args := []interface{}{name}
+		if levelIndex > 0 {
+			query = fmt.Sprintf("INSERT INTO %s (%s, %s) VALUES (?, ?) RETURNING %s", level.Table, level.NameColumn, level.ParentColumn, level.IDColumn)
+			args = append(args, request.ParentID)
+		}
+		if err := tx.Raw(query, args...).Scan(&node.ID).Error; err != nil {
+			return fmt.Errorf("failed to create %s node: %w", level.Slug, err)
+		}
+		return nil
+	})
+	if err != nil {
+		respondHierarchyError(c, "Failed to create node", err)
+		return
+	}
+	cache.InvalidateQuestionHierarchy()
+
+	log.Printf("Created %s node %d (%s)", level.Slug, node.ID, node.Name)
+	c.JSON(http.StatusCreated, gin.H{"node": node})
+}
+
+// RenameHierarchyNodeHandler renames a node. For difficulty levels and formats the name is the value they stand for.
+func RenameHierarchyNodeHandler(c *gin.Context) {
+	levelIndex, ok := hierarchyLevelFromParam(c)
+	if !ok {
+		return
+	}
+	id, ok := hierarchyNodeIDFromParam(c)
+	if !ok {
+		return
+	}
+	level := hierarchyLevels[levelIndex]
+
+	var request requests.RenameHierarchyNodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	name, err := level.normalizeName(request.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid name", "details": err.Error()})
+		return
+	}
+
+	var node response.HierarchyNodeResponse
+	err = config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		var err error
+		if node, err = fetchHierarchyNode(tx, levelIndex, id); err != nil {
+			return err
+		}
+		if err := checkHierarchySiblings(tx, levelIndex, node.ParentID, name, id); err != nil {
+			return err
+		}
+
+		// The questions of a format node are stored in the table of its format
+		if levelIndex == len(hierarchyLevels)-1 && name != node.Name {
+			counts, err := countQuestionsOfFormatNodes(tx, []uint32{id})
+			if err != nil {
+				return err
+			}
+			if counts[node.Name] > 0 {
+				return errFormatNodeHasQuestions
+			}
+		}
+
+		if err := tx.Table(level.Table).Where(level.IDColumn+" = ?", id).
+			Update(level.NameColumn, name).Error; err != nil {
+			return fmt.Errorf("failed to rename %s node: %w", level.Slug, err)
+		}
+		node.Name = name
+		return nil
+	})
+	if err != nil {
+		respondHierarchyError(c, "Failed to rename node", err)
+		return
+	}
+	cache.InvalidateQuestionHierarchy()
+
+	c.JSON(http.StatusOK, gin.H{"node": node})
+}
+
+// MoveHierarchyNodeHandler re-parents a node under another node of the level above.
+// The descendants and the questions stay attached to the node, so they move with it.
+func MoveHierarchyNodeHandler(c *gin.Context) {
+	levelIndex, ok := hierarchyLevelFromParam(c)
+	if !ok {
+		return
+	}
+	id, ok := hierarchyNodeIDFromParam(c)
+	if !ok {
+		return
+	}
+	level := hierarchyLevels[levelIndex]
+
+	var request requests.MoveHierarchyNodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	var node response.HierarchyNodeResponse
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		if levelIndex == 0 {
+			return errDomainHasNoParent
+		}
+
+		var err error
+		if node, err = fetchHierarchyNode(tx, levelIndex, id); err != nil {
+			return err
+		}
+		if err := checkHierarchyParent(tx, levelIndex, request.ParentID); err != nil {
+			return err
+		}
+		if err := checkHierarchySiblings(tx, levelIndex, request.ParentID, node.Name, id); err != nil {
+			return err
+		}
+
+		if err := tx.Table(level.Table).Where(level.IDColumn+" = ?", id).
+			Update(level.ParentColumn, request.ParentID).Error; err != nil {
+			return fmt.Errorf("failed to move %s node: %w", level.Slug, err)
+		}
+		node.ParentID = request.ParentID
+		return nil
+	})
+	if err != nil {
+		respondHierarchyError(c, "Failed to move node", err)
+		return
+	}
+	cache.InvalidateQuestionHierarchy()
+
+	c.JSON(http.StatusOK, gin.H{"node": node})
+}
+
+// PreviewDeleteHierarchyNodeHandler lists the nodes and questions that deleting the node would delete
+func PreviewDeleteHierarchyNodeHandler(c *gin.Context) {
+	levelIndex, ok := hierarchyLevelFromParam(c)
+	if !ok {
+		return
+	}
+	id, ok := hierarchyNodeIDFromParam(c)
+	if !ok {
+		return
+	}
+
+	var preview response.HierarchyDeletePreviewResponse
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		var err error
+		preview, _, err = buildHierarchyDeletePreview(tx, levelIndex, id)
+		return err
+	})
+	if err != nil {
+		respondHierarchyError(c, "Failed to preview the deletion", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preview": preview})
+}
+
+// DeleteHierarchyNodeHandler deletes a node with all its descendants and their questions.
+// The practice session history keeps the served questions, the delete preview counts them.
+func DeleteHierarchyNodeHandler(c *gin.Context) {
+	levelIndex, ok := hierarchyLevelFromParam(c)
+	if !ok {
+		return
+	}
+	id, ok := hierarchyNodeIDFromParam(c)
+	if !ok {
+		return
+	}
+
+	var deleted response.HierarchyDeletePreviewResponse
+	var attachmentKeys []string
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		preview, nodeIDs, err := buildHierarchyDeletePreview(tx, levelIndex, id)
+		if err != nil {
+			return err
+		}
+
+		// Delete the questions of the format nodes along with their tag links, passage items, attachments and statistics
+		if formatIDs := nodeIDs[len(hierarchyLevels)-1]; len(formatIDs) > 0 {
+			if err := deleteQuestionTagLinks(tx, formatIDs); err != nil {
+				return err
+			}
+			if err := deleteQuestionPassageItems(tx, formatIDs); err != nil {
+				return err
+			}
+			if attachmentKeys, err = deleteQuestionAttachments(tx, formatIDs); err != nil {
+				return err
+			}
+			if err := deleteQuestionStatistics(tx, formatIDs); err != nil {
+				return err
+			}
+			for _, format := range question_type.QuestionFormats {
+				questionTable, err := question_type.QuestionTableForFormat(format)
+				if err != nil {
+					return err
+				}
+				if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE question_format_id IN ?", questionTable), formatIDs).Error; err != nil {
+					return fmt.Errorf("failed to delete %s questions: %w", format, err)
+				}
+			}
+		}
+
+		// Delete the nodes from the bottom up
+		for index := len(hierarchyLevels) - 1; index >= levelIndex; index-- {
+			if len(nodeIDs[index]) == 0 {
+				continue
+			}
+			level := hierarchyLevels[index]
+			if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s IN ?", level.Table, level.IDColumn), nodeIDs[index]).Error; err != nil {
+				return fmt.Errorf("failed to delete %s: %w", level.Slug, err)
+			}
+		}
+
+		deleted = preview
+		return nil
+	})
+	if err != nil {
+		respondHierarchyError(c, "Failed to delete node", err)
+		return
+	}
+	cache.InvalidateQuestionHierarchy()
+	deleteAttachmentFiles(c.Request.Context(), attachmentKeys)
+
+	log.Printf("Deleted %s node %d (%s) with %v descendants", deleted.Node.Level, deleted.Node.ID, deleted.Node.Name, deleted.Descendants)
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
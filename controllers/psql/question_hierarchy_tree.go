@@ -0,0 +1,214 @@
+package controllersNew
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"server/config"
+	question_type "server/models/question_bank/question_type"
+	"server/models/response"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// hierarchyTreeOptions shape the tree returned by GetQuestionHierarchyTree
+type hierarchyTreeOptions struct {
+	RootLevel int    // Level index of the subtree root, -1 for the whole hierarchy
+	RootID    uint32 // Subtree root, unused for the whole hierarchy
+	Depth     int    // Levels included below the top of the tree, 0 for all of them
+	NonEmpty  bool   // Leave out the nodes without questions
+}
+
+// countQuestionsPerFormatNode counts the published questions of every format node that has any,
+// the drafts and the questions in review or retired can't be practiced
+func countQuestionsPerFormatNode(tx *gorm.DB, formatIDs []uint32) (map[uint32]int64, error) {
+	counts := make(map[uint32]int64)
+	if len(formatIDs) == 0 {
+		return counts, nil
+	}
+
+	selects := make([]string, 0, len(question_type.QuestionFormats))
+	args := make([]interface{}, 0, len(question_type.QuestionFormats))
+	for _, format := range question_type.QuestionFormats {
+		questionTable, err := question_type.QuestionTableForFormat(format)
+		if err != nil {
+			return nil, err
+		}
+		selects = append(selects, fmt.Sprintf(
+			"SELECT question_format_id, COUNT(*) AS question_count FROM %s WHERE question_format_id IN ? AND status = ? GROUP BY question_format_id",
+			questionTable,
+		))
+		args = append(args, formatIDs, question_type.QuestionStatusPublished)
+	}
+
+	var rows []struct {
+		QuestionFormatID uint32
+		QuestionCount    int64
+	}
+	if err := tx.Raw(strings.Join(selects, " UNION ALL "), args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count questions: %w", err)
+	}
+	for _, row := range rows {
+		counts[row.QuestionFormatID] += row.QuestionCount
+	}
+	return counts, nil
+}
+
+// buildHierarchyTree loads the nodes from the top of the tree down to the formats, counts the questions
+// beneath every node and then trims the tree to the requested depth
+func buildHierarchyTree(tx *gorm.DB, options hierarchyTreeOptions) ([]*response.HierarchyTreeNodeResponse, error) {
+	topLevel := 0
+	if options.RootLevel >= 0 {
+		topLevel = options.RootLevel
+	}
+
+	// Load the nodes level by level, each level filtered by the parents loaded before it
+	nodesByLevel := make([][]response.HierarchyNodeResponse, len(hierarchyLevels))
+	for index := topLevel; index < len(hierarchyLevels); index++ {
+		level := hierarchyLevels[index]
+		query := tx.Table(level.Table).Select(level.nodeColumns()).Order(level.NameColumn)
+
+		switch {
+		case index == topLevel && options.RootLevel >= 0:
+			query = query.Where(level.IDColumn+" = ?", options.RootID)
+		case index > topLevel:
+			parentIDs := make([]uint32, 0, len(nodesByLevel[index-1]))
+			for _, parent := range nodesByLevel[index-1] {
+				parentIDs = append(parentIDs, parent.ID)
+			}
+			if len(parentIDs) == 0 {
+				continue
+			}
+			query = query.Where(level.ParentColumn+" IN ?", parentIDs)
+		}
+
+		if err := query.Scan(&nodesByLevel[index]).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", level.Slug, err)
+		}
+		if index == topLevel && options.RootLevel >= 0 && len(nodesByLevel[index]) == 0 {
+			return nil, gorm.ErrRecordNotFound
+		}
+	}
+
+	formatLevel := len(hierarchyLevels) - 1
+	formatIDs := make([]uint32, 0, len(nodesByLevel[formatLevel]))
+	for _, format := range nodesByLevel[formatLevel] {
+		formatIDs = append(formatIDs, format.ID)
+	}
+	questionCounts, err := countQuestionsPerFormatNode(tx, formatIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Link the nodes from the bottom up, every node adds its count to its parent
+	var children map[uint32][]*response.HierarchyTreeNodeResponse
+	for index := formatLevel; index >= topLevel; index-- {
+		parents := make(map[uint32][]*response.HierarchyTreeNodeResponse)
+		for _, node := range nodesByLevel[index] {
+			treeNode := &response.HierarchyTreeNodeResponse{
+				Level:    hierarchyLevels[index].Slug,
+				ID:       node.ID,
+				Name:     node.Name,
+				Children: children[node.ID],
+			}
+			if index == formatLevel {
+				treeNode.QuestionCount = questionCounts[node.ID]
+			}
+			for _, child := range treeNode.Children {
+				treeNode.QuestionCount += child.QuestionCount
+			}
+
+			if options.NonEmpty && treeNode.QuestionCount == 0 {
+				continue
+			}
+			parents[node.ParentID] = append(parents[node.ParentID], treeNode)
+		}
+		children = parents
+	}
+
+	var roots []*response.HierarchyTreeNodeResponse
+	for _, nodes := range children {
+		roots = append(roots, nodes...)
+	}
+	slices.SortFunc(roots, func(a, b *response.HierarchyTreeNodeResponse) int { return strings.Compare(a.Name, b.Name) })
+
+	if options.Depth > 0 {
+		trimHierarchyTree(roots, options.Depth)
+	}
+	return roots, nil
+}
+
+// trimHierarchyTree drops the children below the depth, the counts still include them
+func trimHierarchyTree(nodes []*response.HierarchyTreeNodeResponse, depth int) {
+	for _, node := range nodes {
+		if depth <= 1 {
+			node.Children = nil
+			continue
+		}
+		trimHierarchyTree(node.Children, depth-1)
+	}
+}
+
+// GetQuestionHierarchyTree returns the hierarchy as a nested tree (domains → sub-domains → niches → difficulties → formats)
+// where every node carries the number of published questions beneath it.
+// ?level=<level>&id=<nodeID> returns the subtree of a node, ?depth=<n> limits the levels returned and
+// ?nonEmpty=true leaves out the branches without questions.
+func GetQuestionHierarchyTree(c *gin.Context) {
+	options := hierarchyTreeOptions{RootLevel: -1}
+
+	if levelSlug := c.Query("level"); levelSlug != "" {
+		options.RootLevel = slices.IndexFunc(hierarchyLevels, func(level hierarchyLevel) bool { return level.Slug == levelSlug })
+		if options.RootLevel < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown hierarchy level", "details": levelSlug})
+			return
+		}
+		id, err := strconv.ParseUint(c.Query("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid node ID", "details": err.Error()})
+			return
+		}
+		options.RootID = uint32(id)
+	}
+
+	if depth := c.Query("depth"); depth != "" {
+		value, err := strconv.Atoi(depth)
+		if err != nil || value < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid depth, expected a positive number"})
+			return
+		}
+		options.Depth = value
+	}
+
+	if nonEmpty := c.Query("nonEmpty"); nonEmpty != "" {
+		value, err := strconv.ParseBool(nonEmpty)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid nonEmpty value", "details": err.Error()})
+			return
+		}
+		options.NonEmpty = value
+	}
+
+	var tree []*response.HierarchyTreeNodeResponse
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		var err error
+		tree, err = buildHierarchyTree(tx, options)
+		return err
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Node not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch the hierarchy tree", "details": err.Error()})
+		return
+	}
+
+	if tree == nil {
+		tree = []*response.HierarchyTreeNodeResponse{}
+	}
+	c.JSON(http.StatusOK, gin.H{"tree": tree})
+}
@@ -0,0 +1,247 @@
+package controllersNew
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"server/config"
+	"server/middlewares"
+	question_type "server/models/question_bank/question_type"
+	requests "server/models/requests"
+	questionBankIO "server/question_bank_io"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportQuestionSpreadsheet imports the questions of an uploaded CSV or XLSX file.
+// The column mapping tells which columns hold the question fields and its place in the hierarchy,
+// every row is validated and the report lists the errors of the skipped rows.
+// Missing hierarchy nodes are created for admins only, the rows on unknown paths are skipped for the volunteers.
+// The questions are inserted as DRAFT, admins restoring an export keep the status of the rows with ?keepStatus=true.
+// With ?dryRun=true the rows are validated without writing to the database.
+func ImportQuestionSpreadsheet(c *gin.Context) {
+	var request requests.ImportQuestionSpreadsheetRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dryRun value", "details": err.Error()})
+		return
+	}
+	duplicates, err := questionBankIO.ValidateDuplicatePolicy(c.Query("duplicates"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duplicates value", "details": err.Error()})
+		return
+	}
+	keepStatus, err := strconv.ParseBool(c.DefaultQuery("keepStatus", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid keepStatus value", "details": err.Error()})
+		return
+	}
+	if keepStatus && !middlewares.RequestHasPrivilege(c, "admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient privileges", "details": "only admins can restore the status of exported questions"})
+		return
+	}
+
+	// Files without a mapping are expected to be in the layout of the CSV exports
+	mapping := questionBankIO.ExportColumnMapping
+	if request.Mapping != "" {
+		mapping = questionBankIO.ColumnMapping{}
+		if err := json.Unmarshal([]byte(request.Mapping), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid column mapping", "details": err.Error()})
+			return
+		}
+	}
+
+	// Read the rows of the uploaded file
+	file, err := request.File.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to open the uploaded file", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	rows, err := questionBankIO.ReadSpreadsheet(request.File.Filename, file, mapping.Sheet)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the spreadsheet", "details": err.Error()})
+		return
+	}
+
+	records, rowErrors, err := questionBankIO.ParseSpreadsheet(rows, mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid spreadsheet", "details": err.Error()})
+		return
+	}
+	if len(records) == 0 && len(rowErrors) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No questions found in the spreadsheet"})
+		return
+	}
+
+	// The inserted questions get their first revision under the name of the importer
+	ctx := question_type.WithRevisionAuthor(c.Request.Context(), middlewares.RequestUsername(c))
+	report, err := questionBankIO.ImportRecords(
+		config.GetPostgresDBConnection().WithContext(ctx),
+		records,
+		rowErrors,
+		questionBankIO.ImportOptions{
+			DryRun:      dryRun,
+			Duplicates:  duplicates,
+			CreateNodes: middlewares.RequestHasPrivilege(c, "admin"),
+			KeepStatus:  keepStatus,
+		},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import questions", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// ImportQuestionArchive imports a zip that bundles a question file (markdown, JSON Lines, CSV or XLSX) with
+// the images its questions and MCQ options refer to. The images are stored along with the uploaded documents,
+// the mapping tells the columns of a CSV/XLSX file as for the spreadsheet imports.
+// Hierarchy nodes and statuses are handled as for the spreadsheet imports (?keepStatus=true, admins only).
+// With ?dryRun=true the entries and their images are validated without writing or uploading anything.
+func ImportQuestionArchive(c *gin.Context) {
+	var request requests.ImportQuestionArchiveRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dryRun value", "details": err.Error()})
+		return
+	}
+	duplicates, err := questionBankIO.ValidateDuplicatePolicy(c.Query("duplicates"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duplicates value", "details": err.Error()})
+		return
+	}
+	keepStatus, err := strconv.ParseBool(c.DefaultQuery("keepStatus", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid keepStatus value", "details": err.Error()})
+		return
+	}
+	if keepStatus && !middlewares.RequestHasPrivilege(c, "admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient privileges", "details": "only admins can restore the status of exported questions"})
+		return
+	}
+
+	mapping := questionBankIO.ExportColumnMapping
+	if request.Mapping != "" {
+		mapping = questionBankIO.ColumnMapping{}
+		if err := json.Unmarshal([]byte(request.Mapping), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid column mapping", "details": err.Error()})
+			return
+		}
+	}
+
+	file, err := request.File.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to open the uploaded file", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	archive, err := questionBankIO.OpenQuestionArchive(file, request.File.Size)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid zip", "details": err.Error()})
+		return
+	}
+	records, entryErrors, err := archive.Records(mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read " + archive.QuestionFile, "details": err.Error()})
+		return
+	}
+	if len(records) == 0 && len(entryErrors) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No questions found in " + archive.QuestionFile})
+		return
+	}
+
+	// The inserted questions get their first revision under the name of the importer
+	username := middlewares.RequestUsername(c)
+	ctx := question_type.WithRevisionAuthor(c.Request.Context(), username)
+	report, err := questionBankIO.ImportRecords(
+		config.GetPostgresDBConnection().WithContext(ctx),
+		records,
+		entryErrors,
+		questionBankIO.ImportOptions{
+			DryRun:      dryRun,
+			Duplicates:  duplicates,
+			CreateNodes: middlewares.RequestHasPrivilege(c, "admin"),
+			KeepStatus:  keepStatus,
+			Archive:     archive,
+			StoreFile:   config.UploadToCloud,
+			DeleteFile:  config.DeleteFromCloud,
+			UploadedBy:  username,
+		},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import questions", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// ExportQuestionsHandler streams the questions under a hierarchy node as a file that can be imported back.
+// ?format=jsonl|zip|csv|markdown (default jsonl), ?level=domain|subDomain|niche|difficulty|format&id=<nodeID>
+// selects the node, everything is exported without a level. The zip bundles the images of the questions and is
+// read back by the zip import, the scopes holding images or passages the format can't carry are refused.
+func ExportQuestionsHandler(c *gin.Context) {
+	format := c.DefaultQuery("format", questionBankIO.ExportFormatJSONL)
+	if _, exists := questionBankIO.ExportContentType[format]; !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, expected jsonl, zip, csv or markdown"})
+		return
+	}
+
+	scope := questionBankIO.ExportScope{Level: c.Query("level")}
+	if scope.Level != "" {
+		id, err := strconv.ParseUint(c.Query("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid node ID", "details": err.Error()})
+			return
+		}
+		scope.ID = uint32(id)
+	}
+	if err := scope.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export scope", "details": err.Error()})
+		return
+	}
+
+	db := config.GetPostgresDBConnection().WithContext(c.Request.Context())
+	if err := questionBankIO.CheckExportScope(db, scope, format); err != nil {
+		if errors.Is(err, questionBankIO.ErrLossyExport) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "The format can't carry every question of the scope", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check the export", "details": err.Error()})
+		return
+	}
+
+	fileName := "questions"
+	if scope.Level != "" {
+		fileName = fmt.Sprintf("questions_%s_%d", scope.Level, scope.ID)
+	}
+	c.Header("Content-Type", questionBankIO.ExportContentType[format])
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s%s"`, fileName, questionBankIO.ExportFileExtension[format]))
+	c.Status(http.StatusOK)
+
+	// The status is sent with the first rows, a failure halfway through can only cut the file short
+	exported, err := questionBankIO.ExportQuestions(db, scope, format, config.DownloadFromCloud, c.Writer)
+	if err != nil {
+		log.Printf("Question export failed after %d questions: %v", exported, err)
+		c.Abort()
+		return
+	}
+	log.Printf("Exported %d questions (%s)", exported, format)
+}
@@ -0,0 +1,321 @@
+package controllersNew
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"server/config"
+	question_type "server/models/question_bank/question_type"
+	requests "server/models/requests"
+	"server/models/response"
+	"server/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errPassageNotFound      = errors.New("passage not found")
+	errPassageQuestionTaken = errors.New("question already belongs to another passage")
+)
+
+// passageIDFromParam parses the :passageID route parameter
+func passageIDFromParam(c *gin.Context) (uint32, bool) {
+	id, err := strconv.ParseUint(c.Param("passageID"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passage ID", "details": err.Error()})
+		return 0, false
+	}
+	return uint32(id), true
+}
+
+// fetchQuestionPassage returns a passage with its group of questions in serve order
+func fetchQuestionPassage(tx *gorm.DB, passageID uint32) (response.QuestionPassageResponse, error) {
+	var passage question_type.QuestionPassage
+	if err := tx.Where("passage_id = ?", passageID).Take(&passage).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.QuestionPassageResponse{}, errPassageNotFound
+		}
+		return response.QuestionPassageResponse{}, fmt.Errorf("failed to fetch passage: %w", err)
+	}
+
+	var items []question_type.QuestionPassageItem
+	if err := tx.Where("passage_id = ?", passageID).Order("position").Find(&items).Error; err != nil {
+		return response.QuestionPassageResponse{}, fmt.Errorf("failed to fetch passage questions: %w", err)
+	}
+
+	questions := make([]response.PassageQuestionResponse, 0, len(items))
+	for _, item := range items {
+		format, question, err := fetchStoredQuestion(tx, item.QuestionFormatID, item.QuestionID)
+		if err != nil {
+			return response.QuestionPassageResponse{}, fmt.Errorf("failed to fetch passage question %d/%d: %w", item.QuestionFormatID, item.QuestionID, err)
+		}
+		questions = append(questions, response.PassageQuestionResponse{
+			Position:         item.Position,
+			QuestionFormatID: item.QuestionFormatID,
+			QuestionID:       item.QuestionID,
+			Format:           format,
+			QuestionText:     question.RevisionSnapshot().QuestionText,
+			Status:           question.QuestionStatus(),
+		})
+	}
+
+	return response.QuestionPassageResponse{
+		PassageID: passage.PassageID,
+		Title:     passage.Title,
+		Body:      passage.Body,
+		CreatedAt: passage.CreatedAt,
+		UpdatedAt: passage.UpdatedAt,
+		Questions: questions,
+	}, nil
+}
+
+// setPassageQuestions replaces the group of questions of a passage, the questions keep the given order
+func setPassageQuestions(tx *gorm.DB, passageID uint32, questionKeys []requests.PassageQuestionKey) error {
+	var problems []string
+	seen := make(map[servedQuestionKey]bool, len(questionKeys))
+	for _, questionKey := range questionKeys {
+		key := servedQuestionKey{questionKey.QuestionFormatID, questionKey.QuestionID}
+		if seen[key] {
+			problems = append(problems, fmt.Sprintf("question %d/%d is listed twice", key.QuestionFormatID, key.QuestionID))
+			continue
+		}
+		seen[key] = true
+
+		if _, _, err := fetchStoredQuestion(tx, key.QuestionFormatID, key.QuestionID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				problems = append(problems, fmt.Sprintf("question %d/%d doesn't exist", key.QuestionFormatID, key.QuestionID))
+				continue
+			}
+			return err
+		}
+
+		var item question_type.QuestionPassageItem
+		err := tx.Where("question_format_id = ? AND question_id = ? AND passage_id <> ?", key.QuestionFormatID, key.QuestionID, passageID).
+			Take(&item).Error
+		if err == nil {
+			return fmt.Errorf("%w: question %d/%d is in passage %d", errPassageQuestionTaken, key.QuestionFormatID, key.QuestionID, item.PassageID)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to check the passage of question %d/%d: %w", key.QuestionFormatID, key.QuestionID, err)
+		}
+	}
+	if len(problems) > 0 {
+		return questionValidationError{problems: problems}
+	}
+
+	if err := tx.Where("passage_id = ?", passageID).Delete(&question_type.QuestionPassageItem{}).Error; err != nil {
+		return fmt.Errorf("failed to remove passage questions: %w", err)
+	}
+	if len(questionKeys) == 0 {
+		return nil
+	}
+
+	items := make([]question_type.QuestionPassageItem, 0, len(questionKeys))
+	for i, questionKey := range questionKeys {
+		items = append(items, question_type.QuestionPassageItem{
+			QuestionFormatID: questionKey.QuestionFormatID,
+			QuestionID:       questionKey.QuestionID,
+			PassageID:        passageID,
+			Position:         i + 1,
+		})
+	}
+	if err := tx.Omit(clause.Associations).Create(&items).Error; err != nil {
+		return fmt.Errorf("failed to add passage questions: %w", err)
+	}
+	return nil
+}
+
+// deleteQuestionPassageItems removes the questions of the format nodes from their passages, the question tables
+// can't be referenced by the passage items so their questions are deleted along with them
+func deleteQuestionPassageItems(tx *gorm.DB, formatIDs []uint32) error {
+	if err := tx.Where("question_format_id IN ?", formatIDs).Delete(&question_type.QuestionPassageItem{}).Error; err != nil {
+		return fmt.Errorf("failed to delete question passage items: %w", err)
+	}
+	return nil
+}
+
+// respondQuestionPassageError maps the errors of the question passage handlers to their status codes
+func respondQuestionPassageError(c *gin.Context, message string, err error) {
+	var validationErr questionValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": validationErr.problems})
+	case errors.Is(err, errPassageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Passage not found"})
+	case errors.Is(err, errPassageQuestionTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": message, "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
+
+// defaultPassagesLimit and maxPassagesLimit bound the page size of the passage list
+const (
+	defaultPassagesLimit = 50
+	maxPassagesLimit     = 200
+)
+
+// ListQuestionPassagesHandler returns the passages with the number of questions of each, latest updated first.
+// ?search= keeps the passages whose title contains the text, ?limit= and ?offset= page the list.
+func ListQuestionPassagesHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPassagesLimit)))
+	if err != nil || limit < 1 || limit > maxPassagesLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid limit, expected 1 to %d", maxPassagesLimit)})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return
+	}
+
+	query := config.GetPostgresDBConnection().
+		Table(question_type.QuestionPassage{}.TableName() + " p").
+		Select("p.passage_id, p.title, COUNT(pi.question_id) AS question_count, p.updated_at").
+		Joins(fmt.Sprintf("LEFT JOIN %s pi ON pi.passage_id = p.passage_id", question_type.QuestionPassageItem{}.TableName())).
+		Group("p.passage_id, p.title, p.updated_at").
+		Order("p.updated_at DESC, p.passage_id DESC").
+		Limit(limit).
+		Offset(offset)
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		query = query.Where("p.title ILIKE ?", "%"+search+"%")
+	}
+
+	passages := []response.QuestionPassageSummary{}
+	if err := query.Scan(&passages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch passages", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"passages": passages, "limit": limit, "offset": offset})
+}
+
+// GetQuestionPassageHandler returns a passage with its group of questions
+func GetQuestionPassageHandler(c *gin.Context) {
+	passageID, ok := passageIDFromParam(c)
+	if !ok {
+		return
+	}
+
+	var passage response.QuestionPassageResponse
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		var err error
+		passage, err = fetchQuestionPassage(tx, passageID)
+		return err
+	})
+	if err != nil {
+		respondQuestionPassageError(c, "Failed to fetch passage", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"passage": passage})
+}
+
+// CreateQuestionPassageHandler creates a passage along with its group of questions
+func CreateQuestionPassageHandler(c *gin.Context) {
+	var request requests.QuestionPassageRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	if err := utils.ValidateRichText(request.Body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": []string{"Body: " + err.Error()}})
+		return
+	}
+
+	var passage response.QuestionPassageResponse
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		stored := question_type.QuestionPassage{
+			Title: strings.TrimSpace(request.Title),
+			Body:  strings.TrimSpace(request.Body),
+		}
+		if err := tx.Create(&stored).Error; err != nil {
+			return fmt.Errorf("failed to create passage: %w", err)
+		}
+		if err := setPassageQuestions(tx, stored.PassageID, request.Questions); err != nil {
+			return err
+		}
+
+		var err error
+		passage, err = fetchQuestionPassage(tx, stored.PassageID)
+		return err
+	})
+	if err != nil {
+		respondQuestionPassageError(c, "Failed to create passage", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"passage": passage})
+}
+
+// UpdateQuestionPassageHandler replaces the text of a passage and its group of questions.
+// Sessions already served keep the group they were served with.
+func UpdateQuestionPassageHandler(c *gin.Context) {
+	passageID, ok := passageIDFromParam(c)
+	if !ok {
+		return
+	}
+	var request requests.QuestionPassageRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	if err := utils.ValidateRichText(request.Body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": []string{"Body: " + err.Error()}})
+		return
+	}
+
+	var passage response.QuestionPassageResponse
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&question_type.QuestionPassage{}).
+			Where("passage_id = ?", passageID).
+			Updates(map[string]interface{}{
+				"title": strings.TrimSpace(request.Title),
+				"body":  strings.TrimSpace(request.Body),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update passage: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errPassageNotFound
+		}
+		if err := setPassageQuestions(tx, passageID, request.Questions); err != nil {
+			return err
+		}
+
+		var err error
+		passage, err = fetchQuestionPassage(tx, passageID)
+		return err
+	})
+	if err != nil {
+		respondQuestionPassageError(c, "Failed to update passage", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"passage": passage})
+}
+
+// DeleteQuestionPassageHandler deletes a passage, its questions stay in the question bank as standalone questions
+func DeleteQuestionPassageHandler(c *gin.Context) {
+	passageID, ok := passageIDFromParam(c)
+	if !ok {
+		return
+	}
+
+	// The passage items are deleted by the foreign key cascade
+	result := config.GetPostgresDBConnection().Where("passage_id = ?", passageID).Delete(&question_type.QuestionPassage{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete passage", "details": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Passage not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Passage deleted successfully"})
+}
@@ -0,0 +1,58 @@
+package controllersNew
+
+import (
+	"net/http"
+	"server/models/response"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// renderHTMLFromQuery reads ?render=, "html" adds the rich texts of the questions rendered to sanitized HTML
+// next to their source. ok is false when the value is invalid, the response is already written then.
+func renderHTMLFromQuery(c *gin.Context) (renderHTML bool, ok bool) {
+	switch c.Query("render") {
+	case "":
+		return false, true
+	case "html":
+		return true, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid render value, expected html"})
+		return false, false
+	}
+}
+
+// renderPracticeContent renders the rich texts of the questions and passages served in a practice session
+func renderPracticeContent(questions []response.PracticeQuestionResponse, passages []response.PracticePassageResponse) {
+	for i := range questions {
+		questions[i].QuestionHTML = utils.RenderRichText(questions[i].QuestionText)
+		questions[i].OptionsHTML = utils.RenderRichTexts(questions[i].Options)
+	}
+	for i := range passages {
+		passages[i].BodyHTML = utils.RenderRichText(passages[i].Body)
+	}
+}
+
+// renderReviewContent renders the rich texts of a practice session review
+func renderReviewContent(review *response.PracticeSessionReviewResponse) {
+	for i := range review.Questions {
+		question := &review.Questions[i]
+		question.QuestionHTML = utils.RenderRichText(question.QuestionText)
+		question.OptionsHTML = utils.RenderRichTexts(question.Options)
+		if question.Explanation != "" {
+			question.ExplanationHTML = utils.RenderRichText(question.Explanation)
+		}
+	}
+	for i := range review.Passages {
+		review.Passages[i].BodyHTML = utils.RenderRichText(review.Passages[i].Body)
+	}
+}
+
+// renderQuestionItem renders the rich texts of a question as seen by the editors
+func renderQuestionItem(item *response.QuestionItemResponse) {
+	item.QuestionHTML = utils.RenderRichText(item.QuestionText)
+	item.OptionsHTML = utils.RenderRichTexts(item.Options)
+	if item.Explanation != "" {
+		item.ExplanationHTML = utils.RenderRichText(item.Explanation)
+	}
+}
@@ -0,0 +1,239 @@
+package controllersNew
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"server/cache"
+	"server/config"
+	"server/middlewares"
+	question_hierarchy "server/models/question_bank/question_hierarchy"
+	question_type "server/models/question_bank/question_type"
+	requests "server/models/requests"
+	"server/models/response"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errInvalidStatusTransition = errors.New("the action doesn't apply to the current status of the question")
+
+var errQuestionNotEditable = errors.New("volunteers can only edit the DRAFT questions they wrote")
+
+// checkQuestionEditable tells whether the caller may change the content of a question: coordinators edit any question,
+// volunteers only their own drafts, including the ones a coordinator rejected. The author of a question is the author
+// of its first revision.
+func checkQuestionEditable(c *gin.Context, tx *gorm.DB, question question_type.RevisionedQuestion, formatID, questionID uint32) error {
+	if middlewares.RequestHasPrivilege(c, "coordinator") {
+		return nil
+	}
+	if status := question.QuestionStatus(); status != question_type.QuestionStatusDraft {
+		return fmt.Errorf("%w, the question is %s", errQuestionNotEditable, status)
+	}
+
+	var author string
+	if err := tx.Model(&question_type.QuestionRevision{}).
+		Where("question_format_id = ? AND question_id = ?", formatID, questionID).
+		Order("revision_number").
+		Limit(1).
+		Pluck("changed_by", &author).Error; err != nil {
+		return fmt.Errorf("failed to fetch the author of the question: %w", err)
+	}
+	if author == "" || author != middlewares.RequestUsername(c) {
+		return fmt.Errorf("%w, the question was written by someone else", errQuestionNotEditable)
+	}
+	return nil
+}
+
+// ChangeQuestionStatusHandler moves a question through the review workflow: volunteers submit their drafts
+// for review, coordinators approve (publish) or reject them and retire or reopen published questions.
+// Every step is logged along with its author and note.
+func ChangeQuestionStatusHandler(c *gin.Context) {
+	formatID, questionID, ok := questionKeyFromParams(c)
+	if !ok {
+		return
+	}
+
+	var request requests.ChangeQuestionStatusRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	request.Note = strings.TrimSpace(request.Note)
+	if request.Action == "reject" && request.Note == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A note is required to reject a question"})
+		return
+	}
+
+	action := question_type.QuestionStatusActions[request.Action]
+	if !middlewares.RequestHasPrivilege(c, action.RequiredRole) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient privileges", "details": fmt.Sprintf("%s requires the %s role", request.Action, action.RequiredRole)})
+		return
+	}
+
+	var item response.QuestionItemResponse
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		format, question, err := fetchStoredQuestion(tx, formatID, questionID)
+		if err != nil {
+			return err
+		}
+		fromStatus := question.QuestionStatus()
+		if !action.AppliesTo(fromStatus) {
+			return fmt.Errorf("%w: can't %s a %s question", errInvalidStatusTransition, request.Action, fromStatus)
+		}
+
+		questionTable, err := question_type.QuestionTableForFormat(format)
+		if err != nil {
+			return err
+		}
+		// The status isn't part of the content, the table is written directly so that no revision is recorded
+		if err := tx.Table(questionTable).
+			Where("question_format_id = ? AND question_id = ?", formatID, questionID).
+			Updates(map[string]interface{}{"status": action.To, "updated_at": time.Now()}).Error; err != nil {
+			return fmt.Errorf("failed to update question status: %w", err)
+		}
+
+		if err := tx.Create(&question_type.QuestionStatusChange{
+			QuestionFormatID: formatID,
+			QuestionID:       questionID,
+			FromStatus:       fromStatus,
+			ToStatus:         action.To,
+			ChangedBy:        middlewares.RequestUsername(c),
+			Note:             request.Note,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to log question status change: %w", err)
+		}
+
+		item, err = fetchQuestionItem(tx, formatID, questionID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errInvalidStatusTransition) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Invalid status change", "details": err.Error()})
+			return
+		}
+		respondQuestionRevisionError(c, "Failed to change question status", err)
+		return
+	}
+	// Publishing and retiring change the practice counts of the hierarchy
+	cache.InvalidateQuestionHierarchy()
+
+	c.JSON(http.StatusOK, gin.H{"question": item})
+}
+
+// sendEditedQuestionToReview moves a PUBLISHED question whose content was just edited back to IN_REVIEW and logs
+// the change, so that the edit isn't served before a coordinator approves it. Returns whether the question moved.
+func sendEditedQuestionToReview(tx *gorm.DB, format string, question question_type.RevisionedQuestion, formatID, questionID uint32, changedBy, note string) (bool, error) {
+	if question.QuestionStatus() != question_type.QuestionStatusPublished {
+		return false, nil
+	}
+
+	questionTable, err := question_type.QuestionTableForFormat(format)
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Table(questionTable).
+		Where("question_format_id = ? AND question_id = ?", formatID, questionID).
+		Updates(map[string]interface{}{"status": question_type.QuestionStatusInReview, "updated_at": time.Now()}).Error; err != nil {
+		return false, fmt.Errorf("failed to send question back to review: %w", err)
+	}
+
+	if err := tx.Create(&question_type.QuestionStatusChange{
+		QuestionFormatID: formatID,
+		QuestionID:       questionID,
+		FromStatus:       question_type.QuestionStatusPublished,
+		ToStatus:         question_type.QuestionStatusInReview,
+		ChangedBy:        changedBy,
+		Note:             note,
+	}).Error; err != nil {
+		return false, fmt.Errorf("failed to log question status change: %w", err)
+	}
+	return true, nil
+}
+
+// ListQuestionStatusChangesHandler returns the review workflow history of a question, latest first
+func ListQuestionStatusChangesHandler(c *gin.Context) {
+	formatID, questionID, ok := questionKeyFromParams(c)
+	if !ok {
+		return
+	}
+
+	var changes []question_type.QuestionStatusChange
+	if err := config.GetPostgresDBConnection().
+		Where("question_format_id = ? AND question_id = ?", formatID, questionID).
+		Order("created_at DESC, status_change_id DESC").
+		Find(&changes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch question status history", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusChanges": changes})
+}
+
+// defaultReviewQueueLimit and maxReviewQueueLimit bound the page size of the review queue
+const (
+	defaultReviewQueueLimit = 50
+	maxReviewQueueLimit     = 200
+)
+
+// GetReviewQueueHandler lists the questions of a workflow status, least recently updated first.
+// ?status= defaults to IN_REVIEW, ?formatID= narrows the queue to a format node, ?limit= and ?offset= page it.
+func GetReviewQueueHandler(c *gin.Context) {
+	status := strings.ToUpper(c.DefaultQuery("status", question_type.QuestionStatusInReview))
+	if !slices.Contains(question_type.QuestionStatuses, status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status", "details": fmt.Sprintf("expected one of %s", strings.Join(question_type.QuestionStatuses, ", "))})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultReviewQueueLimit)))
+	if err != nil || limit < 1 || limit > maxReviewQueueLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid limit, expected 1 to %d", maxReviewQueueLimit)})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return
+	}
+
+	where := "q.status = ?"
+	whereArgs := []interface{}{status}
+	if formatID := c.Query("formatID"); formatID != "" {
+		id, err := strconv.ParseUint(formatID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format ID", "details": err.Error()})
+			return
+		}
+		where += " AND q.question_format_id = ?"
+		whereArgs = append(whereArgs, uint32(id))
+	}
+
+	selects := make([]string, 0, len(question_type.QuestionFormats))
+	var args []interface{}
+	for _, format := range question_type.QuestionFormats {
+		questionTable, err := question_type.QuestionTableForFormat(format)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		selects = append(selects, fmt.Sprintf(
+			"SELECT q.question_format_id, q.question_id, f.format, q.question_text, q.status, q.updated_at FROM %s q JOIN %s f ON f.question_format_id = q.question_format_id WHERE %s",
+			questionTable, question_hierarchy.QuestionFormatTable{}.TableName(), where,
+		))
+		args = append(args, whereArgs...)
+	}
+	query := strings.Join(selects, " UNION ALL ") + " ORDER BY updated_at, question_format_id, question_id LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	queue := []response.QuestionQueueEntry{}
+	if err := config.GetPostgresDBConnection().Raw(query, args...).Scan(&queue).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch the review queue", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"questions": queue, "status": status, "limit": limit, "offset": offset})
+}
@@ -0,0 +1,519 @@
+package controllersNew
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"server/cache"
+	"server/config"
+	"server/middlewares"
+	question_type "server/models/question_bank/question_type"
+	requests "server/models/requests"
+	"server/models/response"
+	questionBankIO "server/question_bank_io"
+	"server/utils"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errRevisionNotFound = errors.New("question revision not found")
+
+// questionValidationError carries the problems found in the new content of a question
+type questionValidationError struct {
+	problems []string
+}
+
+func (e questionValidationError) Error() string {
+	return fmt.Sprintf("invalid question: %v", e.problems)
+}
+
+// questionKeyFromParams parses the :formatID and :questionID route parameters
+func questionKeyFromParams(c *gin.Context) (formatID, questionID uint32, ok bool) {
+	parsedFormatID, err := strconv.ParseUint(c.Param("formatID"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format ID", "details": err.Error()})
+		return 0, 0, false
+	}
+	parsedQuestionID, err := strconv.ParseUint(c.Param("questionID"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID", "details": err.Error()})
+		return 0, 0, false
+	}
+	return uint32(parsedFormatID), uint32(parsedQuestionID), true
+}
+
+// fetchStoredQuestion returns a question of any format along with its format, gorm.ErrRecordNotFound if it doesn't exist
+func fetchStoredQuestion(tx *gorm.DB, formatID, questionID uint32) (string, question_type.RevisionedQuestion, error) {
+	formats, err := fetchFormatsByID(tx, []uint32{formatID})
+	if err != nil {
+		return "", nil, err
+	}
+	format, exists := formats[formatID]
+	if !exists {
+		return "", nil, gorm.ErrRecordNotFound
+	}
+
+	question, err := question_type.NewQuestionModel(format)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := tx.Where("question_format_id = ? AND question_id = ?", formatID, questionID).Take(question).Error; err != nil {
+		return "", nil, err
+	}
+	return format, question, nil
+}
+
+// fetchQuestionRevision returns a revision of a question by its number, the latest one if the number is 0
+func fetchQuestionRevision(tx *gorm.DB, formatID, questionID uint32, revisionNumber int) (question_type.QuestionRevision, error) {
+	var revision question_type.QuestionRevision
+	query := tx.Where("question_format_id = ? AND question_id = ?", formatID, questionID)
+	if revisionNumber > 0 {
+		query = query.Where("revision_number = ?", revisionNumber)
+	}
+	if err := query.Order("revision_number DESC").Take(&revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return revision, errRevisionNotFound
+		}
+		return revision, fmt.Errorf("failed to fetch question revision: %w", err)
+	}
+	return revision, nil
+}
+
+// toQuestionItemResponse builds the editor view of a question
+func toQuestionItemResponse(format string, question question_type.RevisionedQuestion, revisionNumber int) response.QuestionItemResponse {
+	formatID, questionID := question.RevisionKey()
+	snapshot := question.RevisionSnapshot()
+	item := response.QuestionItemResponse{
+		QuestionFormatID: formatID,
+		QuestionID:       questionID,
+		Format:           format,
+		QuestionText:     snapshot.QuestionText,
+		Options:          snapshot.Options,
+		CorrectOptions:   snapshot.CorrectOptions,
+		MultiSelect:      snapshot.MultiSelect,
+		Answer:           snapshot.Answer,
+		Explanation:      snapshot.Explanation,
+		Status:           question.QuestionStatus(),
+		RevisionNumber:   revisionNumber,
+	}
+	if format == "NUM" {
+		item.Tolerance = utils.FormatTolerance(snapshot.Tolerance, snapshot.RelativeTolerance)
+		item.Unit = snapshot.Unit
+	}
+	if format == "CODE" {
+		item.Code = toCodeContent(snapshot)
+	}
+	item.Pairs, item.Sequence = toMatchPairs(snapshot.Pairs), snapshot.Sequence
+	return item
+}
+
+// toCodeContent returns the content of a CODE question besides its statement and reference solution
+func toCodeContent(snapshot question_type.QuestionSnapshot) *response.CodeContent {
+	testCases := make([]response.CodeTestCaseItem, 0, len(snapshot.TestCases))
+	for _, test := range snapshot.TestCases {
+		testCases = append(testCases, response.CodeTestCaseItem{Input: test.Input, ExpectedOutput: test.ExpectedOutput, IsSample: test.IsSample})
+	}
+	return &response.CodeContent{
+		Language:      snapshot.Language,
+		StarterCode:   snapshot.StarterCode,
+		TestCases:     testCases,
+		TimeLimitMs:   snapshot.TimeLimitMs,
+		MemoryLimitMB: snapshot.MemoryLimitMB,
+	}
+}
+
+// toMatchPairs returns the pairs of an MTC question, nil for the other formats
+func toMatchPairs(pairs question_type.MatchPairs) []response.MatchPair {
+	if len(pairs) == 0 {
+		return nil
+	}
+	matchPairs := make([]response.MatchPair, 0, len(pairs))
+	for _, pair := range pairs {
+		matchPairs = append(matchPairs, response.MatchPair{Left: pair.Left, Right: pair.Right})
+	}
+	return matchPairs
+}
+
+// fetchQuestionItem returns the editor view of a stored question
+func fetchQuestionItem(tx *gorm.DB, formatID, questionID uint32) (response.QuestionItemResponse, error) {
+	format, question, err := fetchStoredQuestion(tx, formatID, questionID)
+	if err != nil {
+		return response.QuestionItemResponse{}, err
+	}
+
+	revisionNumber := 0
+	if latest, err := fetchQuestionRevision(tx, formatID, questionID, 0); err == nil {
+		revisionNumber = latest.RevisionNumber
+	} else if !errors.Is(err, errRevisionNotFound) {
+		return response.QuestionItemResponse{}, err
+	}
+
+	return toQuestionItemResponse(format, question, revisionNumber), nil
+}
+
+func toQuestionRevisionResponse(revision question_type.QuestionRevision) response.QuestionRevisionResponse {
+	revisionResponse := response.QuestionRevisionResponse{
+		RevisionID:        revision.RevisionID,
+		RevisionNumber:    revision.RevisionNumber,
+		ChangeType:        revision.ChangeType,
+		ChangedBy:         revision.ChangedBy,
+		RestoredFrom:      revision.RestoredFrom,
+		CreatedAt:         revision.CreatedAt,
+		QuestionText:      revision.Snapshot.QuestionText,
+		Options:           revision.Snapshot.Options,
+		CorrectOptions:    revision.Snapshot.CorrectOptions,
+		MultiSelect:       revision.Snapshot.MultiSelect,
+		Tolerance:         revision.Snapshot.Tolerance,
+		RelativeTolerance: revision.Snapshot.RelativeTolerance,
+		Unit:              revision.Snapshot.Unit,
+		Pairs:             toMatchPairs(revision.Snapshot.Pairs),
+		Sequence:          revision.Snapshot.Sequence,
+		Answer:            revision.Snapshot.Answer,
+		Explanation:       revision.Snapshot.Explanation,
+	}
+	if revision.Snapshot.Language != "" { // Only CODE questions have a language
+		revisionResponse.Code = toCodeContent(revision.Snapshot)
+	}
+	return revisionResponse
+}
+
+// diffQuestionSnapshots lists the fields that differ between two snapshots
+func diffQuestionSnapshots(from, to question_type.QuestionSnapshot) []response.QuestionRevisionFieldChange {
+	changes := []response.QuestionRevisionFieldChange{}
+	if from.QuestionText != to.QuestionText {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "questionText", From: from.QuestionText, To: to.QuestionText})
+	}
+	if !slices.Equal(from.Options, to.Options) {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "options", From: from.Options, To: to.Options})
+	}
+	if !slices.Equal(from.CorrectOptions, to.CorrectOptions) {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "correctOptions", From: from.CorrectOptions, To: to.CorrectOptions})
+	}
+	if from.MultiSelect != to.MultiSelect {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "multiSelect", From: from.MultiSelect, To: to.MultiSelect})
+	}
+	if from.Tolerance != to.Tolerance {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "tolerance", From: from.Tolerance, To: to.Tolerance})
+	}
+	if from.RelativeTolerance != to.RelativeTolerance {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "relativeTolerance", From: from.RelativeTolerance, To: to.RelativeTolerance})
+	}
+	if from.Unit != to.Unit {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "unit", From: from.Unit, To: to.Unit})
+	}
+	if from.Language != to.Language {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "language", From: from.Language, To: to.Language})
+	}
+	if !maps.Equal(from.StarterCode, to.StarterCode) {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "starterCode", From: from.StarterCode, To: to.StarterCode})
+	}
+	if !slices.Equal(from.TestCases, to.TestCases) {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "testCases", From: from.TestCases, To: to.TestCases})
+	}
+	if from.TimeLimitMs != to.TimeLimitMs {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "timeLimitMs", From: from.TimeLimitMs, To: to.TimeLimitMs})
+	}
+	if from.MemoryLimitMB != to.MemoryLimitMB {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "memoryLimitMB", From: from.MemoryLimitMB, To: to.MemoryLimitMB})
+	}
+	if !slices.Equal(from.Pairs, to.Pairs) {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "pairs", From: from.Pairs, To: to.Pairs})
+	}
+	if !slices.Equal(from.Sequence, to.Sequence) {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "sequence", From: from.Sequence, To: to.Sequence})
+	}
+	if from.Answer != to.Answer {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "answer", From: from.Answer, To: to.Answer})
+	}
+	if from.Explanation != to.Explanation {
+		changes = append(changes, response.QuestionRevisionFieldChange{Field: "explanation", From: from.Explanation, To: to.Explanation})
+	}
+	return changes
+}
+
+// respondQuestionRevisionError maps the errors of the question revision handlers to their status codes
+func respondQuestionRevisionError(c *gin.Context, message string, err error) {
+	var validationErr questionValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": validationErr.problems})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+	case errors.Is(err, errRevisionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found", "details": err.Error()})
+	case errors.Is(err, errQuestionNotEditable):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient privileges", "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
+
+// GetQuestionItemHandler returns a question with its answer key, its latest revision number and its attachments
+func GetQuestionItemHandler(c *gin.Context) {
+	formatID, questionID, ok := questionKeyFromParams(c)
+	if !ok {
+		return
+	}
+	renderHTML, ok := renderHTMLFromQuery(c)
+	if !ok {
+		return
+	}
+
+	var item response.QuestionItemResponse
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		var err error
+		if item, err = fetchQuestionItem(tx, formatID, questionID); err != nil {
+			return err
+		}
+		item.Attachments, err = fetchQuestionAttachmentItems(c.Request.Context(), tx, formatID, questionID)
+		return err
+	})
+	if err != nil {
+		respondQuestionRevisionError(c, "Failed to fetch question", err)
+		return
+	}
+	if renderHTML {
+		renderQuestionItem(&item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"question": item})
+}
+
+// UpdateQuestionHandler replaces the content of a question, the previous content stays in its revision history.
+// Volunteers only edit their own drafts. A published question whose content changes goes back to review
+// and isn't served until it is approved again.
+func UpdateQuestionHandler(c *gin.Context) {
+	formatID, questionID, ok := questionKeyFromParams(c)
+	if !ok {
+		return
+	}
+
+	var request requests.UpdateQuestionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	username := middlewares.RequestUsername(c)
+	ctx := question_type.WithRevisionAuthor(c.Request.Context(), username)
+
+	var item response.QuestionItemResponse
+	sentToReview := false
+	err := config.GetPostgresDBConnection().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		format, question, err := fetchStoredQuestion(tx, formatID, questionID)
+		if err != nil {
+			return err
+		}
+		if err := checkQuestionEditable(c, tx, question, formatID, questionID); err != nil {
+			return err
+		}
+
+		// Check the new content against the rules of the format, the same ones applied on import
+		record := questionBankIO.QuestionRecord{
+			Format:        format,
+			QuestionText:  request.QuestionText,
+			Options:       request.Options,
+			MultiSelect:   request.MultiSelect,
+			Answer:        request.Answer,
+			Tolerance:     request.Tolerance,
+			Unit:          request.Unit,
+			Explanation:   request.Explanation,
+			Language:      request.Language,
+			StarterCode:   request.StarterCode,
+			TestCases:     toCodeTestCases(request.TestCases),
+			TimeLimitMs:   request.TimeLimitMs,
+			MemoryLimitMB: request.MemoryLimitMB,
+		}
+		record.Normalize()
+		if problems := record.ValidateContent(); len(problems) > 0 {
+			return questionValidationError{problems: problems}
+		}
+
+		// The snapshots before and after the save tell whether the content changed
+		previous := question.RevisionSnapshot()
+		question.ApplySnapshot(record.Snapshot())
+		if err := tx.Save(question).Error; err != nil {
+			return fmt.Errorf("failed to update question: %w", err)
+		}
+		if !previous.Equal(question.RevisionSnapshot()) {
+			if sentToReview, err = sendEditedQuestionToReview(tx, format, question, formatID, questionID, username, "content edited"); err != nil {
+				return err
+			}
+		}
+
+		item, err = fetchQuestionItem(tx, formatID, questionID)
+		return err
+	})
+	if err != nil {
+		respondQuestionRevisionError(c, "Failed to update question", err)
+		return
+	}
+	if sentToReview {
+		cache.InvalidateQuestionHierarchy() // The question left the practice counts
+	}
+
+	c.JSON(http.StatusOK, gin.H{"question": item})
+}
+
+// toCodeTestCases converts the test cases of a request to the ones stored in a CODE question
+func toCodeTestCases(items []requests.CodeTestCaseItem) []question_type.CodeTestCase {
+	if len(items) == 0 {
+		return nil
+	}
+	testCases := make([]question_type.CodeTestCase, 0, len(items))
+	for _, item := range items {
+		testCases = append(testCases, question_type.CodeTestCase{Input: item.Input, ExpectedOutput: item.ExpectedOutput, IsSample: item.IsSample})
+	}
+	return testCases
+}
+
+// ListQuestionRevisionsHandler returns the revision history of a question, latest first.
+// The history is kept after the question is deleted.
+func ListQuestionRevisionsHandler(c *gin.Context) {
+	formatID, questionID, ok := questionKeyFromParams(c)
+	if !ok {
+		return
+	}
+
+	var revisions []question_type.QuestionRevision
+	if err := config.GetPostgresDBConnection().
+		Where("question_format_id = ? AND question_id = ?", formatID, questionID).
+		Order("revision_number DESC").
+		Find(&revisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch question revisions", "details": err.Error()})
+		return
+	}
+	if len(revisions) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No revisions found for the question"})
+		return
+	}
+
+	history := make([]response.QuestionRevisionResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		history = append(history, toQuestionRevisionResponse(revision))
+	}
+	c.JSON(http.StatusOK, gin.H{"revisions": history})
+}
+
+// DiffQuestionRevisionsHandler compares two revisions of a question field by field.
+// ?to=<n> defaults to the latest revision and ?from=<n> to the revision before it.
+func DiffQuestionRevisionsHandler(c *gin.Context) {
+	formatID, questionID, ok := questionKeyFromParams(c)
+	if !ok {
+		return
+	}
+
+	parseRevisionNumber := func(name string) (int, bool) {
+		value := c.Query(name)
+		if value == "" {
+			return 0, true
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid %s revision, expected a positive number", name)})
+			return 0, false
+		}
+		return number, true
+	}
+	fromNumber, ok := parseRevisionNumber("from")
+	if !ok {
+		return
+	}
+	toNumber, ok := parseRevisionNumber("to")
+	if !ok {
+		return
+	}
+
+	var diff response.QuestionRevisionDiffResponse
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		to, err := fetchQuestionRevision(tx, formatID, questionID, toNumber)
+		if err != nil {
+			return err
+		}
+		if fromNumber == 0 {
+			if fromNumber = to.RevisionNumber - 1; fromNumber == 0 {
+				return fmt.Errorf("%w: revision 1 has no previous revision, give ?from=", errRevisionNotFound)
+			}
+		}
+		from, err := fetchQuestionRevision(tx, formatID, questionID, fromNumber)
+		if err != nil {
+			return err
+		}
+
+		diff = response.QuestionRevisionDiffResponse{
+			From:    toQuestionRevisionResponse(from),
+			To:      toQuestionRevisionResponse(to),
+			Changes: diffQuestionSnapshots(from.Snapshot, to.Snapshot),
+		}
+		return nil
+	})
+	if err != nil {
+		respondQuestionRevisionError(c, "Failed to compare question revisions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// RestoreQuestionRevisionHandler brings a question back to the content of one of its revisions.
+// The restore is recorded as a new revision, the history in between is kept. Volunteers only restore their own drafts,
+// a published question whose content changes goes back to review, as on update.
+func RestoreQuestionRevisionHandler(c *gin.Context) {
+	formatID, questionID, ok := questionKeyFromParams(c)
+	if !ok {
+		return
+	}
+	revisionNumber, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revisionNumber < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision number"})
+		return
+	}
+
+	username := middlewares.RequestUsername(c)
+	ctx := question_type.WithRevisionRestore(c.Request.Context(), username, revisionNumber)
+
+	var item response.QuestionItemResponse
+	sentToReview := false
+	err = config.GetPostgresDBConnection().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		revision, err := fetchQuestionRevision(tx, formatID, questionID, revisionNumber)
+		if err != nil {
+			return err
+		}
+		format, question, err := fetchStoredQuestion(tx, formatID, questionID)
+		if err != nil {
+			return err
+		}
+		if err := checkQuestionEditable(c, tx, question, formatID, questionID); err != nil {
+			return err
+		}
+
+		// Nothing is recorded when the question already matches the revision
+		previous := question.RevisionSnapshot()
+		question.ApplySnapshot(revision.Snapshot)
+		if err := tx.Save(question).Error; err != nil {
+			return fmt.Errorf("failed to restore question: %w", err)
+		}
+		if !previous.Equal(question.RevisionSnapshot()) {
+			note := fmt.Sprintf("revision %d restored", revisionNumber)
+			if sentToReview, err = sendEditedQuestionToReview(tx, format, question, formatID, questionID, username, note); err != nil {
+				return err
+			}
+		}
+
+		item, err = fetchQuestionItem(tx, formatID, questionID)
+		return err
+	})
+	if err != nil {
+		respondQuestionRevisionError(c, "Failed to restore question revision", err)
+		return
+	}
+	if sentToReview {
+		cache.InvalidateQuestionHierarchy() // The question left the practice counts
+	}
+
+	c.JSON(http.StatusOK, gin.H{"question": item, "restoredFrom": revisionNumber})
+}
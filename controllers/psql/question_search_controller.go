@@ -0,0 +1,184 @@
+package controllersNew
+
+import (
+	"fmt"
+	"net/http"
+	"server/config"
+	question_hierarchy "server/models/question_bank/question_hierarchy"
+	question_type "server/models/question_bank/question_type"
+	"server/models/response"
+	"server/postgresql_database/triggers"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxSearchQueryLength     = 200
+	defaultSearchResultLimit = 20
+	maxSearchResultLimit     = 100
+)
+
+// searchHighlightOptions configures the fragments returned by ts_headline
+const searchHighlightOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+
+// searchHierarchyAliases are the aliases of the hierarchy tables in the search query, in the order of hierarchyLevels
+var searchHierarchyAliases = []string{"d", "sd", "n", "dl", "f"}
+
+// searchTextColumns selects the options and the explanation of each question table as text to highlight,
+// the formats without them select empty text so that the tables can be combined
+var searchTextColumns = map[string]string{
+	"MCQ":  "array_to_string(q.options, ' | ') AS options_text, q.explanation AS explanation_text",
+	"TF":   "'' AS options_text, q.explanation AS explanation_text",
+	"FIB":  "'' AS options_text, COALESCE(q.explanation, '') AS explanation_text",
+	"TXT":  "'' AS options_text, '' AS explanation_text",
+	"NUM":  "'' AS options_text, q.explanation AS explanation_text",
+	"CODE": "'' AS options_text, q.explanation AS explanation_text",
+	"MTC":  "'' AS options_text, q.explanation AS explanation_text",
+	"ORD":  "'' AS options_text, q.explanation AS explanation_text",
+}
+
+// questionSearchRow is a search result along with the number of matches of the whole search
+type questionSearchRow struct {
+	response.QuestionSearchResult
+	TotalMatches int64
+}
+
+// SearchQuestionsHandler searches the question text, the options and the explanation of every question format
+// with the Postgres full-text search, best matches first.
+// ?q= takes the web search syntax ("quoted phrases", or, -excluded), ?level=<level>&id=<nodeID> keeps the questions
+// under a hierarchy node, ?format= and ?status= keep a format and a workflow status, ?limit= and ?offset= page the results.
+func SearchQuestionsHandler(c *gin.Context) {
+	searchQuery := strings.TrimSpace(c.Query("q"))
+	if searchQuery == "" || utf8.RuneCountInString(searchQuery) > maxSearchQueryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Missing or invalid search query, expected 1 to %d characters", maxSearchQueryLength)})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSearchResultLimit)))
+	if err != nil || limit < 1 || limit > maxSearchResultLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid limit, expected 1 to %d", maxSearchResultLimit)})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return
+	}
+
+	// Filters shared by the query of every question table
+	var filters []string
+	var filterArgs []interface{}
+
+	if levelSlug := c.Query("level"); levelSlug != "" {
+		levelIndex := slices.IndexFunc(hierarchyLevels, func(level hierarchyLevel) bool { return level.Slug == levelSlug })
+		if levelIndex < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown hierarchy level", "details": levelSlug})
+			return
+		}
+		id, err := strconv.ParseUint(c.Query("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid node ID", "details": err.Error()})
+			return
+		}
+		filters = append(filters, fmt.Sprintf("%s.%s = ?", searchHierarchyAliases[levelIndex], hierarchyLevels[levelIndex].IDColumn))
+		filterArgs = append(filterArgs, uint32(id))
+	}
+
+	if status := c.Query("status"); status != "" {
+		status = strings.ToUpper(status)
+		if !slices.Contains(question_type.QuestionStatuses, status) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status", "details": fmt.Sprintf("expected one of %s", strings.Join(question_type.QuestionStatuses, ", "))})
+			return
+		}
+		filters = append(filters, "q.status = ?")
+		filterArgs = append(filterArgs, status)
+	}
+
+	formats := question_type.QuestionFormats
+	if format := c.Query("format"); format != "" {
+		format = strings.ToUpper(format)
+		if !slices.Contains(question_type.QuestionFormats, format) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format", "details": fmt.Sprintf("expected one of %s", strings.Join(question_type.QuestionFormats, ", "))})
+			return
+		}
+		formats = []string{format}
+	}
+
+	where := "q.search_vector @@ query"
+	for _, filter := range filters {
+		where += " AND " + filter
+	}
+
+	hierarchyJoins := fmt.Sprintf(`
+		JOIN %s f ON f.question_format_id = q.question_format_id
+		JOIN %s dl ON dl.question_difficulty_level_id = f.question_difficulty_level_id
+		JOIN %s n ON n.question_niche_id = dl.question_niche_id
+		JOIN %s sd ON sd.question_sub_domain_id = n.question_sub_domain_id
+		JOIN %s d ON d.question_domain_id = sd.question_domain_id`,
+		question_hierarchy.QuestionFormatTable{}.TableName(),
+		question_hierarchy.QuestionDifficultyLevelTable{}.TableName(),
+		question_hierarchy.QuestionNicheTable{}.TableName(),
+		question_hierarchy.QuestionSubDomainsTable{}.TableName(),
+		question_hierarchy.QuestionDomainsTable{}.TableName(),
+	)
+
+	// Rank the matches of every table, then highlight only the page that is returned
+	selects := make([]string, 0, len(formats))
+	var args []interface{}
+	for _, format := range formats {
+		questionTable, err := question_type.QuestionTableForFormat(format)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		selects = append(selects, fmt.Sprintf(`
+			SELECT q.question_format_id, q.question_id, d.domain_name, sd.sub_domain_name, n.niche_name,
+				dl.difficulty_level, f.format, q.status, ts_rank(q.search_vector, query) AS rank, q.question_text, %s
+			FROM %s q
+			CROSS JOIN websearch_to_tsquery('%s', ?) AS query %s
+			WHERE %s`,
+			searchTextColumns[format], questionTable, triggers.QuestionSearchConfig, hierarchyJoins, where,
+		))
+		args = append(args, searchQuery)
+		args = append(args, filterArgs...)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT page.question_format_id, page.question_id, page.domain_name, page.sub_domain_name, page.niche_name,
+			page.difficulty_level, page.format, page.status, page.rank, page.question_text, page.total_matches,
+			ts_headline('%[2]s', page.question_text, query, '%[3]s') AS question_highlight,
+			CASE WHEN to_tsvector('%[2]s', page.options_text) @@ query
+				THEN ts_headline('%[2]s', page.options_text, query, '%[3]s') ELSE '' END AS options_highlight,
+			CASE WHEN to_tsvector('%[2]s', page.explanation_text) @@ query
+				THEN ts_headline('%[2]s', page.explanation_text, query, '%[3]s') ELSE '' END AS explanation_highlight
+		FROM (
+			SELECT matches.*, COUNT(*) OVER () AS total_matches
+			FROM (%[1]s) matches
+			ORDER BY rank DESC, question_format_id, question_id
+			LIMIT ? OFFSET ?
+		) page
+		CROSS JOIN websearch_to_tsquery('%[2]s', ?) AS query
+		ORDER BY page.rank DESC, page.question_format_id, page.question_id`,
+		strings.Join(selects, " UNION ALL "), triggers.QuestionSearchConfig, searchHighlightOptions,
+	)
+	args = append(args, limit, offset, searchQuery)
+
+	var rows []questionSearchRow
+	if err := config.GetPostgresDBConnection().Raw(query, args...).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search questions", "details": err.Error()})
+		return
+	}
+
+	var totalMatches int64
+	results := make([]response.QuestionSearchResult, 0, len(rows))
+	for _, row := range rows {
+		totalMatches = row.TotalMatches
+		results = append(results, row.QuestionSearchResult)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results, "totalMatches": totalMatches, "limit": limit, "offset": offset})
+}
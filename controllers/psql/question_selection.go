@@ -0,0 +1,271 @@
+package controllersNew
+
+import (
+	"fmt"
+	question_type "server/models/question_bank/question_type"
+	"server/models/response"
+	student_psql "server/models/student_psql"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// questionSelectionRequest describes what a practice session needs from a format node
+type questionSelectionRequest struct {
+	EnrollmentNo   string // Student the questions are selected for
+	QuestionFormat string // Format of the format node (MCQ, TF, FIB, TXT, NUM, CODE, MTC, ORD)
+	FormatID       uint32 // Format node to select the questions from
+	Count          int    // Number of questions needed
+
+	Tags         []string // Lowercased tag names the questions must carry, empty for no tag filter
+	MatchAllTags bool     // Require every tag instead of any of them
+
+	ExcludeSessionID uint32 // Session whose served questions are left out, 0 for none
+	SkipPassages     bool   // Leave out the questions of passages, for the sessions served one question at a time
+}
+
+// tagFilter returns the condition (on the question table aliased q) and its arguments that keeps the questions
+// carrying the tags of the request, empty if the request has no tags
+func (request questionSelectionRequest) tagFilter() (string, []interface{}) {
+	if len(request.Tags) == 0 {
+		return "", nil
+	}
+
+	matchedTags := fmt.Sprintf(`
+		SELECT COUNT(DISTINCT tl.tag_id)
+		FROM %s tl
+		JOIN %s t ON t.tag_id = tl.tag_id
+		WHERE tl.question_format_id = q.question_format_id AND tl.question_id = q.question_id AND LOWER(t.name) IN ?`,
+		question_type.QuestionTagLink{}.TableName(),
+		question_type.QuestionTag{}.TableName(),
+	)
+	if request.MatchAllTags {
+		return fmt.Sprintf("AND (%s) = ?", matchedTags), []interface{}{request.Tags, len(request.Tags)}
+	}
+	return fmt.Sprintf("AND (%s) > 0", matchedTags), []interface{}{request.Tags}
+}
+
+// exclusionFilter returns the condition (on the question table aliased q) and its arguments that leaves out the
+// questions already served in the excluded session and the questions of passages, empty if the request excludes none
+func (request questionSelectionRequest) exclusionFilter() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if request.ExcludeSessionID != 0 {
+		conditions = append(conditions, fmt.Sprintf(`AND NOT EXISTS (
+			SELECT 1 FROM %s es
+			WHERE es.practice_session_id = ? AND es.question_format_id = q.question_format_id AND es.question_id = q.question_id)`,
+			student_psql.StudentPracticeSessionQuestionTable{}.TableName(),
+		))
+		args = append(args, request.ExcludeSessionID)
+	}
+	if request.SkipPassages {
+		conditions = append(conditions, fmt.Sprintf(`AND NOT EXISTS (
+			SELECT 1 FROM %s pi
+			WHERE pi.question_format_id = q.question_format_id AND pi.question_id = q.question_id)`,
+			question_type.QuestionPassageItem{}.TableName(),
+		))
+	}
+	return strings.Join(conditions, " "), args
+}
+
+// questionSelectionStrategy decides which questions of a format node are served in a practice session.
+// It returns the selected question IDs in the order they have to be served.
+type questionSelectionStrategy interface {
+	SelectQuestionIDs(tx *gorm.DB, request questionSelectionRequest) ([]uint32, error)
+}
+
+// practiceQuestionSelector is the strategy used to start practice sessions
+var practiceQuestionSelector questionSelectionStrategy = randomUnseenSelectionStrategy{}
+
+// randomUnseenSelectionStrategy picks published questions at random, preferring the ones the student has never been served.
+// Once the unseen questions run out, the batch is filled with the questions the student saw the longest time ago.
+type randomUnseenSelectionStrategy struct{}
+
+func (randomUnseenSelectionStrategy) SelectQuestionIDs(tx *gorm.DB, request questionSelectionRequest) ([]uint32, error) {
+	questionTable, err := question_type.QuestionTableForFormat(request.QuestionFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	tagCondition, tagArgs := request.tagFilter()
+	exclusionCondition, exclusionArgs := request.exclusionFilter()
+
+	// The history of the student is taken from the questions served in their previous sessions.
+	// Unseen questions have no last_seen, so NULLS FIRST puts them ahead in random order.
+	query := fmt.Sprintf(`
+		SELECT q.question_id
+		FROM %s q
+		LEFT JOIN (
+			SELECT sq.question_id, MAX(r.start_time) AS last_seen
+			FROM %s sq
+			JOIN %s l ON l.practice_session_id = sq.practice_session_id
+			JOIN %s r ON r.practice_session_id = sq.practice_session_id
+			WHERE l.enrollment_no = ? AND sq.question_format_id = ?
+			GROUP BY sq.question_id
+		) seen ON seen.question_id = q.question_id
+		WHERE q.question_format_id = ? AND q.status = ? %s %s
+		ORDER BY seen.last_seen ASC NULLS FIRST, random()
+		LIMIT ?`,
+		questionTable,
+		student_psql.StudentPracticeSessionQuestionTable{}.TableName(),
+		student_psql.StudentPracticeSessionLookupTable{}.TableName(),
+		student_psql.StudentPracticeSessionRecordTable{}.TableName(),
+		tagCondition,
+		exclusionCondition,
+	)
+
+	args := []interface{}{request.EnrollmentNo, request.FormatID, request.FormatID, question_type.QuestionStatusPublished}
+	args = append(args, tagArgs...)
+	args = append(args, exclusionArgs...)
+	args = append(args, request.Count)
+
+	var questionIDs []uint32
+	if err := tx.Raw(query, args...).
+		Scan(&questionIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to select questions: %w", err)
+	}
+
+	return questionIDs, nil
+}
+
+// passageCandidateFactor widens the candidates asked from the selection strategy. A passage is served with its
+// whole group or not at all, the groups that don't fit the room left in the session leave it to the next candidates.
+const passageCandidateFactor = 2
+
+// passageGroup is the published questions of a passage, in their order in the group
+type passageGroup struct {
+	PassageID uint32
+	Questions []response.PracticeQuestionResponse
+}
+
+// fetchPassageGroups returns the groups of the passages the given questions of a format node belong to, by question ID.
+// The other questions of a group may come from any format node.
+func fetchPassageGroups(tx *gorm.DB, formatID uint32, questionIDs []uint32) (map[uint32]*passageGroup, error) {
+	var selectedItems []question_type.QuestionPassageItem
+	if err := tx.Where("question_format_id = ? AND question_id IN ?", formatID, questionIDs).
+		Find(&selectedItems).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch question passages: %w", err)
+	}
+	if len(selectedItems) == 0 {
+		return nil, nil
+	}
+
+	passageIDs := make([]uint32, 0, len(selectedItems))
+	for _, item := range selectedItems {
+		passageIDs = append(passageIDs, item.PassageID)
+	}
+	var items []question_type.QuestionPassageItem
+	if err := tx.Where("passage_id IN ?", passageIDs).
+		Order("passage_id, position").
+		Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch passage questions: %w", err)
+	}
+
+	var itemFormatIDs []uint32
+	itemIDsByFormat := make(map[uint32][]uint32)
+	for _, item := range items {
+		if _, exists := itemIDsByFormat[item.QuestionFormatID]; !exists {
+			itemFormatIDs = append(itemFormatIDs, item.QuestionFormatID)
+		}
+		itemIDsByFormat[item.QuestionFormatID] = append(itemIDsByFormat[item.QuestionFormatID], item.QuestionID)
+	}
+	formats, err := fetchFormatsByID(tx, itemFormatIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the published questions of a group are served
+	questionsByKey := make(map[servedQuestionKey]response.PracticeQuestionResponse, len(items))
+	for _, itemFormatID := range itemFormatIDs {
+		questionTable, err := question_type.QuestionTableForFormat(formats[itemFormatID])
+		if err != nil {
+			return nil, err
+		}
+		var publishedIDs []uint32
+		if err := tx.Table(questionTable).
+			Where("question_format_id = ? AND question_id IN ? AND status = ?", itemFormatID, itemIDsByFormat[itemFormatID], question_type.QuestionStatusPublished).
+			Pluck("question_id", &publishedIDs).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch published passage questions: %w", err)
+		}
+		if len(publishedIDs) == 0 {
+			continue
+		}
+
+		questions, err := fetchQuestionsByIDs(tx, formats[itemFormatID], itemFormatID, publishedIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, question := range toPracticeQuestions(questions) {
+			questionsByKey[servedQuestionKey{question.QuestionFormatID, question.QuestionID}] = question
+		}
+	}
+
+	groups := make(map[uint32]*passageGroup, len(passageIDs))
+	for _, item := range items {
+		group, exists := groups[item.PassageID]
+		if !exists {
+			group = &passageGroup{PassageID: item.PassageID}
+			groups[item.PassageID] = group
+		}
+		if question, published := questionsByKey[servedQuestionKey{item.QuestionFormatID, item.QuestionID}]; published {
+			question.PassageID = item.PassageID
+			group.Questions = append(group.Questions, question)
+		}
+	}
+
+	groupsByQuestionID := make(map[uint32]*passageGroup, len(selectedItems))
+	for _, item := range selectedItems {
+		groupsByQuestionID[item.QuestionID] = groups[item.PassageID]
+	}
+	return groupsByQuestionID, nil
+}
+
+// selectPracticeQuestions selects the questions of a practice session with the configured strategy and
+// returns their answer-stripped DTOs in the selected order. A question that belongs to a passage brings the
+// whole group of the passage along, a group is never split across sessions.
+func selectPracticeQuestions(tx *gorm.DB, request questionSelectionRequest) ([]response.PracticeQuestionResponse, error) {
+	candidates := request
+	candidates.Count = request.Count * passageCandidateFactor
+	questionIDs, err := practiceQuestionSelector.SelectQuestionIDs(tx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if len(questionIDs) == 0 {
+		return nil, nil
+	}
+
+	questions, err := fetchQuestionsByIDs(tx, request.QuestionFormat, request.FormatID, questionIDs)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := fetchPassageGroups(tx, request.FormatID, questionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Restore the order decided by the strategy
+	questionsByID := make(map[uint32]response.PracticeQuestionResponse, len(questionIDs))
+	for _, question := range toPracticeQuestions(questions) {
+		questionsByID[question.QuestionID] = question
+	}
+
+	selectedQuestions := make([]response.PracticeQuestionResponse, 0, request.Count)
+	selectedPassages := make(map[uint32]bool)
+	for _, questionID := range questionIDs {
+		if len(selectedQuestions) == request.Count {
+			break
+		}
+		if group, inPassage := groups[questionID]; inPassage {
+			if selectedPassages[group.PassageID] || len(selectedQuestions)+len(group.Questions) > request.Count {
+				continue
+			}
+			selectedPassages[group.PassageID] = true
+			selectedQuestions = append(selectedQuestions, group.Questions...)
+			continue
+		}
+		if question, exists := questionsByID[questionID]; exists {
+			selectedQuestions = append(selectedQuestions, question)
+		}
+	}
+	return selectedQuestions, nil
+}
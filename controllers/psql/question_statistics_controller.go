@@ -0,0 +1,262 @@
+package controllersNew
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"server/config"
+	question_hierarchy "server/models/question_bank/question_hierarchy"
+	question_type "server/models/question_bank/question_type"
+	"server/models/response"
+	"server/workers"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultFlaggedQuestionsLimit and maxFlaggedQuestionsLimit bound the page size of the flagged questions report
+const (
+	defaultFlaggedQuestionsLimit = 50
+	maxFlaggedQuestionsLimit     = 200
+)
+
+// questionStatisticsRow is a row of the statistics query, the statistics of a question along with its hierarchy
+type questionStatisticsRow struct {
+	question_type.QuestionStatistics
+	Format          string
+	DifficultyLevel string
+	DomainName      string
+	SubDomainName   string
+	NicheName       string
+	QuestionText    string
+	Status          string
+	TotalMatches    int64
+}
+
+// fetchQuestionStatistics returns a page of the stored statistics matching the filter, the questions with the
+// most flags and attempts first, along with the number of matches
+func fetchQuestionStatistics(tx *gorm.DB, where string, whereArgs []interface{}, limit, offset int) ([]response.QuestionStatisticsEntry, int64, error) {
+	questionColumns := make([]string, 0, len(question_type.QuestionFormats))
+	for _, format := range question_type.QuestionFormats {
+		questionTable, err := question_type.QuestionTableForFormat(format)
+		if err != nil {
+			return nil, 0, err
+		}
+		questionColumns = append(questionColumns, fmt.Sprintf("SELECT question_format_id, question_id, question_text, status FROM %s", questionTable))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT s.*, q.question_text, q.status, f.format, dl.difficulty_level, n.niche_name, sd.sub_domain_name, d.domain_name,
+			COUNT(*) OVER () AS total_matches
+		FROM %s s
+		JOIN (%s) q ON q.question_format_id = s.question_format_id AND q.question_id = s.question_id
+		JOIN %s f ON f.question_format_id = s.question_format_id
+		JOIN %s dl ON dl.question_difficulty_level_id = f.question_difficulty_level_id
+		JOIN %s n ON n.question_niche_id = dl.question_niche_id
+		JOIN %s sd ON sd.question_sub_domain_id = n.question_sub_domain_id
+		JOIN %s d ON d.question_domain_id = sd.question_domain_id
+		WHERE %s
+		ORDER BY cardinality(s.flags) DESC, s.attempts DESC, s.question_format_id, s.question_id
+		LIMIT ? OFFSET ?`,
+		question_type.QuestionStatistics{}.TableName(),
+		strings.Join(questionColumns, " UNION ALL "),
+		question_hierarchy.QuestionFormatTable{}.TableName(),
+		question_hierarchy.QuestionDifficultyLevelTable{}.TableName(),
+		question_hierarchy.QuestionNicheTable{}.TableName(),
+		question_hierarchy.QuestionSubDomainsTable{}.TableName(),
+		question_hierarchy.QuestionDomainsTable{}.TableName(),
+		where,
+	)
+
+	var rows []questionStatisticsRow
+	if err := tx.Raw(query, append(whereArgs, limit, offset)...).Scan(&rows).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch question statistics: %w", err)
+	}
+
+	// The options and the answer key of the MCQ questions, the IN lists cross the formats and the questions
+	var formatIDs, questionIDs []uint32
+	for _, row := range rows {
+		if row.Format == "MCQ" {
+			formatIDs = append(formatIDs, row.QuestionFormatID)
+			questionIDs = append(questionIDs, row.QuestionID)
+		}
+	}
+	mcqQuestions := make(map[servedQuestionKey]question_type.MCQQuestion)
+	if len(formatIDs) > 0 {
+		var questions []question_type.MCQQuestion
+		if err := tx.Select("question_format_id", "question_id", "options", "correct_option_indices").
+			Where("question_format_id IN ? AND question_id IN ?", formatIDs, questionIDs).
+			Find(&questions).Error; err != nil {
+			return nil, 0, fmt.Errorf("failed to fetch MCQ options: %w", err)
+		}
+		for _, question := range questions {
+			mcqQuestions[servedQuestionKey{question.QuestionFormatID, question.QuestionID}] = question
+		}
+	}
+
+	var totalMatches int64
+	entries := make([]response.QuestionStatisticsEntry, 0, len(rows))
+	for _, row := range rows {
+		totalMatches = row.TotalMatches
+		question := mcqQuestions[servedQuestionKey{row.QuestionFormatID, row.QuestionID}]
+		entries = append(entries, response.QuestionStatisticsEntry{
+			QuestionStatisticsResponse: toQuestionStatisticsResponse(row.QuestionStatistics, row.DifficultyLevel, question.Options, question.CorrectOptionIndices),
+			Format:                     row.Format,
+			DomainName:                 row.DomainName,
+			SubDomainName:              row.SubDomainName,
+			NicheName:                  row.NicheName,
+			QuestionText:               row.QuestionText,
+			Status:                     row.Status,
+		})
+	}
+	return entries, totalMatches, nil
+}
+
+// toQuestionStatisticsResponse builds the statistics of a question, with the distribution of the choices of an MCQ
+// question. The distribution is left out when the options changed since the statistics were computed.
+func toQuestionStatisticsResponse(statistics question_type.QuestionStatistics, difficultyLevel string, options []string, correctOptions []int32) response.QuestionStatisticsResponse {
+	statisticsResponse := response.QuestionStatisticsResponse{
+		QuestionFormatID:    statistics.QuestionFormatID,
+		QuestionID:          statistics.QuestionID,
+		RevisionID:          statistics.RevisionID,
+		Attempts:            statistics.Attempts,
+		Skips:               statistics.Skips,
+		GradedAttempts:      statistics.GradedAttempts,
+		CorrectAttempts:     statistics.CorrectAttempts,
+		PercentCorrect:      statistics.PercentCorrect,
+		MedianTimeSeconds:   statistics.MedianTimeSeconds,
+		DifficultyLevel:     difficultyLevel,
+		EmpiricalDifficulty: statistics.EmpiricalDifficulty,
+		Flags:               statistics.Flags,
+		ComputedAt:          statistics.ComputedAt,
+	}
+	if statisticsResponse.Flags == nil {
+		statisticsResponse.Flags = []string{}
+	}
+
+	if len(statistics.OptionCounts) == 0 || len(statistics.OptionCounts) != len(options) {
+		return statisticsResponse
+	}
+	for index, choices := range statistics.OptionCounts {
+		optionStatistics := response.QuestionOptionStatistics{
+			OptionIndex: int32(index),
+			Option:      options[index],
+			IsCorrect:   slices.Contains(correctOptions, int32(index)),
+			Choices:     choices,
+		}
+		if statistics.Attempts > 0 {
+			optionStatistics.Percent = 100 * float64(choices) / float64(statistics.Attempts)
+		}
+		statisticsResponse.Options = append(statisticsResponse.Options, optionStatistics)
+	}
+	return statisticsResponse
+}
+
+// deleteQuestionStatistics removes the statistics of the questions of the format nodes, the question tables
+// can't be referenced by a foreign key
+func deleteQuestionStatistics(tx *gorm.DB, formatIDs []uint32) error {
+	if err := tx.Where("question_format_id IN ?", formatIDs).Delete(&question_type.QuestionStatistics{}).Error; err != nil {
+		return fmt.Errorf("failed to delete question statistics: %w", err)
+	}
+	return nil
+}
+
+// GetQuestionStatisticsHandler returns the answer statistics of a question: attempts, percent correct, median time,
+// the distribution of the choices of an MCQ question, its empirical difficulty and its flags
+func GetQuestionStatisticsHandler(c *gin.Context) {
+	formatID, questionID, ok := questionKeyFromParams(c)
+	if !ok {
+		return
+	}
+
+	entries, _, err := fetchQuestionStatistics(config.GetPostgresDBConnection(),
+		"s.question_format_id = ? AND s.question_id = ?", []interface{}{formatID, questionID}, 1, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch question statistics", "details": err.Error()})
+		return
+	}
+	if len(entries) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No statistics for the question", "details": "the question doesn't exist or its latest revision wasn't answered yet"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statistics": entries[0]})
+}
+
+// GetFlaggedQuestionsHandler lists the questions whose statistics raised a flag: an empirical difficulty that
+// disagrees with their node, a distractor almost nobody picks or a likely wrong answer key.
+// ?flag= narrows the report to a flag, ?formatID= to a format node, ?limit= and ?offset= page it.
+func GetFlaggedQuestionsHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultFlaggedQuestionsLimit)))
+	if err != nil || limit < 1 || limit > maxFlaggedQuestionsLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid limit, expected 1 to %d", maxFlaggedQuestionsLimit)})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return
+	}
+
+	where := "cardinality(s.flags) > 0"
+	var whereArgs []interface{}
+	if flag := c.Query("flag"); flag != "" {
+		flag = strings.ToUpper(flag)
+		if !slices.Contains(question_type.QuestionStatisticsFlags, flag) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid flag", "details": fmt.Sprintf("expected one of %s", strings.Join(question_type.QuestionStatisticsFlags, ", "))})
+			return
+		}
+		where += " AND ? = ANY(s.flags)"
+		whereArgs = append(whereArgs, flag)
+	}
+	if formatID := c.Query("formatID"); formatID != "" {
+		id, err := strconv.ParseUint(formatID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format ID", "details": err.Error()})
+			return
+		}
+		where += " AND s.question_format_id = ?"
+		whereArgs = append(whereArgs, uint32(id))
+	}
+
+	db := config.GetPostgresDBConnection()
+	entries, totalMatches, err := fetchQuestionStatistics(db, where, whereArgs, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch flagged questions", "details": err.Error()})
+		return
+	}
+
+	var latest struct{ ComputedAt *time.Time }
+	if err := db.Model(&question_type.QuestionStatistics{}).Select("MAX(computed_at) AS computed_at").Scan(&latest).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch flagged questions", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"questions":    entries,
+		"totalMatches": totalMatches,
+		"computedAt":   latest.ComputedAt,
+		"minAttempts":  workers.LoadQuestionStatisticsConfig().MinAttempts,
+		"limit":        limit,
+		"offset":       offset,
+	})
+}
+
+// RecomputeQuestionStatisticsHandler computes the question statistics right away instead of waiting for the worker
+func RecomputeQuestionStatisticsHandler(c *gin.Context) {
+	run, err := workers.ComputeQuestionStatistics(workers.LoadQuestionStatisticsConfig().MinAttempts)
+	if err != nil {
+		if errors.Is(err, workers.ErrQuestionStatisticsLocked) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Question statistics are already being computed"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute question statistics", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Question statistics computed successfully", "run": run})
+}
@@ -0,0 +1,364 @@
+package controllersNew
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"server/config"
+	question_hierarchy "server/models/question_bank/question_hierarchy"
+	question_type "server/models/question_bank/question_type"
+	requests "server/models/requests"
+	"server/models/response"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errTagNameTaken = errors.New("a tag with the same name already exists")
+
+// normalizeTagFilter normalizes and lowercases the tags of a question filter, dropping the duplicates
+func normalizeTagFilter(names []string) ([]string, error) {
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		tag, err := question_type.NormalizeTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		if tag = strings.ToLower(tag); !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// tagIDFromParam parses the :tagID route parameter
+func tagIDFromParam(c *gin.Context) (uint32, bool) {
+	id, err := strconv.ParseUint(c.Param("tagID"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID", "details": err.Error()})
+		return 0, false
+	}
+	return uint32(id), true
+}
+
+// fetchQuestionTags returns the tags of a question by name
+func fetchQuestionTags(tx *gorm.DB, formatID, questionID uint32) ([]question_type.QuestionTag, error) {
+	tags := []question_type.QuestionTag{}
+	if err := tx.Joins(fmt.Sprintf("JOIN %s tl ON tl.tag_id = %s.tag_id", question_type.QuestionTagLink{}.TableName(), question_type.QuestionTag{}.TableName())).
+		Where("tl.question_format_id = ? AND tl.question_id = ?", formatID, questionID).
+		Order("name").
+		Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch question tags: %w", err)
+	}
+	return tags, nil
+}
+
+// deleteQuestionTagLinks removes the tag links of the questions of the format nodes, the question tables
+// can't be referenced by the links so their questions are deleted along with them
+func deleteQuestionTagLinks(tx *gorm.DB, formatIDs []uint32) error {
+	if err := tx.Where("question_format_id IN ?", formatIDs).Delete(&question_type.QuestionTagLink{}).Error; err != nil {
+		return fmt.Errorf("failed to delete question tag links: %w", err)
+	}
+	return nil
+}
+
+// ListQuestionTagsHandler returns every tag with the number of questions it labels, by name.
+// ?search= keeps the tags whose name contains the text.
+func ListQuestionTagsHandler(c *gin.Context) {
+	query := config.GetPostgresDBConnection().
+		Table(question_type.QuestionTag{}.TableName() + " t").
+		Select("t.tag_id, t.name, COUNT(tl.tag_id) AS question_count").
+		Joins(fmt.Sprintf("LEFT JOIN %s tl ON tl.tag_id = t.tag_id", question_type.QuestionTagLink{}.TableName())).
+		Group("t.tag_id, t.name").
+		Order("t.name")
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		query = query.Where("t.name ILIKE ?", "%"+search+"%")
+	}
+
+	tags := []response.QuestionTagResponse{}
+	if err := query.Scan(&tags).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// CreateQuestionTagHandler creates a tag, names are unique regardless of case
+func CreateQuestionTagHandler(c *gin.Context) {
+	var request requests.QuestionTagRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	name, err := question_type.NormalizeTagName(request.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid name", "details": err.Error()})
+		return
+	}
+
+	tag := question_type.QuestionTag{Name: name}
+	err = config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&question_type.QuestionTag{}).Where("LOWER(name) = LOWER(?)", name).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check the existing tags: %w", err)
+		}
+		if count > 0 {
+			return errTagNameTaken
+		}
+		if err := tx.Create(&tag).Error; err != nil {
+			return fmt.Errorf("failed to create tag: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errTagNameTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Failed to create tag", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"tag": tag})
+}
+
+// RenameQuestionTagHandler renames a tag, the questions it labels keep it
+func RenameQuestionTagHandler(c *gin.Context) {
+	tagID, ok := tagIDFromParam(c)
+	if !ok {
+		return
+	}
+	var request requests.QuestionTagRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	name, err := question_type.NormalizeTagName(request.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid name", "details": err.Error()})
+		return
+	}
+
+	var tag question_type.QuestionTag
+	err = config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", tagID).Take(&tag).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&question_type.QuestionTag{}).
+			Where("LOWER(name) = LOWER(?) AND tag_id <> ?", name, tagID).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check the existing tags: %w", err)
+		}
+		if count > 0 {
+			return errTagNameTaken
+		}
+
+		if err := tx.Model(&tag).Update("name", name).Error; err != nil {
+			return fmt.Errorf("failed to rename tag: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		case errors.Is(err, errTagNameTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": "Failed to rename tag", "details": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename tag", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tag": tag})
+}
+
+// DeleteQuestionTagHandler deletes a tag and removes it from every question
+func DeleteQuestionTagHandler(c *gin.Context) {
+	tagID, ok := tagIDFromParam(c)
+	if !ok {
+		return
+	}
+
+	// The links are deleted by the foreign key cascade
+	result := config.GetPostgresDBConnection().Where("tag_id = ?", tagID).Delete(&question_type.QuestionTag{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tag", "details": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tag deleted successfully"})
+}
+
+// GetQuestionTagsHandler returns the tags of a question
+func GetQuestionTagsHandler(c *gin.Context) {
+	formatID, questionID, ok := questionKeyFromParams(c)
+	if !ok {
+		return
+	}
+
+	var tags []question_type.QuestionTag
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		if _, _, err := fetchStoredQuestion(tx, formatID, questionID); err != nil {
+			return err
+		}
+		var err error
+		tags, err = fetchQuestionTags(tx, formatID, questionID)
+		return err
+	})
+	if err != nil {
+		respondQuestionRevisionError(c, "Failed to fetch question tags", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// SetQuestionTagsHandler replaces the tags of a question, creating the tags that don't exist yet
+func SetQuestionTagsHandler(c *gin.Context) {
+	formatID, questionID, ok := questionKeyFromParams(c)
+	if !ok {
+		return
+	}
+
+	var request requests.SetQuestionTagsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	names, err := question_type.NormalizeTagNames(request.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag", "details": err.Error()})
+		return
+	}
+
+	var tags []question_type.QuestionTag
+	err = config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		if _, _, err := fetchStoredQuestion(tx, formatID, questionID); err != nil {
+			return err
+		}
+
+		if err := tx.Where("question_format_id = ? AND question_id = ?", formatID, questionID).
+			Delete(&question_type.QuestionTagLink{}).Error; err != nil {
+			return fmt.Errorf("failed to remove question tags: %w", err)
+		}
+		if err := question_type.TagQuestion(tx, formatID, questionID, names); err != nil {
+			return err
+		}
+
+		var err error
+		tags, err = fetchQuestionTags(tx, formatID, questionID)
+		return err
+	})
+	if err != nil {
+		respondQuestionRevisionError(c, "Failed to tag question", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// defaultTaggedQuestionsLimit and maxTaggedQuestionsLimit bound the page size of the questions of a tag
+const (
+	defaultTaggedQuestionsLimit = 50
+	maxTaggedQuestionsLimit     = 200
+)
+
+// ListTaggedQuestionsHandler returns the questions of a tag across the whole hierarchy, with the place of each one.
+// ?status= keeps the questions of a workflow status, ?limit= and ?offset= page the list.
+func ListTaggedQuestionsHandler(c *gin.Context) {
+	tagID, ok := tagIDFromParam(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultTaggedQuestionsLimit)))
+	if err != nil || limit < 1 || limit > maxTaggedQuestionsLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid limit, expected 1 to %d", maxTaggedQuestionsLimit)})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return
+	}
+
+	where := "tl.tag_id = ?"
+	whereArgs := []interface{}{tagID}
+	if status := c.Query("status"); status != "" {
+		status = strings.ToUpper(status)
+		if !slices.Contains(question_type.QuestionStatuses, status) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status", "details": fmt.Sprintf("expected one of %s", strings.Join(question_type.QuestionStatuses, ", "))})
+			return
+		}
+		where += " AND q.status = ?"
+		whereArgs = append(whereArgs, status)
+	}
+
+	hierarchyJoins := fmt.Sprintf(`
+		JOIN %s f ON f.question_format_id = q.question_format_id
+		JOIN %s dl ON dl.question_difficulty_level_id = f.question_difficulty_level_id
+		JOIN %s n ON n.question_niche_id = dl.question_niche_id
+		JOIN %s sd ON sd.question_sub_domain_id = n.question_sub_domain_id
+		JOIN %s d ON d.question_domain_id = sd.question_domain_id`,
+		question_hierarchy.QuestionFormatTable{}.TableName(),
+		question_hierarchy.QuestionDifficultyLevelTable{}.TableName(),
+		question_hierarchy.QuestionNicheTable{}.TableName(),
+		question_hierarchy.QuestionSubDomainsTable{}.TableName(),
+		question_hierarchy.QuestionDomainsTable{}.TableName(),
+	)
+
+	selects := make([]string, 0, len(question_type.QuestionFormats))
+	var args []interface{}
+	for _, format := range question_type.QuestionFormats {
+		questionTable, err := question_type.QuestionTableForFormat(format)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		selects = append(selects, fmt.Sprintf(`
+			SELECT q.question_format_id, q.question_id, d.domain_name, sd.sub_domain_name, n.niche_name,
+				dl.difficulty_level, f.format, q.question_text, q.status
+			FROM %s tl
+			JOIN %s q ON q.question_format_id = tl.question_format_id AND q.question_id = tl.question_id %s
+			WHERE %s`,
+			question_type.QuestionTagLink{}.TableName(), questionTable, hierarchyJoins, where,
+		))
+		args = append(args, whereArgs...)
+	}
+	query := strings.Join(selects, " UNION ALL ") +
+		" ORDER BY domain_name, sub_domain_name, niche_name, difficulty_level, format, question_format_id, question_id LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	questions := []response.TaggedQuestionResponse{}
+	err = config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", tagID).Take(&question_type.QuestionTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Raw(query, args...).Scan(&questions).Error; err != nil {
+			return fmt.Errorf("failed to fetch tagged questions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tagged questions", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"questions": questions, "limit": limit, "offset": offset})
+}
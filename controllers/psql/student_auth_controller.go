@@ -2,12 +2,9 @@ package controllersNew
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"log"
 	"mime/multipart"
 	"net/http"
-	"os"
 	"server/config"
 	"strconv"
 
@@ -16,10 +13,6 @@ import (
 	"server/utils"
 	"server/validators"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"golang.org/x/crypto/bcrypt"
@@ -91,7 +84,7 @@ func StudentLoginHandler(c *gin.Context) {
 	}
 
 	// Generate JWT token for student user
-	commonTokenString, err := utils.GenerateToken("student_user", "common", 24)
+	commonTokenString, err := utils.GenerateStudentToken(user.EnrollmentNo, 24)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token", "details": err.Error()})
 		return
@@ -411,7 +404,7 @@ func StudentSignupHandler(c *gin.Context) {
 	}
 
 	// Generate JWT token for student user
-	commonTokenString, err := utils.GenerateToken("student_user", "common", 24)
+	commonTokenString, err := utils.GenerateStudentToken(userInput.EnrollmentNo, 24)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token", "details": err.Error()})
 		return
@@ -494,37 +487,8 @@ func uploadFileToCloud(file *multipart.FileHeader, filename string) (string, err
 	uploadFile, err := file.Open()
 	if err != nil {
 		return "", fmt.Errorf("failed to open file: %w", err)
-	} else {
-		defer uploadFile.Close()
-
-		// Get the S3 service client
-		client := config.AWSClient
-
-		// Retrieve the bucket name from environment variables or use a default
-		bucket := os.Getenv("S3_BUCKET_NAME")
-		key := "uploads/" + filename
-
-		// Initialize the S3 uploader
-		uploader := manager.NewUploader(client)
-
-		// Perform the upload to S3
-		result, err := uploader.Upload(
-			context.TODO(),
-			&s3.PutObjectInput{
-				Bucket: aws.String(bucket),
-				Key:    aws.String(key),
-				Body:   uploadFile,
-			},
-		)
-		if err != nil {
-			// Handle multi-part upload-specific errors if applicable
-			var multiUploadError manager.MultiUploadFailure
-			if errors.As(err, &multiUploadError) {
-				log.Printf("MultiUpload failure detected, UploadID: %s, Error: %s", multiUploadError.UploadID(), err)
-			}
-			return "", fmt.Errorf("failed to upload file to S3: %w", err)
-		}
-
-		return result.Location, nil
 	}
+	defer uploadFile.Close()
+
+	return config.UploadToCloud(context.TODO(), uploadFile, "uploads/"+filename, file.Header.Get("Content-Type"))
 }
@@ -7,8 +7,10 @@ import (
 	"os"
 
 	"server/config"
+	controllersNew "server/controllers/psql"
 	"server/routes"
 	seed "server/seeds"
+	"server/workers"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -21,10 +23,10 @@ func main() {
 		log.Fatalf("Error loading environment variables: %v", err)
 	}
 
-	// // Initialize AWS session
-	// if err := config.InitializeAWSSession(); err != nil {
-	// 	log.Fatalf("Error initializing AWS session: %v", err)
-	// }
+	// Initialize AWS session, the server runs without the uploads (documents, question images) when it fails
+	if err := config.InitializeAWSSession(); err != nil {
+		log.Printf("Error initializing AWS session, file uploads are disabled: %v", err)
+	}
 
 	// Connect to MongoDB
 	if err := config.ConnectMongoDB(); err != nil {
@@ -50,6 +52,15 @@ func main() {
 
 	go InitGraphQLServer()
 
+	// Expire the practice sessions abandoned by the clients in the background
+	go workers.StartPracticeSessionReaper(controllersNew.CloseExpiredAdaptivePracticeSession)
+
+	// Compute the answer statistics of the questions and flag the ones to review in the background
+	go workers.StartQuestionStatisticsWorker()
+
+	// Drop the cached question hierarchy whenever it is written, by this instance or any other
+	go workers.StartQuestionHierarchyListener()
+
 	// Initialize Gin router
 	router := gin.Default()
 
@@ -67,8 +78,8 @@ func main() {
 
 	// Enable CORS
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},           // Replace * with specific origins for production
-		AllowMethods:     []string{"GET", "POST"}, // "PUT", "DELETE"
+		AllowOrigins:     []string{"*"}, // Replace * with specific origins for production
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
 		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
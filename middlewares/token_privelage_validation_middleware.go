@@ -24,7 +24,24 @@ func PrivilegedMiddleware(requiredRole string) gin.HandlerFunc {
 			return
 		}
 
+		// Store the claims in the context for later use in handlers
+		c.Set("claims", claims)
+
 		// User has sufficient privileges, proceed to the next handler
 		c.Next()
 	}
 }
+
+// RequestHasPrivilege tells whether the token validated for the request holds the role or a higher one,
+// for the handlers whose actions need different roles
+func RequestHasPrivilege(c *gin.Context, requiredRole string) bool {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return false
+	}
+	tokenClaims, ok := claims.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	return utils.HasPrivilege(tokenClaims, requiredRole)
+}
@@ -49,3 +49,32 @@ func TokenValidationMiddleware(c *gin.Context) {
 	// Proceed to the next handler
 	c.Next()
 }
+
+// RequestUsername returns the username of the token validated for the request, empty if none was
+func RequestUsername(c *gin.Context) string {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return ""
+	}
+	tokenClaims, ok := claims.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	username, _ := tokenClaims["username"].(string)
+	return username
+}
+
+// RequestEnrollmentNo returns the enrollment number of the student token validated for the request,
+// empty if none was or if the token isn't a student's
+func RequestEnrollmentNo(c *gin.Context) string {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return ""
+	}
+	tokenClaims, ok := claims.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	enrollmentNo, _ := tokenClaims["enrollmentNo"].(string)
+	return enrollmentNo
+}
@@ -10,5 +10,11 @@ type BaseQuestion struct {
 	QuestionID       uint32    `gorm:"autoIncrement;primaryKey" json:"questionID" bson:"questionID"` // Part of composite primary key
 	QuestionText     string    `gorm:"type:text;not null" json:"questionText" bson:"questionText"`
 	Answer           string    `gorm:"type:text;not null" json:"answer" bson:"answer"`
+	Status           string    `gorm:"type:varchar(10);not null;default:'DRAFT';check:status IN ('DRAFT', 'IN_REVIEW', 'PUBLISHED', 'RETIRED');index" json:"status" bson:"status"` // Lifecycle status, only PUBLISHED questions are served
 	UpdatedAt        time.Time `gorm:"not null;autoUpdateTime;index"`
 }
+
+// QuestionStatus returns the lifecycle status of the question
+func (b BaseQuestion) QuestionStatus() string {
+	return b.Status
+}
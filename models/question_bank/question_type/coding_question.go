@@ -0,0 +1,192 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	codeRunner "server/code_runner"
+
+	"gorm.io/gorm"
+)
+
+// MaxCodeTestCases is the largest number of test cases of a coding question
+const MaxCodeTestCases = 50
+
+// CodeTestCase is an input fed to the submissions on stdin along with the output expected on stdout.
+// The sample test cases are shown to the students and can be run before submitting, the others stay hidden.
+type CodeTestCase struct {
+	Input          string `json:"input"`
+	ExpectedOutput string `json:"expectedOutput"`
+	IsSample       bool   `json:"isSample,omitempty"`
+}
+
+// CodeTestCases stores the test cases of a coding question as JSON
+type CodeTestCases []CodeTestCase
+
+// Value stores the test cases as JSON
+func (t CodeTestCases) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	return json.Marshal(t)
+}
+
+// Scan reads the test cases from their JSON column
+func (t *CodeTestCases) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	default:
+		return fmt.Errorf("unsupported code test cases type %T", value)
+	}
+}
+
+// Samples returns the sample test cases
+func (t CodeTestCases) Samples() CodeTestCases {
+	var samples CodeTestCases
+	for _, test := range t {
+		if test.IsSample {
+			samples = append(samples, test)
+		}
+	}
+	return samples
+}
+
+// RunnerTests converts the test cases for the code runner
+func (t CodeTestCases) RunnerTests() []codeRunner.TestCase {
+	tests := make([]codeRunner.TestCase, 0, len(t))
+	for _, test := range t {
+		tests = append(tests, codeRunner.TestCase{Input: test.Input, ExpectedOutput: test.ExpectedOutput})
+	}
+	return tests
+}
+
+// StarterCode maps a language to the code the students start from in that language
+type StarterCode map[string]string
+
+// Value stores the starter code as JSON
+func (s StarterCode) Value() (driver.Value, error) {
+	if s == nil {
+		return "{}", nil
+	}
+	return json.Marshal(s)
+}
+
+// Scan reads the starter code from its JSON column
+func (s *StarterCode) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("unsupported starter code type %T", value)
+	}
+}
+
+// CodingQuestion extends BaseQuestion for questions answered with a program.
+// QuestionText is the problem statement, Answer is the reference solution written in Language.
+// The submissions are graded by the share of the test cases they pass, every test case runs within the limits.
+type CodingQuestion struct {
+	BaseQuestion                // Embedding common fields
+	Explanation   string        `gorm:"type:text;default:''" json:"explanation,omitempty" bson:"explanation,omitempty"`
+	Language      string        `gorm:"type:varchar(16);not null" json:"language" bson:"language"` // Language of the reference solution
+	StarterCode   StarterCode   `gorm:"type:jsonb;not null;default:'{}'" json:"starterCode" bson:"starterCode"`
+	TestCases     CodeTestCases `gorm:"type:jsonb;not null;default:'[]'" json:"testCases" bson:"testCases"`
+	TimeLimitMs   int           `gorm:"not null;default:2000;check:time_limit_ms > 0" json:"timeLimitMs" bson:"timeLimitMs"`      // Per test case
+	MemoryLimitMB int           `gorm:"not null;default:256;check:memory_limit_mb > 0" json:"memoryLimitMB" bson:"memoryLimitMB"` // Per test case
+}
+
+func (CodingQuestion) TableName() string {
+	return "question_schema.code_questions"
+}
+
+// BeforeSave is a GORM hook that validates the coding question before saving
+func (q *CodingQuestion) BeforeSave(tx *gorm.DB) error {
+	return q.ValidateCode()
+}
+
+// ValidateCode checks the languages, test cases and limits of the question, the limits left out get their defaults
+func (q *CodingQuestion) ValidateCode() error {
+	q.Language = strings.ToLower(strings.TrimSpace(q.Language))
+	if !codeRunner.IsSupportedLanguage(q.Language) {
+		return fmt.Errorf("unsupported language %q for the reference solution, expected one of %s", q.Language, strings.Join(codeRunner.Languages(), ", "))
+	}
+	if strings.TrimSpace(q.Answer) == "" {
+		return errors.New("coding questions must have a reference solution")
+	}
+	for language := range q.StarterCode {
+		if !codeRunner.IsSupportedLanguage(language) {
+			return fmt.Errorf("unsupported language %q for the starter code, expected one of %s", language, strings.Join(codeRunner.Languages(), ", "))
+		}
+	}
+
+	if len(q.TestCases) == 0 || len(q.TestCases) > MaxCodeTestCases {
+		return fmt.Errorf("coding questions must have 1 to %d test cases, got %d", MaxCodeTestCases, len(q.TestCases))
+	}
+	if !slices.ContainsFunc(q.TestCases, func(test CodeTestCase) bool { return !test.IsSample }) {
+		return errors.New("coding questions must have at least one hidden test case")
+	}
+
+	if q.TimeLimitMs == 0 {
+		q.TimeLimitMs = int(codeRunner.DefaultTimeLimit / time.Millisecond)
+	}
+	if q.MemoryLimitMB == 0 {
+		q.MemoryLimitMB = codeRunner.DefaultMemoryLimitMB
+	}
+	if q.TimeLimitMs < 0 || time.Duration(q.TimeLimitMs)*time.Millisecond > codeRunner.MaxTimeLimit {
+		return fmt.Errorf("time limit must be 1 to %d ms, got %d", codeRunner.MaxTimeLimit.Milliseconds(), q.TimeLimitMs)
+	}
+	if q.MemoryLimitMB < 0 || q.MemoryLimitMB > codeRunner.MaxMemoryLimitMB {
+		return fmt.Errorf("memory limit must be 1 to %d MB, got %d", codeRunner.MaxMemoryLimitMB, q.MemoryLimitMB)
+	}
+	return nil
+}
+
+// RunnerLimits are the limits every test case of the question runs within
+func (q CodingQuestion) RunnerLimits() codeRunner.Limits {
+	return CodeRunnerLimits(q.TimeLimitMs, q.MemoryLimitMB)
+}
+
+// CodeRunnerLimits builds the runner limits of a coding question from its stored limits, used for the served revisions too
+func CodeRunnerLimits(timeLimitMs, memoryLimitMB int) codeRunner.Limits {
+	return codeRunner.Limits{
+		TimeLimit:     time.Duration(timeLimitMs) * time.Millisecond,
+		MemoryLimitMB: memoryLimitMB,
+	}
+}
+
+func (q CodingQuestion) RevisionSnapshot() QuestionSnapshot {
+	return QuestionSnapshot{
+		QuestionText:  q.QuestionText,
+		Answer:        q.Answer,
+		Language:      q.Language,
+		StarterCode:   q.StarterCode,
+		TestCases:     q.TestCases,
+		TimeLimitMs:   q.TimeLimitMs,
+		MemoryLimitMB: q.MemoryLimitMB,
+		Explanation:   q.Explanation,
+	}
+}
+
+func (q *CodingQuestion) ApplySnapshot(snapshot QuestionSnapshot) {
+	q.QuestionText, q.Answer, q.Explanation = snapshot.QuestionText, snapshot.Answer, snapshot.Explanation
+	q.Language, q.StarterCode, q.TestCases = snapshot.Language, snapshot.StarterCode, snapshot.TestCases
+	q.TimeLimitMs, q.MemoryLimitMB = snapshot.TimeLimitMs, snapshot.MemoryLimitMB
+}
+
+// AfterCreate and AfterUpdate record the revisions of the question
+func (q *CodingQuestion) AfterCreate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &CodingQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeCreate)
+}
+
+func (q *CodingQuestion) AfterUpdate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &CodingQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeUpdate)
+}
@@ -1,5 +1,7 @@
 package models
 
+import "gorm.io/gorm"
+
 // FillInTheBlankQuestion extends BaseQuestion for fill-in-the-blank questions
 type FillInTheBlankQuestion struct {
 	BaseQuestion         // Embedding common fields
@@ -9,3 +11,25 @@ type FillInTheBlankQuestion struct {
 func (FillInTheBlankQuestion) TableName() string {
 	return "question_schema.fib_questions"
 }
+
+func (q FillInTheBlankQuestion) RevisionSnapshot() QuestionSnapshot {
+	snapshot := QuestionSnapshot{QuestionText: q.QuestionText, Answer: q.Answer}
+	if q.Explanation != nil {
+		snapshot.Explanation = *q.Explanation
+	}
+	return snapshot
+}
+
+func (q *FillInTheBlankQuestion) ApplySnapshot(snapshot QuestionSnapshot) {
+	explanation := snapshot.Explanation
+	q.QuestionText, q.Answer, q.Explanation = snapshot.QuestionText, snapshot.Answer, &explanation
+}
+
+// AfterCreate and AfterUpdate record the revisions of the question
+func (q *FillInTheBlankQuestion) AfterCreate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &FillInTheBlankQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeCreate)
+}
+
+func (q *FillInTheBlankQuestion) AfterUpdate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &FillInTheBlankQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeUpdate)
+}
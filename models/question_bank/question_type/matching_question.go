@@ -0,0 +1,193 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Bounds on the number of pairs of a matching question
+const (
+	MinMatchPairs = 2
+	MaxMatchPairs = 10
+)
+
+// MatchPairSeparator separates the column A item from its column B item in the answer text of a matching question,
+// written one pair per line
+const MatchPairSeparator = "=>"
+
+// MatchPair is an item of column A along with the item of column B it matches
+type MatchPair struct {
+	Left  string `json:"left"`
+	Right string `json:"right"`
+}
+
+// MatchPairs stores the pairs of a matching question as JSON
+type MatchPairs []MatchPair
+
+// Value stores the pairs as JSON
+func (p MatchPairs) Value() (driver.Value, error) {
+	if p == nil {
+		return "[]", nil
+	}
+	return json.Marshal(p)
+}
+
+// Scan reads the pairs from their JSON column
+func (p *MatchPairs) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	default:
+		return fmt.Errorf("unsupported match pairs type %T", value)
+	}
+}
+
+// ColumnA returns the column A items in the order of the pairs
+func (p MatchPairs) ColumnA() []string {
+	column := make([]string, 0, len(p))
+	for _, pair := range p {
+		column = append(column, pair.Left)
+	}
+	return column
+}
+
+// ColumnB returns the column B items in the order of the pairs, the item at a position matches the column A item at the same position
+func (p MatchPairs) ColumnB() []string {
+	column := make([]string, 0, len(p))
+	for _, pair := range p {
+		column = append(column, pair.Right)
+	}
+	return column
+}
+
+// MatchedText is the answer text of the given matches, one pair per line. matches holds the position of the
+// column B item matched to each column A item, the column A items left unmatched (-1) are left out.
+func (p MatchPairs) MatchedText(matches []int32) string {
+	lines := make([]string, 0, len(matches))
+	for i, match := range matches {
+		if i < len(p) && match >= 0 && int(match) < len(p) {
+			lines = append(lines, p[i].Left+" "+MatchPairSeparator+" "+p[match].Right)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseMatchPairs reads the pairs of a matching question from its answer text, one "<column A> => <column B>" pair per line
+func ParseMatchPairs(answer string) (MatchPairs, error) {
+	var pairs MatchPairs
+	for _, line := range strings.Split(answer, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		left, right, found := strings.Cut(line, MatchPairSeparator)
+		if !found {
+			return nil, fmt.Errorf("matching answer line %q must be written as \"<column A> %s <column B>\"", strings.TrimSpace(line), MatchPairSeparator)
+		}
+		pairs = append(pairs, MatchPair{Left: strings.TrimSpace(left), Right: strings.TrimSpace(right)})
+	}
+	return pairs, nil
+}
+
+// MatchingQuestion extends BaseQuestion for questions that match the items of column A to the items of column B.
+// Column B is shuffled when served, Answer keeps the pairs as text (one pair per line) for display.
+type MatchingQuestion struct {
+	BaseQuestion            // Embedding common fields
+	Explanation  string     `gorm:"type:text;default:''" json:"explanation,omitempty" bson:"explanation,omitempty"`
+	Pairs        MatchPairs `gorm:"type:jsonb;not null;default:'[]'" json:"pairs" bson:"pairs"`
+}
+
+func (MatchingQuestion) TableName() string {
+	return "question_schema.mtc_questions"
+}
+
+// BeforeSave is a GORM hook that validates the pairs of the matching question before saving
+func (q *MatchingQuestion) BeforeSave(tx *gorm.DB) error {
+	return q.ResolveAnswer()
+}
+
+// ResolveAnswer reads the pairs from the answer text when they aren't set, validates them and rewrites Answer from them
+func (q *MatchingQuestion) ResolveAnswer() error {
+	if len(q.Pairs) == 0 {
+		pairs, err := ParseMatchPairs(q.Answer)
+		if err != nil {
+			return err
+		}
+		q.Pairs = pairs
+	}
+	if err := q.ValidatePairs(); err != nil {
+		return err
+	}
+
+	q.Answer = q.Pairs.MatchedText(identityArrangement(len(q.Pairs)))
+	return nil
+}
+
+// ValidatePairs checks the number of pairs and that the items of each column are given once
+func (q *MatchingQuestion) ValidatePairs() error {
+	if len(q.Pairs) < MinMatchPairs || len(q.Pairs) > MaxMatchPairs {
+		return fmt.Errorf("matching questions must have %d to %d pairs, got %d", MinMatchPairs, MaxMatchPairs, len(q.Pairs))
+	}
+
+	seenLeft := make(map[string]bool, len(q.Pairs))
+	seenRight := make(map[string]bool, len(q.Pairs))
+	for i := range q.Pairs {
+		q.Pairs[i].Left, q.Pairs[i].Right = strings.TrimSpace(q.Pairs[i].Left), strings.TrimSpace(q.Pairs[i].Right)
+		left, right := q.Pairs[i].Left, q.Pairs[i].Right
+		if left == "" || right == "" {
+			return errors.New("matching items can't be empty")
+		}
+		if strings.Contains(left, "\n") || strings.Contains(right, "\n") {
+			return errors.New("matching items must fit on one line")
+		}
+		if strings.Contains(left, MatchPairSeparator) {
+			return fmt.Errorf("column A item %q can't contain %q", left, MatchPairSeparator)
+		}
+		if seenLeft[normalizeOption(left)] {
+			return fmt.Errorf("column A item %q is given twice", left)
+		}
+		if seenRight[normalizeOption(right)] {
+			return fmt.Errorf("column B item %q is given twice", right)
+		}
+		seenLeft[normalizeOption(left)], seenRight[normalizeOption(right)] = true, true
+	}
+	return nil
+}
+
+// identityArrangement is the arrangement of n items left in their place, the correct one of a matching or ordering question
+func identityArrangement(n int) []int32 {
+	arrangement := make([]int32, n)
+	for i := range arrangement {
+		arrangement[i] = int32(i)
+	}
+	return arrangement
+}
+
+func (q MatchingQuestion) RevisionSnapshot() QuestionSnapshot {
+	return QuestionSnapshot{
+		QuestionText: q.QuestionText,
+		Answer:       q.Answer,
+		Pairs:        q.Pairs,
+		Explanation:  q.Explanation,
+	}
+}
+
+func (q *MatchingQuestion) ApplySnapshot(snapshot QuestionSnapshot) {
+	q.QuestionText, q.Answer, q.Explanation = snapshot.QuestionText, snapshot.Answer, snapshot.Explanation
+	q.Pairs = snapshot.Pairs
+}
+
+// AfterCreate and AfterUpdate record the revisions of the question
+func (q *MatchingQuestion) AfterCreate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &MatchingQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeCreate)
+}
+
+func (q *MatchingQuestion) AfterUpdate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &MatchingQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeUpdate)
+}
@@ -2,16 +2,31 @@ package models
 
 import (
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
-// MCQQuestion extends BaseQuestion for MCQ questions
+// Bounds on the number of options of an MCQ question
+const (
+	MinMCQOptions = 2
+	MaxMCQOptions = 6
+)
+
+// MCQAnswerSeparator separates the correct options in the answer text of a multi-select MCQ question
+const MCQAnswerSeparator = "\n"
+
+// MCQQuestion extends BaseQuestion for MCQ questions.
+// The correct options are stored as their positions, Answer keeps their text (one per line) for display.
 type MCQQuestion struct {
-	BaseQuestion                // Embedding common fields
-	Explanation  string         `gorm:"type:text;default:''"  json:"explanation,omitempty" bson:"explanation,omitempty"`
-	Options      pq.StringArray `gorm:"not null;type:text[];not null" json:"options" bson:"options"` // Additional field for MCQ options
+	BaseQuestion                        // Embedding common fields
+	Explanation          string         `gorm:"type:text;default:''"  json:"explanation,omitempty" bson:"explanation,omitempty"`
+	Options              pq.StringArray `gorm:"not null;type:text[];not null" json:"options" bson:"options"`                                  // Additional field for MCQ options
+	CorrectOptionIndices pq.Int32Array  `gorm:"type:integer[];not null;default:'{}'" json:"correctOptionIndices" bson:"correctOptionIndices"` // Zero-based positions of the correct options
+	IsMultiSelect        bool           `gorm:"not null;default:false" json:"isMultiSelect" bson:"isMultiSelect"`                             // Students may pick several options
 }
 
 func (MCQQuestion) TableName() string {
@@ -21,17 +36,172 @@ func (MCQQuestion) TableName() string {
 // Use if want to check before every save operation of the database.
 // BeforeSave is a GORM hook that validates the MCQ question before saving
 func (m *MCQQuestion) BeforeSave(tx *gorm.DB) error {
-	if len(m.Options) != 4 {
-		return errors.New("MCQ questions must have exactly 4 options")
-	}
-	return nil
+	return m.ResolveAnswer()
 }
 
 // Use if want to use custom or multilevel validations and want to call check and validations
 // conditionally and not every time before inserting or updating data.
 func (m *MCQQuestion) ValidateOptions() error {
-	if len(m.Options) != 4 {
-		return errors.New("MCQ questions must have exactly 4 options")
+	if len(m.Options) < MinMCQOptions || len(m.Options) > MaxMCQOptions {
+		return fmt.Errorf("MCQ questions must have %d to %d options, got %d", MinMCQOptions, MaxMCQOptions, len(m.Options))
+	}
+	seen := make(map[string]bool, len(m.Options))
+	for _, option := range m.Options {
+		normalized := normalizeOption(option)
+		if normalized == "" {
+			return errors.New("MCQ options can't be empty")
+		}
+		if seen[normalized] {
+			return fmt.Errorf("MCQ option %q is given twice", option)
+		}
+		seen[normalized] = true
+	}
+
+	if len(m.CorrectOptionIndices) == 0 {
+		return errors.New("MCQ questions must have at least one correct option")
+	}
+	if !m.IsMultiSelect && len(m.CorrectOptionIndices) > 1 {
+		return errors.New("single-select MCQ questions must have exactly one correct option")
+	}
+	for i, index := range m.CorrectOptionIndices {
+		if index < 0 || int(index) >= len(m.Options) {
+			return fmt.Errorf("correct option %d is out of the %d options", index, len(m.Options))
+		}
+		if slices.Contains(m.CorrectOptionIndices[:i], index) {
+			return fmt.Errorf("correct option %d is given twice", index)
+		}
 	}
 	return nil
 }
+
+// ResolveAnswer fills the correct options from the answer text when they aren't set, validates the question
+// and rewrites Answer from the correct options. Several correct options make the question multi-select.
+func (m *MCQQuestion) ResolveAnswer() error {
+	if len(m.CorrectOptionIndices) == 0 {
+		indices, err := MCQOptionIndices(m.Options, m.Answer)
+		if err != nil {
+			return err
+		}
+		m.CorrectOptionIndices = indices
+	}
+	if len(m.CorrectOptionIndices) > 1 {
+		m.IsMultiSelect = true
+	}
+	if err := m.ValidateOptions(); err != nil {
+		return err
+	}
+
+	slices.Sort(m.CorrectOptionIndices)
+	m.Answer = MCQAnswerText(m.Options, m.CorrectOptionIndices)
+	return nil
+}
+
+// normalizeOption lowercases an option and collapses its whitespace so that options are matched the way answers are graded
+func normalizeOption(option string) string {
+	return strings.Join(strings.Fields(strings.ToLower(option)), " ")
+}
+
+// MCQOptionIndices finds the positions of the options named by an answer text, one option per line.
+// Options spanning several lines are matched as a whole, the longest match wins.
+func MCQOptionIndices(options []string, answer string) ([]int32, error) {
+	optionIndex := make(map[string]int32, len(options))
+	for i, option := range options {
+		optionIndex[normalizeOption(option)] = int32(i)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(answer, MCQAnswerSeparator) {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("MCQ answer must name at least one of the options")
+	}
+
+	var indices []int32
+	for start := 0; start < len(lines); {
+		matched := false
+		for end := len(lines); end > start; end-- {
+			if index, exists := optionIndex[normalizeOption(strings.Join(lines[start:end], " "))]; exists {
+				if !slices.Contains(indices, index) {
+					indices = append(indices, index)
+				}
+				start, matched = end, true
+				break
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("MCQ answer %q must be one of the options", strings.TrimSpace(lines[start]))
+		}
+	}
+	return indices, nil
+}
+
+// MCQAnswerText is the answer text of the given correct options, one option per line
+func MCQAnswerText(options []string, indices []int32) string {
+	answers := make([]string, 0, len(indices))
+	for _, index := range indices {
+		if index >= 0 && int(index) < len(options) {
+			answers = append(answers, options[index])
+		}
+	}
+	return strings.Join(answers, MCQAnswerSeparator)
+}
+
+// BackfillMCQCorrectOptions fills the correct options of the MCQ questions stored before they were kept,
+// by matching their answer text with their options. Returns the number of questions left without a match.
+func BackfillMCQCorrectOptions(tx *gorm.DB) (int64, error) {
+	normalize := func(column string) string {
+		return fmt.Sprintf(`lower(regexp_replace(btrim(%s), '\s+', ' ', 'g'))`, column)
+	}
+	table := MCQQuestion{}.TableName()
+
+	// Written straight to the table, the content doesn't change so no revision is recorded
+	if err := tx.Exec(fmt.Sprintf(`
+		UPDATE %s AS q SET correct_option_indices = ARRAY(
+			SELECT (o.position - 1)::integer
+			FROM unnest(q.options) WITH ORDINALITY AS o(option, position)
+			WHERE %s = %s
+			ORDER BY o.position
+			LIMIT 1
+		)
+		WHERE cardinality(q.correct_option_indices) = 0`,
+		table, normalize("o.option"), normalize("q.answer"),
+	)).Error; err != nil {
+		return 0, fmt.Errorf("failed to backfill the correct MCQ options: %w", err)
+	}
+
+	var unmatched int64
+	if err := tx.Table(table).Where("cardinality(correct_option_indices) = 0").Count(&unmatched).Error; err != nil {
+		return 0, fmt.Errorf("failed to count the MCQ questions without a correct option: %w", err)
+	}
+	return unmatched, nil
+}
+
+func (q MCQQuestion) RevisionSnapshot() QuestionSnapshot {
+	return QuestionSnapshot{
+		QuestionText:   q.QuestionText,
+		Answer:         q.Answer,
+		Options:        q.Options,
+		CorrectOptions: q.CorrectOptionIndices,
+		MultiSelect:    q.IsMultiSelect,
+		Explanation:    q.Explanation,
+	}
+}
+
+// ApplySnapshot brings back the content of a snapshot, the correct options of the snapshots taken before
+// they were kept are resolved from the answer text on save
+func (q *MCQQuestion) ApplySnapshot(snapshot QuestionSnapshot) {
+	q.QuestionText, q.Answer, q.Options, q.Explanation = snapshot.QuestionText, snapshot.Answer, snapshot.Options, snapshot.Explanation
+	q.CorrectOptionIndices, q.IsMultiSelect = snapshot.CorrectOptions, snapshot.MultiSelect
+}
+
+// AfterCreate and AfterUpdate record the revisions of the question
+func (q *MCQQuestion) AfterCreate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &MCQQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeCreate)
+}
+
+func (q *MCQQuestion) AfterUpdate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &MCQQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeUpdate)
+}
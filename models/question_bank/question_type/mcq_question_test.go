@@ -0,0 +1,111 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMCQOptionIndices(t *testing.T) {
+	options := []string{"Paris", "New  Delhi", "A line\nspanning two", "A line"}
+
+	tests := []struct {
+		name    string
+		answer  string
+		want    []int32
+		wantErr bool
+	}{
+		{"single option", "Paris", []int32{0}, false},
+		{"matched like graded answers", " new delhi ", []int32{1}, false},
+		{"one option per line", "Paris\nNew Delhi", []int32{0, 1}, false},
+		{"blank lines are ignored", "\nParis\n\n", []int32{0}, false},
+		{"an option named twice counts once", "Paris\nparis", []int32{0}, false},
+		{"multi-line option wins over its first line", "A line\nspanning two", []int32{2}, false},
+		{"shorter option alone", "A line\nParis", []int32{3, 0}, false},
+		{"unknown option", "Rome", nil, true},
+		{"empty answer", " \n ", nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := MCQOptionIndices(options, test.answer)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("MCQOptionIndices(%q) error = %v, want error %v", test.answer, err, test.wantErr)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("MCQOptionIndices(%q) = %v, want %v", test.answer, got, test.want)
+			}
+		})
+	}
+}
+
+func TestMCQResolveAnswer(t *testing.T) {
+	tests := []struct {
+		name            string
+		question        MCQQuestion
+		wantAnswer      string
+		wantMultiSelect bool
+		wantErr         string // Part of the error expected, none when empty
+	}{
+		{
+			name:       "single correct option",
+			question:   MCQQuestion{BaseQuestion: BaseQuestion{Answer: "paris"}, Options: []string{"Paris", "Rome"}},
+			wantAnswer: "Paris",
+		},
+		{
+			name:            "several correct options make it multi-select, in option order",
+			question:        MCQQuestion{BaseQuestion: BaseQuestion{Answer: "Rome\nParis"}, Options: []string{"Paris", "Rome", "Oslo"}},
+			wantAnswer:      "Paris\nRome",
+			wantMultiSelect: true,
+		},
+		{
+			name:       "correct options by index take precedence",
+			question:   MCQQuestion{BaseQuestion: BaseQuestion{Answer: "stale"}, Options: []string{"Paris", "Rome"}, CorrectOptionIndices: []int32{1}},
+			wantAnswer: "Rome",
+		},
+		{
+			name:     "too few options",
+			question: MCQQuestion{BaseQuestion: BaseQuestion{Answer: "Paris"}, Options: []string{"Paris"}},
+			wantErr:  "2 to 6 options",
+		},
+		{
+			name:     "too many options",
+			question: MCQQuestion{BaseQuestion: BaseQuestion{Answer: "a"}, Options: []string{"a", "b", "c", "d", "e", "f", "g"}},
+			wantErr:  "2 to 6 options",
+		},
+		{
+			name:     "duplicate options",
+			question: MCQQuestion{BaseQuestion: BaseQuestion{Answer: "Paris"}, Options: []string{"Paris", " paris"}},
+			wantErr:  "given twice",
+		},
+		{
+			name:     "correct option out of range",
+			question: MCQQuestion{Options: []string{"Paris", "Rome"}, CorrectOptionIndices: []int32{2}},
+			wantErr:  "out of the 2 options",
+		},
+		{
+			name:            "several correct options by index make it multi-select",
+			question:        MCQQuestion{Options: []string{"Paris", "Rome"}, CorrectOptionIndices: []int32{0, 1}, IsMultiSelect: false},
+			wantAnswer:      "Paris\nRome",
+			wantMultiSelect: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			question := test.question
+			err := question.ResolveAnswer()
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("ResolveAnswer() error = %v, want one containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveAnswer() error = %v", err)
+			}
+			if question.Answer != test.wantAnswer || question.IsMultiSelect != test.wantMultiSelect {
+				t.Errorf("ResolveAnswer() = %q (multi-select %v), want %q (multi-select %v)",
+					question.Answer, question.IsMultiSelect, test.wantAnswer, test.wantMultiSelect)
+			}
+		})
+	}
+}
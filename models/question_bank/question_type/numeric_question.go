@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"server/utils"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// NumericQuestion extends BaseQuestion for questions answered with a number.
+// Answer keeps the number as written ("1/6", "16.67%"), Value is its canonical value the answers are graded against.
+type NumericQuestion struct {
+	BaseQuestion                // Embedding common fields
+	Explanation         string  `gorm:"type:text;default:''" json:"explanation,omitempty" bson:"explanation,omitempty"`
+	Value               float64 `gorm:"type:double precision;not null" json:"value" bson:"value"`
+	Tolerance           float64 `gorm:"type:double precision;not null;default:0;check:tolerance >= 0" json:"tolerance" bson:"tolerance"` // Absolute, or a fraction of Value when IsRelativeTolerance is set
+	IsRelativeTolerance bool    `gorm:"not null;default:false" json:"isRelativeTolerance" bson:"isRelativeTolerance"`
+	Unit                string  `gorm:"type:varchar(32);not null;default:''" json:"unit,omitempty" bson:"unit,omitempty"` // e.g. "km/h", answers may be followed by it
+}
+
+func (NumericQuestion) TableName() string {
+	return "question_schema.num_questions"
+}
+
+// BeforeSave is a GORM hook that computes the canonical value of the answer before saving
+func (q *NumericQuestion) BeforeSave(tx *gorm.DB) error {
+	return q.ResolveAnswer()
+}
+
+// ResolveAnswer validates the answer, tolerance and unit of the question and computes the canonical value of the answer
+func (q *NumericQuestion) ResolveAnswer() error {
+	q.Unit = strings.TrimSpace(q.Unit)
+	if q.Unit == "%" {
+		return errors.New("percentages are written in the answer, not as the unit")
+	}
+	if q.Tolerance < 0 {
+		return fmt.Errorf("tolerance can't be negative, got %g", q.Tolerance)
+	}
+
+	value, _, err := utils.ParseNumericAnswer(q.Answer, q.Unit)
+	if err != nil {
+		return fmt.Errorf("numeric answer: %w", err)
+	}
+	q.Value = value
+	return nil
+}
+
+// AnswerKey is what the answers to the question are graded against
+func (q NumericQuestion) AnswerKey() utils.NumericAnswerKey {
+	return NumericAnswerKey(q.Answer, q.Tolerance, q.IsRelativeTolerance, q.Unit)
+}
+
+// NumericAnswerKey builds the answer key of a numeric question from its stored answer, used for the served revisions too
+func NumericAnswerKey(answer string, tolerance float64, relative bool, unit string) utils.NumericAnswerKey {
+	value, percent, _ := utils.ParseNumericAnswer(answer, unit)
+	return utils.NumericAnswerKey{
+		Value:             value,
+		Tolerance:         tolerance,
+		RelativeTolerance: relative,
+		Unit:              unit,
+		Percent:           percent,
+	}
+}
+
+func (q NumericQuestion) RevisionSnapshot() QuestionSnapshot {
+	return QuestionSnapshot{
+		QuestionText:      q.QuestionText,
+		Answer:            q.Answer,
+		Tolerance:         q.Tolerance,
+		RelativeTolerance: q.IsRelativeTolerance,
+		Unit:              q.Unit,
+		Explanation:       q.Explanation,
+	}
+}
+
+func (q *NumericQuestion) ApplySnapshot(snapshot QuestionSnapshot) {
+	q.QuestionText, q.Answer, q.Explanation = snapshot.QuestionText, snapshot.Answer, snapshot.Explanation
+	q.Tolerance, q.IsRelativeTolerance, q.Unit = snapshot.Tolerance, snapshot.RelativeTolerance, snapshot.Unit
+}
+
+// AfterCreate and AfterUpdate record the revisions of the question
+func (q *NumericQuestion) AfterCreate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &NumericQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeCreate)
+}
+
+func (q *NumericQuestion) AfterUpdate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &NumericQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeUpdate)
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// Bounds on the number of items of an ordering question
+const (
+	MinSequenceItems = 2
+	MaxSequenceItems = 10
+)
+
+// ParseSequence reads the items of an ordering question from its answer text, one item per line in their correct order
+func ParseSequence(answer string) []string {
+	var items []string
+	for _, line := range strings.Split(answer, "\n") {
+		if item := strings.TrimSpace(line); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// SequenceText is the answer text of the items in the given order, one item per line.
+// order holds the positions of the items, the positions that don't exist are left out.
+func SequenceText(items []string, order []int32) string {
+	lines := make([]string, 0, len(order))
+	for _, position := range order {
+		if position >= 0 && int(position) < len(items) {
+			lines = append(lines, items[position])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// OrderingQuestion extends BaseQuestion for questions that arrange items (sentences, steps, events) in order.
+// Sequence holds the items in their correct order and is shuffled when served, Answer keeps it as text (one item per line).
+type OrderingQuestion struct {
+	BaseQuestion                // Embedding common fields
+	Explanation  string         `gorm:"type:text;default:''" json:"explanation,omitempty" bson:"explanation,omitempty"`
+	Sequence     pq.StringArray `gorm:"type:text[];not null;default:'{}'" json:"sequence" bson:"sequence"`
+}
+
+func (OrderingQuestion) TableName() string {
+	return "question_schema.ord_questions"
+}
+
+// BeforeSave is a GORM hook that validates the sequence of the ordering question before saving
+func (q *OrderingQuestion) BeforeSave(tx *gorm.DB) error {
+	return q.ResolveAnswer()
+}
+
+// ResolveAnswer reads the sequence from the answer text when it isn't set, validates it and rewrites Answer from it
+func (q *OrderingQuestion) ResolveAnswer() error {
+	if len(q.Sequence) == 0 {
+		q.Sequence = ParseSequence(q.Answer)
+	}
+	if err := q.ValidateSequence(); err != nil {
+		return err
+	}
+
+	q.Answer = SequenceText(q.Sequence, identityArrangement(len(q.Sequence)))
+	return nil
+}
+
+// ValidateSequence checks the number of items and that every item is given once
+func (q *OrderingQuestion) ValidateSequence() error {
+	if len(q.Sequence) < MinSequenceItems || len(q.Sequence) > MaxSequenceItems {
+		return fmt.Errorf("ordering questions must have %d to %d items, got %d", MinSequenceItems, MaxSequenceItems, len(q.Sequence))
+	}
+
+	seen := make(map[string]bool, len(q.Sequence))
+	for i := range q.Sequence {
+		q.Sequence[i] = strings.TrimSpace(q.Sequence[i])
+		item := q.Sequence[i]
+		if item == "" {
+			return errors.New("ordering items can't be empty")
+		}
+		if strings.Contains(item, "\n") {
+			return errors.New("ordering items must fit on one line")
+		}
+		if seen[normalizeOption(item)] {
+			return fmt.Errorf("ordering item %q is given twice", item)
+		}
+		seen[normalizeOption(item)] = true
+	}
+	return nil
+}
+
+func (q OrderingQuestion) RevisionSnapshot() QuestionSnapshot {
+	return QuestionSnapshot{
+		QuestionText: q.QuestionText,
+		Answer:       q.Answer,
+		Sequence:     q.Sequence,
+		Explanation:  q.Explanation,
+	}
+}
+
+func (q *OrderingQuestion) ApplySnapshot(snapshot QuestionSnapshot) {
+	q.QuestionText, q.Answer, q.Explanation = snapshot.QuestionText, snapshot.Answer, snapshot.Explanation
+	q.Sequence = snapshot.Sequence
+}
+
+// AfterCreate and AfterUpdate record the revisions of the question
+func (q *OrderingQuestion) AfterCreate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &OrderingQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeCreate)
+}
+
+func (q *OrderingQuestion) AfterUpdate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &OrderingQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeUpdate)
+}
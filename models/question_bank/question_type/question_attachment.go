@@ -0,0 +1,86 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// MaxAttachmentBytes is the largest image a question or option can carry
+const MaxAttachmentBytes = 5 << 20
+
+// MaxQuestionAttachments bounds the images of a question, its options' included
+const MaxQuestionAttachments = 10
+
+// attachmentExtensions are the image types accepted as attachments, by content type. SVG is left out
+// since it can carry scripts.
+var attachmentExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// DetectAttachmentType sniffs the content type of an attachment from its first bytes,
+// the name or type given by the uploader isn't trusted
+func DetectAttachmentType(content []byte) (string, error) {
+	contentType := http.DetectContentType(content)
+	if _, allowed := attachmentExtensions[contentType]; !allowed {
+		return "", fmt.Errorf("attachments must be PNG, JPEG, GIF or WebP images, got %s", contentType)
+	}
+	return contentType, nil
+}
+
+// ReadAttachment reads an attachment up to its size limit and detects its type
+func ReadAttachment(file io.Reader) (content []byte, contentType string, err error) {
+	content, err = io.ReadAll(io.LimitReader(file, MaxAttachmentBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read attachment: %w", err)
+	}
+	if len(content) == 0 {
+		return nil, "", errors.New("attachment is empty")
+	}
+	if len(content) > MaxAttachmentBytes {
+		return nil, "", fmt.Errorf("attachments can't be larger than %d MB", MaxAttachmentBytes>>20)
+	}
+
+	contentType, err = DetectAttachmentType(content)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, contentType, nil
+}
+
+// NewAttachmentStorageKey returns a fresh key to store an attachment of the question under
+func NewAttachmentStorageKey(formatID, questionID uint32, contentType string) (string, error) {
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate attachment key: %w", err)
+	}
+	return fmt.Sprintf("question-attachments/%d/%d/%s%s", formatID, questionID, hex.EncodeToString(suffix), attachmentExtensions[contentType]), nil
+}
+
+// QuestionAttachment is an image shown with a question (charts, figures) or with one of the options of an MCQ
+// question. The file itself is kept in the cloud storage and served through short-lived URLs. The question
+// tables can't all be referenced by a foreign key, so the attachments of deleted questions are removed along with them.
+type QuestionAttachment struct {
+	AttachmentID     uint32    `gorm:"primaryKey;autoIncrement" json:"attachmentID" bson:"attachmentID"`
+	QuestionFormatID uint32    `gorm:"not null;index:idx_question_attachment_question,priority:1" json:"formatID" bson:"formatID"`
+	QuestionID       uint32    `gorm:"not null;index:idx_question_attachment_question,priority:2" json:"questionID" bson:"questionID"`
+	OptionIndex      *int32    `gorm:"check:option_index >= 0" json:"optionIndex,omitempty" bson:"optionIndex,omitempty"` // Zero-based MCQ option the image belongs to, the question itself when nil
+	StorageKey       string    `gorm:"type:varchar(255);not null;uniqueIndex" json:"-" bson:"-"`
+	FileName         string    `gorm:"type:varchar(255);not null" json:"fileName" bson:"fileName"` // Name of the uploaded file
+	ContentType      string    `gorm:"type:varchar(50);not null" json:"contentType" bson:"contentType"`
+	SizeBytes        int64     `gorm:"not null" json:"sizeBytes" bson:"sizeBytes"`
+	AltText          string    `gorm:"type:varchar(500);not null;default:''" json:"altText" bson:"altText"` // Description read out by screen readers
+	UploadedBy       string    `gorm:"type:varchar(100);not null;default:''" json:"uploadedBy" bson:"uploadedBy"`
+	CreatedAt        time.Time `gorm:"not null;autoCreateTime" json:"createdAt" bson:"createdAt"`
+}
+
+func (QuestionAttachment) TableName() string {
+	return "question_schema.question_attachments"
+}
@@ -0,0 +1,54 @@
+package models
+
+import "fmt"
+
+// QuestionFormats lists every format a format node (QuestionFormatTable) can have
+var QuestionFormats = []string{"MCQ", "TF", "FIB", "TXT", "NUM", "CODE", "MTC", "ORD"}
+
+// QuestionTableForFormat returns the name of the table that stores the questions of the given format
+func QuestionTableForFormat(format string) (string, error) {
+	switch format {
+	case "MCQ":
+		return MCQQuestion{}.TableName(), nil
+	case "TF":
+		return TrueFalseQuestion{}.TableName(), nil
+	case "FIB":
+		return FillInTheBlankQuestion{}.TableName(), nil
+	case "TXT":
+		return TextBasedQuestion{}.TableName(), nil
+	case "NUM":
+		return NumericQuestion{}.TableName(), nil
+	case "CODE":
+		return CodingQuestion{}.TableName(), nil
+	case "MTC":
+		return MatchingQuestion{}.TableName(), nil
+	case "ORD":
+		return OrderingQuestion{}.TableName(), nil
+	default:
+		return "", fmt.Errorf("invalid question format: %s", format)
+	}
+}
+
+// NewQuestionModel returns an empty model of the given format, to read or write a question of any format
+func NewQuestionModel(format string) (RevisionedQuestion, error) {
+	switch format {
+	case "MCQ":
+		return &MCQQuestion{}, nil
+	case "TF":
+		return &TrueFalseQuestion{}, nil
+	case "FIB":
+		return &FillInTheBlankQuestion{}, nil
+	case "TXT":
+		return &TextBasedQuestion{}, nil
+	case "NUM":
+		return &NumericQuestion{}, nil
+	case "CODE":
+		return &CodingQuestion{}, nil
+	case "MTC":
+		return &MatchingQuestion{}, nil
+	case "ORD":
+		return &OrderingQuestion{}, nil
+	default:
+		return nil, fmt.Errorf("invalid question format: %s", format)
+	}
+}
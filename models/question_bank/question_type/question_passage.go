@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// MaxPassageQuestions is the largest group of questions a passage can own
+const MaxPassageQuestions = 10
+
+// QuestionPassage is a reading passage (or any shared context, e.g. a data table) followed by a group of
+// questions. The passage is served once with its questions, which no longer have to repeat it in their text.
+type QuestionPassage struct {
+	PassageID uint32    `gorm:"primaryKey;autoIncrement" json:"passageID" bson:"passageID"`
+	Title     string    `gorm:"type:varchar(200);not null;default:''" json:"title" bson:"title"`
+	Body      string    `gorm:"type:text;not null" json:"body" bson:"body"`
+	CreatedAt time.Time `gorm:"not null;autoCreateTime" json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `gorm:"not null;autoUpdateTime" json:"updatedAt" bson:"updatedAt"`
+}
+
+func (QuestionPassage) TableName() string {
+	return "question_schema.question_passages"
+}
+
+// QuestionPassageItem places a question of any format in the group of a passage. A question belongs to one
+// passage at most. The question tables can't all be referenced by a foreign key, so the items of deleted
+// questions are removed along with them.
+type QuestionPassageItem struct {
+	QuestionFormatID uint32 `gorm:"primaryKey;autoIncrement:false" json:"formatID" bson:"formatID"`
+	QuestionID       uint32 `gorm:"primaryKey;autoIncrement:false" json:"questionID" bson:"questionID"`
+	PassageID        uint32 `gorm:"not null;uniqueIndex:idx_question_passage_item_position,priority:1" json:"passageID" bson:"passageID"`
+	Position         int    `gorm:"not null;uniqueIndex:idx_question_passage_item_position,priority:2;check:position > 0" json:"position" bson:"position"` // Order of the question in the group, starting at 1
+
+	// Foreign key relationships
+	Passage QuestionPassage `gorm:"foreignKey:PassageID;references:PassageID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-" bson:"-"`
+}
+
+func (QuestionPassageItem) TableName() string {
+	return "question_schema.question_passage_items"
+}
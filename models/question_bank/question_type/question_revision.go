@@ -0,0 +1,276 @@
+package models
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Revision change types
+const (
+	RevisionChangeCreate   = "CREATE"   // Question inserted
+	RevisionChangeUpdate   = "UPDATE"   // Question edited
+	RevisionChangeRestore  = "RESTORE"  // Question brought back to an older revision
+	RevisionChangeBaseline = "BASELINE" // Question written before revisions were tracked, recorded as found
+)
+
+// QuestionSnapshot is the editable content of a question of any format at a point in time
+type QuestionSnapshot struct {
+	QuestionText      string        `json:"questionText"`
+	Answer            string        `json:"answer"`
+	Options           []string      `json:"options,omitempty"`
+	CorrectOptions    []int32       `json:"correctOptions,omitempty"`    // MCQ only, missing from the snapshots taken before they were kept
+	MultiSelect       bool          `json:"multiSelect,omitempty"`       // MCQ only
+	Tolerance         float64       `json:"tolerance,omitempty"`         // NUM only
+	RelativeTolerance bool          `json:"relativeTolerance,omitempty"` // NUM only
+	Unit              string        `json:"unit,omitempty"`              // NUM only
+	Language          string        `json:"language,omitempty"`          // CODE only, language of the reference solution
+	StarterCode       StarterCode   `json:"starterCode,omitempty"`       // CODE only
+	TestCases         CodeTestCases `json:"testCases,omitempty"`         // CODE only, hidden ones included
+	TimeLimitMs       int           `json:"timeLimitMs,omitempty"`       // CODE only
+	MemoryLimitMB     int           `json:"memoryLimitMB,omitempty"`     // CODE only
+	Pairs             MatchPairs    `json:"pairs,omitempty"`             // MTC only
+	Sequence          []string      `json:"sequence,omitempty"`          // ORD only, in the correct order
+	Explanation       string        `json:"explanation,omitempty"`
+}
+
+// Value stores the snapshot as JSON
+func (s QuestionSnapshot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan reads the snapshot from its JSON column
+func (s *QuestionSnapshot) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("unsupported question snapshot type %T", value)
+	}
+}
+
+// Equal reports whether two snapshots hold the same content
+func (s QuestionSnapshot) Equal(other QuestionSnapshot) bool {
+	return s.QuestionText == other.QuestionText &&
+		s.Answer == other.Answer &&
+		s.Explanation == other.Explanation &&
+		s.MultiSelect == other.MultiSelect &&
+		s.Tolerance == other.Tolerance &&
+		s.RelativeTolerance == other.RelativeTolerance &&
+		s.Unit == other.Unit &&
+		s.Language == other.Language &&
+		s.TimeLimitMs == other.TimeLimitMs &&
+		s.MemoryLimitMB == other.MemoryLimitMB &&
+		slices.Equal(s.Options, other.Options) &&
+		slices.Equal(s.CorrectOptions, other.CorrectOptions) &&
+		maps.Equal(s.StarterCode, other.StarterCode) &&
+		slices.Equal(s.TestCases, other.TestCases) &&
+		slices.Equal(s.Pairs, other.Pairs) &&
+		slices.Equal(s.Sequence, other.Sequence)
+}
+
+// QuestionRevision stores every version of a question along with who wrote it and when.
+// Revisions outlive the question they belong to so that past sessions keep what they were served.
+type QuestionRevision struct {
+	RevisionID       uint32 `gorm:"primaryKey;autoIncrement" json:"revisionID" bson:"revisionID"`
+	QuestionFormatID uint32 `gorm:"not null;uniqueIndex:idx_question_revision_number,priority:1" json:"formatID" bson:"formatID"`
+	QuestionID       uint32 `gorm:"not null;uniqueIndex:idx_question_revision_number,priority:2" json:"questionID" bson:"questionID"`
+	RevisionNumber   int    `gorm:"not null;uniqueIndex:idx_question_revision_number,priority:3;check:revision_number > 0" json:"revisionNumber" bson:"revisionNumber"` // Starts at 1 for every question
+
+	Snapshot QuestionSnapshot `gorm:"type:jsonb;not null" json:"snapshot" bson:"snapshot"`
+
+	ChangeType   string    `gorm:"type:varchar(10);not null;check:change_type IN ('CREATE', 'UPDATE', 'RESTORE', 'BASELINE')" json:"changeType" bson:"changeType"`
+	ChangedBy    string    `gorm:"type:varchar(255);not null;default:''" json:"changedBy" bson:"changedBy"` // Username of the author, empty when unknown
+	RestoredFrom *int      `json:"restoredFrom,omitempty" bson:"restoredFrom,omitempty"`                    // Revision number brought back by a RESTORE
+	CreatedAt    time.Time `gorm:"not null;autoCreateTime" json:"createdAt" bson:"createdAt"`
+}
+
+func (QuestionRevision) TableName() string {
+	return "question_schema.question_revisions"
+}
+
+// RevisionedQuestion is implemented by the models of every question format
+type RevisionedQuestion interface {
+	RevisionKey() (formatID, questionID uint32)
+	QuestionStatus() string
+	RevisionSnapshot() QuestionSnapshot
+	ApplySnapshot(snapshot QuestionSnapshot)
+}
+
+// RevisionKey returns the composite key of the question
+func (b BaseQuestion) RevisionKey() (formatID, questionID uint32) {
+	return b.QuestionFormatID, b.QuestionID
+}
+
+// revisionContextKey holds the revisionChange of a write in the context of the statement
+type revisionContextKey struct{}
+
+type revisionChange struct {
+	ChangedBy    string
+	RestoredFrom *int
+}
+
+// WithRevisionAuthor tags the question writes made with the context with the username of their author
+func WithRevisionAuthor(ctx context.Context, changedBy string) context.Context {
+	return context.WithValue(ctx, revisionContextKey{}, revisionChange{ChangedBy: changedBy})
+}
+
+// RevisionAuthor returns the username the question writes made with the context are tagged with, empty when untagged
+func RevisionAuthor(ctx context.Context) string {
+	change, _ := ctx.Value(revisionContextKey{}).(revisionChange)
+	return change.ChangedBy
+}
+
+// WithRevisionRestore marks the question writes made with the context as a restore of the given revision
+func WithRevisionRestore(ctx context.Context, changedBy string, revisionNumber int) context.Context {
+	return context.WithValue(ctx, revisionContextKey{}, revisionChange{ChangedBy: changedBy, RestoredFrom: &revisionNumber})
+}
+
+// recordQuestionRevision reads the question back (the written model may hold only the updated columns)
+// and records it as a new revision, unless it is unchanged since the latest one.
+// The write that triggers it holds the lock of the question row, so revision numbers don't race. BASELINE
+// revisions are recorded without a write (LatestQuestionRevisionIDs), two of them racing for the first revision
+// number keep the one inserted first.
+func recordQuestionRevision(tx *gorm.DB, question RevisionedQuestion, formatID, questionID uint32, changeType string) error {
+	if formatID == 0 || questionID == 0 {
+		return nil // Bulk writes without a key, nothing to attach the revision to
+	}
+
+	db := tx.Session(&gorm.Session{NewDB: true, SkipHooks: true})
+	if err := db.Where("question_format_id = ? AND question_id = ?", formatID, questionID).Take(question).Error; err != nil {
+		return fmt.Errorf("failed to read question for its revision: %w", err)
+	}
+	snapshot := question.RevisionSnapshot()
+
+	var latest QuestionRevision
+	err := db.Where("question_format_id = ? AND question_id = ?", formatID, questionID).
+		Order("revision_number DESC").
+		Take(&latest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to fetch latest question revision: %w", err)
+	}
+	if latest.RevisionID != 0 && latest.Snapshot.Equal(snapshot) {
+		return nil
+	}
+
+	revision := QuestionRevision{
+		QuestionFormatID: formatID,
+		QuestionID:       questionID,
+		RevisionNumber:   latest.RevisionNumber + 1,
+		Snapshot:         snapshot,
+		ChangeType:       changeType,
+	}
+	if change, ok := tx.Statement.Context.Value(revisionContextKey{}).(revisionChange); ok {
+		revision.ChangedBy = change.ChangedBy
+		if change.RestoredFrom != nil && changeType == RevisionChangeUpdate {
+			revision.ChangeType = RevisionChangeRestore
+			revision.RestoredFrom = change.RestoredFrom
+		}
+	}
+
+	create := db
+	if changeType == RevisionChangeBaseline {
+		create = db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "question_format_id"}, {Name: "question_id"}, {Name: "revision_number"}},
+			DoNothing: true,
+		})
+	}
+	if err := create.Create(&revision).Error; err != nil {
+		return fmt.Errorf("failed to record question revision: %w", err)
+	}
+	return nil
+}
+
+// LatestQuestionRevisionIDs returns the ID of the latest revision of each of the given questions of a format node.
+// Questions written before revisions were tracked get a BASELINE revision of their current content, the one
+// recorded by a concurrent call when it won the race.
+func LatestQuestionRevisionIDs(tx *gorm.DB, format string, formatID uint32, questionIDs []uint32) (map[uint32]uint32, error) {
+	revisionIDs := make(map[uint32]uint32, len(questionIDs))
+	if len(questionIDs) == 0 {
+		return revisionIDs, nil
+	}
+
+	var latest []QuestionRevision
+	if err := tx.Raw(fmt.Sprintf(
+		"SELECT DISTINCT ON (question_id) question_id, revision_id FROM %s WHERE question_format_id = ? AND question_id IN ? ORDER BY question_id, revision_number DESC",
+		QuestionRevision{}.TableName(),
+	), formatID, questionIDs).Scan(&latest).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch question revisions: %w", err)
+	}
+	for _, revision := range latest {
+		revisionIDs[revision.QuestionID] = revision.RevisionID
+	}
+
+	for _, questionID := range questionIDs {
+		if _, exists := revisionIDs[questionID]; exists {
+			continue
+		}
+		question, err := NewQuestionModel(format)
+		if err != nil {
+			return nil, err
+		}
+		if err := recordQuestionRevision(tx, question, formatID, questionID, RevisionChangeBaseline); err != nil {
+			return nil, err
+		}
+
+		var baseline QuestionRevision
+		if err := tx.Select("revision_id").
+			Where("question_format_id = ? AND question_id = ?", formatID, questionID).
+			Order("revision_number DESC").
+			Take(&baseline).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch baseline question revision: %w", err)
+		}
+		revisionIDs[questionID] = baseline.RevisionID
+	}
+	return revisionIDs, nil
+}
+
+// BackfillBaselineRevisions records a BASELINE revision for every question written before revisions were tracked,
+// so that serving them doesn't have to. It returns the number of questions backfilled.
+func BackfillBaselineRevisions(tx *gorm.DB) (int, error) {
+	backfilled := 0
+	for _, format := range QuestionFormats {
+		questionTable, err := QuestionTableForFormat(format)
+		if err != nil {
+			return backfilled, err
+		}
+
+		var keys []struct {
+			QuestionFormatID uint32
+			QuestionID       uint32
+		}
+		if err := tx.Raw(fmt.Sprintf(`
+			SELECT q.question_format_id, q.question_id
+			FROM %s q
+			WHERE NOT EXISTS (
+				SELECT 1 FROM %s r WHERE r.question_format_id = q.question_format_id AND r.question_id = q.question_id
+			)
+			ORDER BY q.question_format_id, q.question_id`,
+			questionTable, QuestionRevision{}.TableName(),
+		)).Scan(&keys).Error; err != nil {
+			return backfilled, fmt.Errorf("failed to fetch %s questions without revisions: %w", format, err)
+		}
+
+		questionIDs := make(map[uint32][]uint32)
+		for _, key := range keys {
+			questionIDs[key.QuestionFormatID] = append(questionIDs[key.QuestionFormatID], key.QuestionID)
+		}
+		for formatID, ids := range questionIDs {
+			if _, err := LatestQuestionRevisionIDs(tx, format, formatID, ids); err != nil {
+				return backfilled, err
+			}
+		}
+		backfilled += len(keys)
+	}
+	return backfilled, nil
+}
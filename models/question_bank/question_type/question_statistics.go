@@ -0,0 +1,125 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Flags raised on the statistics of a question, for the admins to review it
+const (
+	StatisticsFlagDifficultyMismatch = "DIFFICULTY_MISMATCH" // Empirical difficulty differs from the difficulty node of the question
+	StatisticsFlagWeakDistractor     = "WEAK_DISTRACTOR"     // MCQ only, a wrong option almost nobody picks
+	StatisticsFlagLikelyWrongKey     = "LIKELY_WRONG_KEY"    // MCQ only, a wrong option picked more often than a correct one
+)
+
+// QuestionStatisticsFlags lists every flag the statistics of a question can raise
+var QuestionStatisticsFlags = []string{StatisticsFlagDifficultyMismatch, StatisticsFlagWeakDistractor, StatisticsFlagLikelyWrongKey}
+
+// Bounds of the empirical difficulty levels on the percent correct, EASY at or above EasyMinPercentCorrect,
+// HARD below HardMaxPercentCorrect and MEDIUM in between
+const (
+	EasyMinPercentCorrect = 70.0
+	HardMaxPercentCorrect = 40.0
+)
+
+// WeakDistractorMaxPercent is the share of the attempts under which a wrong MCQ option is a weak distractor
+const WeakDistractorMaxPercent = 5.0
+
+// QuestionStatistics holds the answer statistics of a question, computed periodically from the responses of the
+// submitted practice sessions. Only the responses to the latest revision of the question are counted, so an edit
+// starts the statistics over (the options may have moved). Rewritten whole on every computation.
+type QuestionStatistics struct {
+	QuestionFormatID uint32 `gorm:"primaryKey;autoIncrement:false" json:"formatID" bson:"formatID"`
+	QuestionID       uint32 `gorm:"primaryKey;autoIncrement:false" json:"questionID" bson:"questionID"`
+	RevisionID       uint32 `gorm:"not null" json:"revisionID" bson:"revisionID"` // Revision (QuestionRevision) the statistics cover
+
+	Attempts        int `gorm:"not null;default:0" json:"attempts" bson:"attempts"`               // Responses that answered the question
+	Skips           int `gorm:"not null;default:0" json:"skips" bson:"skips"`                     // Responses that left it unanswered
+	GradedAttempts  int `gorm:"not null;default:0" json:"gradedAttempts" bson:"gradedAttempts"`   // Attempts graded automatically, none for TXT
+	CorrectAttempts int `gorm:"not null;default:0" json:"correctAttempts" bson:"correctAttempts"` // Graded attempts with full credit
+
+	PercentCorrect    *float64 `json:"percentCorrect" bson:"percentCorrect"`       // Share of the graded attempts that are correct, NULL without any
+	MedianTimeSeconds *float64 `json:"medianTimeSeconds" bson:"medianTimeSeconds"` // Over the attempts whose time was reported
+
+	// OptionCounts = MCQ only, number of attempts that chose each option, by position. Empty for the other formats.
+	OptionCounts pq.Int64Array `gorm:"type:bigint[];not null;default:'{}'" json:"optionCounts,omitempty" bson:"optionCounts,omitempty"`
+
+	// EmpiricalDifficulty = Difficulty level matching the percent correct, empty below the minimum of graded attempts
+	EmpiricalDifficulty string         `gorm:"type:varchar(6);not null;default:''" json:"empiricalDifficulty" bson:"empiricalDifficulty"`
+	Flags               pq.StringArray `gorm:"type:text[];not null;default:'{}'" json:"flags" bson:"flags"`
+
+	ComputedAt time.Time `gorm:"type:timestamp with time zone;not null" json:"computedAt" bson:"computedAt"`
+}
+
+func (QuestionStatistics) TableName() string {
+	return "question_schema.question_statistics"
+}
+
+// EmpiricalDifficulty returns the difficulty level matching the percent correct of a question
+func EmpiricalDifficulty(percentCorrect float64) string {
+	switch {
+	case percentCorrect >= EasyMinPercentCorrect:
+		return "EASY"
+	case percentCorrect < HardMaxPercentCorrect:
+		return "HARD"
+	default:
+		return "MEDIUM"
+	}
+}
+
+// Evaluate derives the percent correct, the empirical difficulty and the flags of the statistics from the counts.
+// difficultyLevel is the one of the node of the question and correctOptions the answer key of an MCQ question.
+// Questions with fewer than minAttempts attempts are neither calibrated nor flagged, their numbers are noise.
+func (s *QuestionStatistics) Evaluate(difficultyLevel string, correctOptions []int32, minAttempts int) {
+	s.PercentCorrect = nil
+	s.EmpiricalDifficulty = ""
+	s.Flags = pq.StringArray{}
+
+	if s.GradedAttempts > 0 {
+		percentCorrect := 100 * float64(s.CorrectAttempts) / float64(s.GradedAttempts)
+		s.PercentCorrect = &percentCorrect
+	}
+	if s.Attempts < minAttempts {
+		return
+	}
+
+	if s.PercentCorrect != nil && s.GradedAttempts >= minAttempts {
+		s.EmpiricalDifficulty = EmpiricalDifficulty(*s.PercentCorrect)
+		if s.EmpiricalDifficulty != difficultyLevel {
+			s.Flags = append(s.Flags, StatisticsFlagDifficultyMismatch)
+		}
+	}
+
+	if len(s.OptionCounts) == 0 || len(correctOptions) == 0 {
+		return
+	}
+
+	// The least chosen correct option is the one a popular distractor competes with
+	leastChosenKey := int64(-1)
+	for _, index := range correctOptions {
+		if int(index) < len(s.OptionCounts) && (leastChosenKey < 0 || s.OptionCounts[index] < leastChosenKey) {
+			leastChosenKey = s.OptionCounts[index]
+		}
+	}
+
+	weakDistractor, likelyWrongKey := false, false
+	for index, count := range s.OptionCounts {
+		if slices.Contains(correctOptions, int32(index)) {
+			continue
+		}
+		if 100*float64(count)/float64(s.Attempts) < WeakDistractorMaxPercent {
+			weakDistractor = true
+		}
+		if leastChosenKey >= 0 && count > leastChosenKey {
+			likelyWrongKey = true
+		}
+	}
+	if weakDistractor {
+		s.Flags = append(s.Flags, StatisticsFlagWeakDistractor)
+	}
+	if likelyWrongKey {
+		s.Flags = append(s.Flags, StatisticsFlagLikelyWrongKey)
+	}
+}
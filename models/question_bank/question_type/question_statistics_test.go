@@ -0,0 +1,151 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestEmpiricalDifficulty(t *testing.T) {
+	tests := []struct {
+		percentCorrect float64
+		want           string
+	}{
+		{100, "EASY"},
+		{EasyMinPercentCorrect, "EASY"},
+		{69.9, "MEDIUM"},
+		{HardMaxPercentCorrect, "MEDIUM"},
+		{39.9, "HARD"},
+		{0, "HARD"},
+	}
+	for _, test := range tests {
+		if got := EmpiricalDifficulty(test.percentCorrect); got != test.want {
+			t.Errorf("EmpiricalDifficulty(%v) = %q, want %q", test.percentCorrect, got, test.want)
+		}
+	}
+}
+
+func TestQuestionStatisticsEvaluate(t *testing.T) {
+	percent := func(value float64) *float64 { return &value }
+
+	tests := []struct {
+		name            string
+		statistics      QuestionStatistics
+		difficultyLevel string
+		correctOptions  []int32
+		wantPercent     *float64
+		wantDifficulty  string
+		wantFlags       pq.StringArray
+	}{
+		{
+			name:            "no attempts",
+			statistics:      QuestionStatistics{},
+			difficultyLevel: "EASY",
+			wantFlags:       pq.StringArray{},
+		},
+		{
+			name:            "too few attempts are neither calibrated nor flagged",
+			statistics:      QuestionStatistics{Attempts: 5, GradedAttempts: 5, CorrectAttempts: 0, OptionCounts: pq.Int64Array{5, 0}},
+			difficultyLevel: "EASY",
+			correctOptions:  []int32{0},
+			wantPercent:     percent(0),
+			wantFlags:       pq.StringArray{},
+		},
+		{
+			name:            "difficulty matching the node",
+			statistics:      QuestionStatistics{Attempts: 10, GradedAttempts: 10, CorrectAttempts: 8},
+			difficultyLevel: "EASY",
+			wantPercent:     percent(80),
+			wantDifficulty:  "EASY",
+			wantFlags:       pq.StringArray{},
+		},
+		{
+			name:            "difficulty disagreeing with the node",
+			statistics:      QuestionStatistics{Attempts: 10, GradedAttempts: 10, CorrectAttempts: 2},
+			difficultyLevel: "EASY",
+			wantPercent:     percent(20),
+			wantDifficulty:  "HARD",
+			wantFlags:       pq.StringArray{StatisticsFlagDifficultyMismatch},
+		},
+		{
+			name:            "ungraded attempts aren't calibrated",
+			statistics:      QuestionStatistics{Attempts: 10},
+			difficultyLevel: "MEDIUM",
+			wantFlags:       pq.StringArray{},
+		},
+		{
+			name:            "too few graded attempts aren't calibrated",
+			statistics:      QuestionStatistics{Attempts: 10, GradedAttempts: 4, CorrectAttempts: 1},
+			difficultyLevel: "MEDIUM",
+			wantPercent:     percent(25),
+			wantFlags:       pq.StringArray{},
+		},
+		{
+			name:            "distractor almost nobody picks",
+			statistics:      QuestionStatistics{Attempts: 40, GradedAttempts: 40, CorrectAttempts: 20, OptionCounts: pq.Int64Array{20, 19, 1}},
+			difficultyLevel: "MEDIUM",
+			correctOptions:  []int32{0},
+			wantPercent:     percent(50),
+			wantDifficulty:  "MEDIUM",
+			wantFlags:       pq.StringArray{StatisticsFlagWeakDistractor},
+		},
+		{
+			name:            "wrong option picked more than the key",
+			statistics:      QuestionStatistics{Attempts: 20, GradedAttempts: 20, CorrectAttempts: 6, OptionCounts: pq.Int64Array{6, 10, 4}},
+			difficultyLevel: "HARD",
+			correctOptions:  []int32{0},
+			wantPercent:     percent(30),
+			wantDifficulty:  "HARD",
+			wantFlags:       pq.StringArray{StatisticsFlagLikelyWrongKey},
+		},
+		{
+			name:            "multi-select compares with the least chosen key",
+			statistics:      QuestionStatistics{Attempts: 20, GradedAttempts: 20, CorrectAttempts: 10, OptionCounts: pq.Int64Array{15, 8, 9, 2}},
+			difficultyLevel: "MEDIUM",
+			correctOptions:  []int32{0, 1},
+			wantPercent:     percent(50),
+			wantDifficulty:  "MEDIUM",
+			wantFlags:       pq.StringArray{StatisticsFlagLikelyWrongKey},
+		},
+		{
+			name:            "every flag at once",
+			statistics:      QuestionStatistics{Attempts: 40, GradedAttempts: 40, CorrectAttempts: 8, OptionCounts: pq.Int64Array{8, 31, 1}},
+			difficultyLevel: "EASY",
+			correctOptions:  []int32{0},
+			wantPercent:     percent(20),
+			wantDifficulty:  "HARD",
+			wantFlags:       pq.StringArray{StatisticsFlagDifficultyMismatch, StatisticsFlagWeakDistractor, StatisticsFlagLikelyWrongKey},
+		},
+		{
+			name:            "earlier results are cleared",
+			statistics:      QuestionStatistics{Attempts: 2, PercentCorrect: percent(90), EmpiricalDifficulty: "EASY", Flags: pq.StringArray{StatisticsFlagWeakDistractor}},
+			difficultyLevel: "EASY",
+			wantFlags:       pq.StringArray{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statistics := test.statistics
+			statistics.Evaluate(test.difficultyLevel, test.correctOptions, 10)
+
+			if !reflect.DeepEqual(statistics.PercentCorrect, test.wantPercent) {
+				t.Errorf("PercentCorrect = %v, want %v", derefPercent(statistics.PercentCorrect), derefPercent(test.wantPercent))
+			}
+			if statistics.EmpiricalDifficulty != test.wantDifficulty {
+				t.Errorf("EmpiricalDifficulty = %q, want %q", statistics.EmpiricalDifficulty, test.wantDifficulty)
+			}
+			if !reflect.DeepEqual(statistics.Flags, test.wantFlags) {
+				t.Errorf("Flags = %v, want %v", statistics.Flags, test.wantFlags)
+			}
+		})
+	}
+}
+
+// derefPercent prints a percent correct in the test failures, nil when there is none
+func derefPercent(percent *float64) interface{} {
+	if percent == nil {
+		return nil
+	}
+	return *percent
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Question lifecycle statuses. Questions are written as drafts, submitted for review by the volunteers
+// and published by the coordinators. Only published questions are served in practice sessions.
+const (
+	QuestionStatusDraft     = "DRAFT"
+	QuestionStatusInReview  = "IN_REVIEW"
+	QuestionStatusPublished = "PUBLISHED"
+	QuestionStatusRetired   = "RETIRED"
+)
+
+// QuestionStatuses lists every lifecycle status
+var QuestionStatuses = []string{QuestionStatusDraft, QuestionStatusInReview, QuestionStatusPublished, QuestionStatusRetired}
+
+// QuestionStatusAction is a step of the review workflow
+type QuestionStatusAction struct {
+	From         []string // Statuses the action applies to
+	To           string
+	RequiredRole string // Lowest role allowed to take the action
+}
+
+// QuestionStatusActions lists the review workflow steps by name
+var QuestionStatusActions = map[string]QuestionStatusAction{
+	"submit":  {From: []string{QuestionStatusDraft}, To: QuestionStatusInReview, RequiredRole: "volunteer"},
+	"approve": {From: []string{QuestionStatusInReview}, To: QuestionStatusPublished, RequiredRole: "coordinator"},
+	"reject":  {From: []string{QuestionStatusInReview}, To: QuestionStatusDraft, RequiredRole: "coordinator"},
+	"retire":  {From: []string{QuestionStatusPublished}, To: QuestionStatusRetired, RequiredRole: "coordinator"},
+	"reopen":  {From: []string{QuestionStatusRetired}, To: QuestionStatusDraft, RequiredRole: "coordinator"},
+}
+
+// AppliesTo reports whether the action can be taken on a question in the given status
+func (a QuestionStatusAction) AppliesTo(status string) bool {
+	return slices.Contains(a.From, status)
+}
+
+// QuestionStatusChange logs every step a question takes through the review workflow
+type QuestionStatusChange struct {
+	StatusChangeID   uint32    `gorm:"primaryKey;autoIncrement" json:"statusChangeID" bson:"statusChangeID"`
+	QuestionFormatID uint32    `gorm:"not null;index:idx_question_status_change" json:"formatID" bson:"formatID"`
+	QuestionID       uint32    `gorm:"not null;index:idx_question_status_change" json:"questionID" bson:"questionID"`
+	FromStatus       string    `gorm:"type:varchar(10);not null" json:"fromStatus" bson:"fromStatus"`
+	ToStatus         string    `gorm:"type:varchar(10);not null" json:"toStatus" bson:"toStatus"`
+	ChangedBy        string    `gorm:"type:varchar(255);not null;default:''" json:"changedBy" bson:"changedBy"`
+	Note             string    `gorm:"type:text;not null;default:''" json:"note,omitempty" bson:"note,omitempty"` // Reason given by the reviewer, e.g. why a question was rejected
+	CreatedAt        time.Time `gorm:"not null;autoCreateTime" json:"createdAt" bson:"createdAt"`
+}
+
+func (QuestionStatusChange) TableName() string {
+	return "question_schema.question_status_changes"
+}
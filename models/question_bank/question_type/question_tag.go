@@ -0,0 +1,104 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxTagNameLength is the longest tag name accepted
+const MaxTagNameLength = 64
+
+// QuestionTag is a free-form label that cuts across the question hierarchy, e.g. "TCS-PYQ" or "probability".
+// Names are unique regardless of case.
+type QuestionTag struct {
+	TagID     uint32    `gorm:"primaryKey;autoIncrement" json:"tagID" bson:"tagID"`
+	Name      string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_question_tag_name,expression:LOWER(name)" json:"name" bson:"name"`
+	CreatedAt time.Time `gorm:"not null;autoCreateTime" json:"createdAt" bson:"createdAt"`
+}
+
+func (QuestionTag) TableName() string {
+	return "question_schema.question_tags"
+}
+
+// QuestionTagLink tags a question of any format. The question tables can't all be referenced by a foreign key,
+// so the links of deleted questions are removed along with them.
+type QuestionTagLink struct {
+	TagID            uint32 `gorm:"primaryKey;autoIncrement:false" json:"tagID" bson:"tagID"`
+	QuestionFormatID uint32 `gorm:"primaryKey;autoIncrement:false;index:idx_question_tag_link_question" json:"formatID" bson:"formatID"`
+	QuestionID       uint32 `gorm:"primaryKey;autoIncrement:false;index:idx_question_tag_link_question" json:"questionID" bson:"questionID"`
+
+	// Foreign key relationships
+	Tag QuestionTag `gorm:"foreignKey:TagID;references:TagID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-" bson:"-"`
+}
+
+func (QuestionTagLink) TableName() string {
+	return "question_schema.question_tag_links"
+}
+
+// NormalizeTagName trims a tag name and collapses its inner spaces
+func NormalizeTagName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return "", errors.New("tag name can't be empty")
+	}
+	if len(name) > MaxTagNameLength {
+		return "", errors.New("tag name can't be longer than 64 characters")
+	}
+	return name, nil
+}
+
+// NormalizeTagNames normalizes the tag names, keeping the first spelling of the duplicates
+func NormalizeTagNames(names []string) ([]string, error) {
+	normalized := make([]string, 0, len(names))
+	for _, tag := range names {
+		name, err := NormalizeTagName(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.ContainsFunc(normalized, func(existing string) bool { return strings.EqualFold(existing, name) }) {
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized, nil
+}
+
+// FindOrCreateTags returns the tags with the given names (matched regardless of case), creating the missing ones
+func FindOrCreateTags(tx *gorm.DB, names []string) ([]QuestionTag, error) {
+	tags := make([]QuestionTag, 0, len(names))
+	for _, name := range names {
+		var tag QuestionTag
+		err := tx.Where("LOWER(name) = LOWER(?)", name).Take(&tag).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			tag = QuestionTag{Name: name}
+			err = tx.Create(&tag).Error
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find or create tag %q: %w", name, err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// TagQuestion adds the tags with the given normalized names to a question, creating the tags that don't exist yet
+func TagQuestion(tx *gorm.DB, formatID, questionID uint32, names []string) error {
+	tags, err := FindOrCreateTags(tx, names)
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+
+	links := make([]QuestionTagLink, 0, len(tags))
+	for _, tag := range tags {
+		links = append(links, QuestionTagLink{TagID: tag.TagID, QuestionFormatID: formatID, QuestionID: questionID})
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(&links).Error; err != nil {
+		return fmt.Errorf("failed to tag question: %w", err)
+	}
+	return nil
+}
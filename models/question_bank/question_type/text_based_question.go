@@ -1,5 +1,7 @@
 package models
 
+import "gorm.io/gorm"
+
 type TextBasedQuestion struct {
 	BaseQuestion // Embedding common fields
 }
@@ -7,3 +9,20 @@ type TextBasedQuestion struct {
 func (TextBasedQuestion) TableName() string {
 	return "question_schema.text_questions"
 }
+
+func (q TextBasedQuestion) RevisionSnapshot() QuestionSnapshot {
+	return QuestionSnapshot{QuestionText: q.QuestionText, Answer: q.Answer}
+}
+
+func (q *TextBasedQuestion) ApplySnapshot(snapshot QuestionSnapshot) {
+	q.QuestionText, q.Answer = snapshot.QuestionText, snapshot.Answer
+}
+
+// AfterCreate and AfterUpdate record the revisions of the question
+func (q *TextBasedQuestion) AfterCreate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &TextBasedQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeCreate)
+}
+
+func (q *TextBasedQuestion) AfterUpdate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &TextBasedQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeUpdate)
+}
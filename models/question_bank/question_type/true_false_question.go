@@ -1,5 +1,7 @@
 package models
 
+import "gorm.io/gorm"
+
 type TrueFalseQuestion struct {
 	BaseQuestion        // Embedding common fields
 	Explanation  string `gorm:"type:text;default:''" json:"explanation" bson:"explanation"`
@@ -8,3 +10,20 @@ type TrueFalseQuestion struct {
 func (TrueFalseQuestion) TableName() string {
 	return "question_schema.tf_questions"
 }
+
+func (q TrueFalseQuestion) RevisionSnapshot() QuestionSnapshot {
+	return QuestionSnapshot{QuestionText: q.QuestionText, Answer: q.Answer, Explanation: q.Explanation}
+}
+
+func (q *TrueFalseQuestion) ApplySnapshot(snapshot QuestionSnapshot) {
+	q.QuestionText, q.Answer, q.Explanation = snapshot.QuestionText, snapshot.Answer, snapshot.Explanation
+}
+
+// AfterCreate and AfterUpdate record the revisions of the question
+func (q *TrueFalseQuestion) AfterCreate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &TrueFalseQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeCreate)
+}
+
+func (q *TrueFalseQuestion) AfterUpdate(tx *gorm.DB) error {
+	return recordQuestionRevision(tx, &TrueFalseQuestion{}, q.QuestionFormatID, q.QuestionID, RevisionChangeUpdate)
+}
@@ -0,0 +1,19 @@
+package requests
+
+// StartAdaptivePracticeSessionRequest starts an adaptive practice session for the student of the token
+type StartAdaptivePracticeSessionRequest struct {
+	QuestionDomainID    uint32 `json:"questionDomainID" bson:"questionDomainID" binding:"required"`
+	QuestionSubDomainID uint32 `json:"questionSubDomainID" bson:"questionSubDomainID" binding:"required"`
+	QuestionNicheID     uint32 `json:"questionNicheID" bson:"questionNicheID" binding:"required"`
+	QuestionFormat      string `json:"questionFormat" bson:"questionFormat" binding:"required,oneof=MCQ TF FIB TXT NUM CODE MTC ORD"`
+	QuestionCount       int    `json:"questionCount" bson:"questionCount" binding:"required"`
+}
+
+type AdaptivePracticeAnswerRequest struct {
+	// PracticeSessionID = Adaptive practice session the answer belongs to
+	PracticeSessionID uint32 `json:"practiceSessionId" binding:"required"`
+	// Answer = Answer to the question the session is waiting on, empty to skip it
+	Answer PracticeSessionAnswer `json:"answer" binding:"required"`
+	// Feedbacks = Optional feedbacks for the practice session, kept when the answer ends it
+	Feedbacks string `json:"feedbacks" binding:"max=255"`
+}
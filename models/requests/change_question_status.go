@@ -0,0 +1,8 @@
+package requests
+
+// ChangeQuestionStatusRequest moves a question through the review workflow.
+// Action is submit, approve, reject, retire or reopen, a rejection needs a note for the author.
+type ChangeQuestionStatusRequest struct {
+	Action string `json:"action" bson:"action" binding:"required,oneof=submit approve reject retire reopen"`
+	Note   string `json:"note" bson:"note" binding:"max=2000"`
+}
@@ -6,7 +6,10 @@ type GetQuestionsRequest struct {
 	QuestionSubDomainID       uint32 `json:"questionSubDomainID" bson:"questionSubDomainID" binding:"required"`
 	QuestionDifficultyLevelID uint32 `json:"questionDifficultyLevelID" bson:"questionDifficultyLevelID" binding:"required"`
 	QuestionFormatID          uint32 `json:"questionFormatID" bson:"questionFormatID" binding:"required"`
-	QuestionFormat            string `json:"questionFormat" bson:"questionFormat" binding:"required,max=3"`
+	QuestionFormat            string `json:"questionFormat" bson:"questionFormat" binding:"max=4"` // Optional, the format is resolved from QuestionFormatID
 	QuestionCount             int    `json:"questionCount" bson:"questionCount" binding:"required"`
-	LastAttemptedQuestionID   uint32 `json:"lastAttemptedQuestionID" bson:"lastAttemptedQuestionID" binding:"required"`
+
+	// Tags narrow the questions of the format node to the ones with any (default) or all of the tags
+	Tags     []string `json:"tags" bson:"tags" binding:"max=10"`
+	TagMatch string   `json:"tagMatch" bson:"tagMatch" binding:"omitempty,oneof=any all"`
 }
@@ -0,0 +1,9 @@
+package requests
+
+import "mime/multipart"
+
+// ImportQuestionSpreadsheetRequest is the multipart form of a CSV/XLSX question import
+type ImportQuestionSpreadsheetRequest struct {
+	File    *multipart.FileHeader `form:"file" binding:"required"` // .csv or .xlsx file
+	Mapping string                `form:"mapping"`                 // JSON encoded column mapping, the layout of the CSV exports if empty
+}
@@ -0,0 +1,16 @@
+package requests
+
+import "mime/multipart"
+
+// UploadQuestionAttachmentRequest is the multipart form of an image attached to a question or one of its options
+type UploadQuestionAttachmentRequest struct {
+	File        *multipart.FileHeader `form:"file" binding:"required"`               // PNG, JPEG, GIF or WebP image
+	OptionIndex *int32                `form:"optionIndex" binding:"omitempty,gte=0"` // MCQ only, zero-based option the image belongs to, the question itself when left out
+	AltText     string                `form:"altText" binding:"max=500"`
+}
+
+// ImportQuestionArchiveRequest is the multipart form of a zip import, the question file along with the images it refers to
+type ImportQuestionArchiveRequest struct {
+	File    *multipart.FileHeader `form:"file" binding:"required"` // .zip file
+	Mapping string                `form:"mapping"`                 // CSV/XLSX question files only, as for the spreadsheet imports
+}
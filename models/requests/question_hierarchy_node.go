@@ -0,0 +1,18 @@
+package requests
+
+// CreateHierarchyNodeRequest creates a node under a parent of the level above.
+// Name is the difficulty level (EASY, MEDIUM, HARD) or the format (MCQ, TF, FIB, TXT, NUM, CODE, MTC, ORD) for those levels.
+type CreateHierarchyNodeRequest struct {
+	Name     string `json:"name" bson:"name" binding:"required,max=255"`
+	ParentID uint32 `json:"parentID" bson:"parentID"` // Not needed for domains
+}
+
+// RenameHierarchyNodeRequest renames a node, or changes the difficulty level/format it stands for
+type RenameHierarchyNodeRequest struct {
+	Name string `json:"name" bson:"name" binding:"required,max=255"`
+}
+
+// MoveHierarchyNodeRequest re-parents a node, its descendants and their questions move along with it
+type MoveHierarchyNodeRequest struct {
+	ParentID uint32 `json:"parentID" bson:"parentID" binding:"required"`
+}
@@ -0,0 +1,15 @@
+package requests
+
+// QuestionPassageRequest creates or replaces a passage along with its group of questions, listed in serve order.
+// A question belongs to one passage at most, an empty list leaves the passage without questions.
+type QuestionPassageRequest struct {
+	Title     string               `json:"title" bson:"title" binding:"max=200"`
+	Body      string               `json:"body" bson:"body" binding:"required"`
+	Questions []PassageQuestionKey `json:"questions" bson:"questions" binding:"max=10,dive"`
+}
+
+// PassageQuestionKey identifies a question of any format in the group of a passage
+type PassageQuestionKey struct {
+	QuestionFormatID uint32 `json:"formatID" bson:"formatID" binding:"required"`
+	QuestionID       uint32 `json:"questionID" bson:"questionID" binding:"required"`
+}
@@ -0,0 +1,12 @@
+package requests
+
+// QuestionTagRequest creates or renames a tag
+type QuestionTagRequest struct {
+	Name string `json:"name" bson:"name" binding:"required,max=64"`
+}
+
+// SetQuestionTagsRequest replaces the tags of a question, the tags that don't exist yet are created.
+// An empty list removes every tag of the question.
+type SetQuestionTagsRequest struct {
+	Tags []string `json:"tags" bson:"tags" binding:"max=20"`
+}
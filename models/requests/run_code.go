@@ -0,0 +1,17 @@
+package requests
+
+// RunCodeRequest runs a program against the test cases of a CODE question.
+// An empty code runs the reference solution of the question.
+type RunCodeRequest struct {
+	Language string `json:"language" bson:"language" binding:"max=16"` // Defaults to the language of the reference solution
+	Code     string `json:"code" bson:"code" binding:"max=65536"`
+}
+
+// RunPracticeCodeRequest runs a student's program against the sample test cases of a CODE question served in their session,
+// the student is the one of the token
+type RunPracticeCodeRequest struct {
+	QuestionFormatID uint32 `json:"formatID" bson:"formatID" binding:"required"`
+	QuestionID       uint32 `json:"questionID" bson:"questionID" binding:"required"`
+	Language         string `json:"language" bson:"language" binding:"required,max=16"`
+	Code             string `json:"code" bson:"code" binding:"required,max=65536"`
+}
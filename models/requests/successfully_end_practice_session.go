@@ -1,16 +1,30 @@
 package requests
 
+// PracticeSessionAnswer = Answer given by the student for a single served question
+type PracticeSessionAnswer struct {
+	// QuestionFormatID = Format node of the question being answered
+	QuestionFormatID uint32 `json:"formatID" binding:"required"`
+	// QuestionID = Identifier of the question being answered
+	QuestionID uint32 `json:"questionID" binding:"required"`
+	// Answer = The chosen option (MCQ), true/false (TF), a number (NUM), text (FIB/TXT) or a program (CODE). Empty if skipped.
+	// A multi-select MCQ answer names every chosen option, one per line.
+	Answer string `json:"answer"`
+	// ChosenOptions = Zero-based positions of the chosen options (MCQ), used instead of Answer when given
+	ChosenOptions []int32 `json:"chosenOptions" binding:"omitempty,max=6,dive,gte=0"`
+	// Arrangement = Answer to an MTC or ORD question, as the positions the items were served at (the Answer text is ignored).
+	// MTC: for each column A item, the column B item matched to it, -1 when left unmatched. ORD: the items in the order given.
+	Arrangement []int32 `json:"arrangement" binding:"omitempty,max=10,dive,gte=-1"`
+	// Language = Language the program is written in (CODE), defaults to the language of the reference solution
+	Language string `json:"language" binding:"omitempty,max=16"`
+	// TimeSpentSeconds = Time spent by the student on the question
+	TimeSpentSeconds int `json:"timeSpentSeconds" binding:"gte=0"`
+}
+
 type SuccessfullyEndPracticeSessionRequest struct {
 	// PracticeSessionID = Unique identifier for the practice session to end
 	PracticeSessionID uint32 `json:"practiceSessionId" binding:"required"`
-	// QuestionsAttempted = Number of questions attempted during the session
-	QuestionsAttempted int `json:"questionsAttempted" binding:"required"`
-	// QuestionsCorrect = Number of questions answered correctly
-	QuestionsCorrect int `json:"questionsCorrect" binding:"required"`
+	// Answers = Answers given by the student, graded on the server against the answer key
+	Answers []PracticeSessionAnswer `json:"answers" binding:"dive"`
 	// Feedbacks = Feedbacks given by the student for the practice session
 	Feedbacks string `json:"feedbacks" binding:"required"`
-
-	// ScoreEarnedPercentage = Total score percentage ((correct ans/total ques) * 100) earned in the session
-	// Calculate and send from the front end.
-	ScoreEarnedPercentage float64 `json:"scoreEarnedPercentage" binding:"required"`
 }
@@ -0,0 +1,31 @@
+package requests
+
+// UpdateQuestionRequest replaces the content of a stored question, the format and the place in the hierarchy stay.
+// Options and multiSelect are only for MCQ questions, whose answer names the correct options one per line.
+// Tolerance and unit are only for NUM questions, an empty tolerance falls back to 0.1% of the answer.
+// Language, starter code, test cases and limits are only for CODE questions, whose answer is the reference solution.
+// The answer of an MTC question gives its pairs one per line ("<column A> => <column B>"), the one of an ORD question
+// gives its items one per line in their correct order.
+// The question text, options and explanation are rich text: Markdown with $...$ math, no HTML.
+type UpdateQuestionRequest struct {
+	QuestionText string   `json:"questionText" bson:"questionText" binding:"required"`
+	Options      []string `json:"options" bson:"options"`
+	MultiSelect  bool     `json:"multiSelect" bson:"multiSelect"`
+	Answer       string   `json:"answer" bson:"answer" binding:"required"`
+	Tolerance    string   `json:"tolerance" bson:"tolerance"` // "0.01" absolute or "0.5%" of the answer
+	Unit         string   `json:"unit" bson:"unit"`
+	Explanation  string   `json:"explanation" bson:"explanation"`
+
+	Language      string             `json:"language" bson:"language"`
+	StarterCode   map[string]string  `json:"starterCode" bson:"starterCode"`
+	TestCases     []CodeTestCaseItem `json:"testCases" bson:"testCases" binding:"max=50,dive"`
+	TimeLimitMs   int                `json:"timeLimitMs" bson:"timeLimitMs" binding:"gte=0"`     // Defaults to 2000
+	MemoryLimitMB int                `json:"memoryLimitMB" bson:"memoryLimitMB" binding:"gte=0"` // Defaults to 256
+}
+
+// CodeTestCaseItem is a test case of a CODE question, the sample ones are shown to the students
+type CodeTestCaseItem struct {
+	Input          string `json:"input" bson:"input"`
+	ExpectedOutput string `json:"expectedOutput" bson:"expectedOutput"`
+	IsSample       bool   `json:"isSample" bson:"isSample"`
+}
@@ -0,0 +1,36 @@
+// DTO (Data Transfer Object) for the responses of the adaptive practice session APIs, which serve one question
+// at a time at the difficulty matching the ability of the student.
+package response
+
+import "time"
+
+// NicheAbilityResponse is the ability estimate of a student in a niche
+type NicheAbilityResponse struct {
+	QuestionNicheID uint32    `json:"nicheID" bson:"nicheID"`
+	NicheName       string    `json:"nicheName,omitempty" bson:"nicheName,omitempty"`
+	Rating          float64   `json:"rating" bson:"rating"`                   // Elo rating, 1500 to start with
+	GradedAnswers   int       `json:"gradedAnswers" bson:"gradedAnswers"`     // Answers the rating was estimated from
+	DifficultyLevel string    `json:"difficultyLevel" bson:"difficultyLevel"` // Difficulty level matching the rating
+	UpdatedAt       time.Time `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+}
+
+// AdaptiveAnswerResult is the grading of the answer to an adaptive practice question, the answer key
+// is only revealed by the review once the session is over
+type AdaptiveAnswerResult struct {
+	ServeOrder int      `json:"serveOrder" bson:"serveOrder"`
+	IsCorrect  *bool    `json:"isCorrect" bson:"isCorrect"` // null if the format isn't auto-graded
+	Credit     *float64 `json:"credit" bson:"credit"`
+}
+
+// AdaptivePracticeSessionResponse is the state of an adaptive practice session after it started or after an answer
+type AdaptivePracticeSessionResponse struct {
+	PracticeSessionID uint32                    `json:"practiceSessionID" bson:"practiceSessionID"`
+	Question          *PracticeQuestionResponse `json:"question,omitempty" bson:"question,omitempty"`               // Question to answer next, none once the session is over
+	ServeOrder        int                       `json:"serveOrder,omitempty" bson:"serveOrder,omitempty"`           // Position of the next question in the session
+	DifficultyLevel   string                    `json:"difficultyLevel,omitempty" bson:"difficultyLevel,omitempty"` // Difficulty level of the next question
+	QuestionCount     int                       `json:"questionCount" bson:"questionCount"`
+	Ability           NicheAbilityResponse      `json:"ability" bson:"ability"`
+	LastAnswer        *AdaptiveAnswerResult     `json:"lastAnswer,omitempty" bson:"lastAnswer,omitempty"`
+	Finished          bool                      `json:"finished" bson:"finished"` // The session is submitted, its review is available
+	Message           string                    `json:"message" bson:"message"`
+}
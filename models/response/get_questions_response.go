@@ -1,7 +1,8 @@
 package response
 
 type GetQuestionsResponse struct {
-	Questions         interface{} `json:"questions"`
-	PracticeSessionID uint32      `json:"practiceSessionID"`
-	Message           string      `json:"message"`
+	Questions         []PracticeQuestionResponse `json:"questions"`
+	Passages          []PracticePassageResponse  `json:"passages,omitempty"` // Passages of the questions that have one, each sent once
+	PracticeSessionID uint32                     `json:"practiceSessionID"`
+	Message           string                     `json:"message"`
 }
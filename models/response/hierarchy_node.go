@@ -0,0 +1,28 @@
+// DTOs (Data Transfer Objects) for the responses of the hierarchy admin APIs
+package response
+
+// HierarchyNodeResponse is a node of any level of the question hierarchy
+type HierarchyNodeResponse struct {
+	Level    string `json:"level" bson:"level"`
+	ID       uint32 `json:"id" bson:"id"`
+	Name     string `json:"name" bson:"name"`
+	ParentID uint32 `json:"parentID,omitempty" bson:"parentID,omitempty"`
+}
+
+// HierarchyDeletePreviewResponse lists everything that is deleted along with a node
+type HierarchyDeletePreviewResponse struct {
+	Node        HierarchyNodeResponse `json:"node" bson:"node"`
+	Descendants map[string]int        `json:"descendants" bson:"descendants"` // Level → number of nodes deleted
+	Questions   map[string]int64      `json:"questions" bson:"questions"`     // Format → number of questions deleted
+	// ServedInPracticeSessions = Times the deleted questions were served, the session history keeps them
+	ServedInPracticeSessions int64 `json:"servedInPracticeSessions" bson:"servedInPracticeSessions"`
+}
+
+// HierarchyTreeNodeResponse is a node of the nested hierarchy tree along with the number of questions beneath it
+type HierarchyTreeNodeResponse struct {
+	Level         string                       `json:"level" bson:"level"`
+	ID            uint32                       `json:"id" bson:"id"`
+	Name          string                       `json:"name" bson:"name"`
+	QuestionCount int64                        `json:"questionCount" bson:"questionCount"`
+	Children      []*HierarchyTreeNodeResponse `json:"children,omitempty" bson:"children,omitempty"`
+}
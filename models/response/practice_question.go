@@ -0,0 +1,46 @@
+// DTO (Data Transfer Object) for the questions served in a practice session.
+// Never carries the answer or the explanation, those stay on the server for grading.
+package response
+
+type PracticeQuestionResponse struct {
+	QuestionFormatID uint32   `json:"formatID" bson:"formatID"`
+	QuestionID       uint32   `json:"questionID" bson:"questionID"`
+	QuestionText     string   `json:"questionText" bson:"questionText"`
+	Options          []string `json:"options,omitempty" bson:"options,omitempty"`             // Only for MCQ questions
+	IsMultiSelect    bool     `json:"isMultiSelect,omitempty" bson:"isMultiSelect,omitempty"` // MCQ questions whose answer is one or more options
+	Unit             string   `json:"unit,omitempty" bson:"unit,omitempty"`                   // Unit of the answer of NUM questions, e.g. "km/h"
+	RevisionID       uint32   `json:"revisionID,omitempty" bson:"revisionID,omitempty"`       // Revision of the question that was served
+	PassageID        uint32   `json:"passageID,omitempty" bson:"passageID,omitempty"`         // Passage the question belongs to, sent once in the passages of the session
+
+	Attachments []QuestionAttachmentResponse `json:"attachments,omitempty" bson:"attachments,omitempty"` // Images of the question and of its options
+
+	// Rendered rich text, with ?render=html only
+	QuestionHTML string   `json:"questionHTML,omitempty" bson:"questionHTML,omitempty"`
+	OptionsHTML  []string `json:"optionsHTML,omitempty" bson:"optionsHTML,omitempty"`
+
+	// CODE questions only, the hidden test cases and the reference solution stay on the server
+	StarterCode   map[string]string `json:"starterCode,omitempty" bson:"starterCode,omitempty"` // Code to start from, by language
+	SampleTests   []CodeSampleTest  `json:"sampleTests,omitempty" bson:"sampleTests,omitempty"`
+	TimeLimitMs   int               `json:"timeLimitMs,omitempty" bson:"timeLimitMs,omitempty"`     // Per test case
+	MemoryLimitMB int               `json:"memoryLimitMB,omitempty" bson:"memoryLimitMB,omitempty"` // Per test case
+
+	// MTC and ORD questions only, the items to match or arrange are shuffled and answered by the positions they are served at
+	ColumnA     []string `json:"columnA,omitempty" bson:"columnA,omitempty"` // MTC, the items to match, in order
+	ColumnB     []string `json:"columnB,omitempty" bson:"columnB,omitempty"` // MTC, the items they are matched to, shuffled
+	Items       []string `json:"items,omitempty" bson:"items,omitempty"`     // ORD, the items to arrange, shuffled
+	Permutation []int32  `json:"-" bson:"-"`                                 // Order the items were shuffled into, kept on the server
+}
+
+// CodeSampleTest is a sample test case of a coding question, shown to the students
+type CodeSampleTest struct {
+	Input          string `json:"input" bson:"input"`
+	ExpectedOutput string `json:"expectedOutput" bson:"expectedOutput"`
+}
+
+// PracticePassageResponse is a passage served with its group of questions, the questions follow it in serve order
+type PracticePassageResponse struct {
+	PassageID uint32 `json:"passageID" bson:"passageID"`
+	Title     string `json:"title,omitempty" bson:"title,omitempty"`
+	Body      string `json:"body" bson:"body"`
+	BodyHTML  string `json:"bodyHTML,omitempty" bson:"bodyHTML,omitempty"` // Rendered rich text, with ?render=html only
+}
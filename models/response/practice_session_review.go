@@ -0,0 +1,46 @@
+// DTO (Data Transfer Object) for the response of the practice session review API.
+// Only sent once the session is submitted as it reveals the answer key.
+package response
+
+type PracticeSessionReviewQuestion struct {
+	ServeOrder       int      `json:"serveOrder" bson:"serveOrder"`
+	QuestionFormatID uint32   `json:"formatID" bson:"formatID"`
+	QuestionID       uint32   `json:"questionID" bson:"questionID"`
+	RevisionID       uint32   `json:"revisionID,omitempty" bson:"revisionID,omitempty"` // Revision served, the answer key shown is the one of this revision
+	PassageID        uint32   `json:"passageID,omitempty" bson:"passageID,omitempty"`   // Passage the question was served under
+	Format           string   `json:"format" bson:"format"`
+	QuestionText     string   `json:"questionText" bson:"questionText"`
+	Options          []string `json:"options,omitempty" bson:"options,omitempty"`
+	IsMultiSelect    bool     `json:"isMultiSelect,omitempty" bson:"isMultiSelect,omitempty"`
+	ChosenOptions    []int32  `json:"chosenOptions,omitempty" bson:"chosenOptions,omitempty"`   // MCQ only, zero-based
+	CorrectOptions   []int32  `json:"correctOptions,omitempty" bson:"correctOptions,omitempty"` // MCQ only, zero-based
+	Unit             string   `json:"unit,omitempty" bson:"unit,omitempty"`                     // NUM only
+	Language         string   `json:"language,omitempty" bson:"language,omitempty"`             // CODE only, language of the submission
+	TestVerdicts     []string `json:"testVerdicts,omitempty" bson:"testVerdicts,omitempty"`     // CODE only, verdict (AC, WA, TLE, RE) of each test case
+	ColumnA          []string `json:"columnA,omitempty" bson:"columnA,omitempty"`               // MTC only, a column A item matches the column B item at its position
+	ColumnB          []string `json:"columnB,omitempty" bson:"columnB,omitempty"`               // MTC only, unshuffled
+	Items            []string `json:"items,omitempty" bson:"items,omitempty"`                   // ORD only, in the correct order
+	Arrangement      []int32  `json:"arrangement,omitempty" bson:"arrangement,omitempty"`       // MTC and ORD only, the answer of the student as positions in columnB or items
+	StudentAnswer    string   `json:"studentAnswer" bson:"studentAnswer"`
+	CorrectAnswer    string   `json:"correctAnswer" bson:"correctAnswer"`
+	Explanation      string   `json:"explanation" bson:"explanation"`
+	IsCorrect        *bool    `json:"isCorrect" bson:"isCorrect"` // null if the format isn't auto-graded
+	Credit           *float64 `json:"credit" bson:"credit"`       // Share of the marks earned (0 to 1), partial for multi-select MCQ, CODE, MTC and ORD questions
+	TimeSpentSeconds int      `json:"timeSpentSeconds" bson:"timeSpentSeconds"`
+
+	Attachments []QuestionAttachmentResponse `json:"attachments,omitempty" bson:"attachments,omitempty"` // Images of the question and of its options
+
+	// Rendered rich text, with ?render=html only
+	QuestionHTML    string   `json:"questionHTML,omitempty" bson:"questionHTML,omitempty"`
+	OptionsHTML     []string `json:"optionsHTML,omitempty" bson:"optionsHTML,omitempty"`
+	ExplanationHTML string   `json:"explanationHTML,omitempty" bson:"explanationHTML,omitempty"`
+}
+
+type PracticeSessionReviewResponse struct {
+	PracticeSessionID  uint32                          `json:"practiceSessionID" bson:"practiceSessionID"`
+	QuestionsAttempted int                             `json:"questionsAttempted" bson:"questionsAttempted"`
+	QuestionsCorrect   int                             `json:"questionsCorrect" bson:"questionsCorrect"`
+	ScoreEarned        float64                         `json:"scoreEarned" bson:"scoreEarned"`
+	Questions          []PracticeSessionReviewQuestion `json:"questions" bson:"questions"`
+	Passages           []PracticePassageResponse       `json:"passages,omitempty" bson:"passages,omitempty"`
+}
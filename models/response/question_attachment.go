@@ -0,0 +1,23 @@
+// DTOs (Data Transfer Objects) for the question attachment APIs.
+package response
+
+import "time"
+
+// QuestionAttachmentResponse is an image attached to a question or one of its options.
+// URL reads the image until it expires, it is signed anew every time the attachment is served.
+type QuestionAttachmentResponse struct {
+	AttachmentID uint32 `json:"attachmentID" bson:"attachmentID"`
+	OptionIndex  *int32 `json:"optionIndex,omitempty" bson:"optionIndex,omitempty"` // Zero-based MCQ option, the question itself when left out
+	URL          string `json:"url" bson:"url"`                                     // Empty while the cloud storage can't be reached
+	ContentType  string `json:"contentType" bson:"contentType"`
+	AltText      string `json:"altText" bson:"altText"`
+}
+
+// QuestionAttachmentItem is an attachment as listed to the editors
+type QuestionAttachmentItem struct {
+	QuestionAttachmentResponse
+	FileName   string    `json:"fileName" bson:"fileName"`
+	SizeBytes  int64     `json:"sizeBytes" bson:"sizeBytes"`
+	UploadedBy string    `json:"uploadedBy" bson:"uploadedBy"`
+	CreatedAt  time.Time `json:"createdAt" bson:"createdAt"`
+}
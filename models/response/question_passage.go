@@ -0,0 +1,32 @@
+// DTOs (Data Transfer Objects) for the question passage APIs.
+package response
+
+import "time"
+
+// QuestionPassageResponse is a passage with its group of questions, in serve order
+type QuestionPassageResponse struct {
+	PassageID uint32                    `json:"passageID" bson:"passageID"`
+	Title     string                    `json:"title" bson:"title"`
+	Body      string                    `json:"body" bson:"body"`
+	CreatedAt time.Time                 `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time                 `json:"updatedAt" bson:"updatedAt"`
+	Questions []PassageQuestionResponse `json:"questions" bson:"questions"`
+}
+
+// PassageQuestionResponse is a question of the group of a passage
+type PassageQuestionResponse struct {
+	Position         int    `json:"position" bson:"position"`
+	QuestionFormatID uint32 `json:"formatID" bson:"formatID"`
+	QuestionID       uint32 `json:"questionID" bson:"questionID"`
+	Format           string `json:"format" bson:"format"`
+	QuestionText     string `json:"questionText" bson:"questionText"`
+	Status           string `json:"status" bson:"status"` // Only PUBLISHED questions are served with the passage
+}
+
+// QuestionPassageSummary is a passage in the passage list
+type QuestionPassageSummary struct {
+	PassageID     uint32    `json:"passageID" bson:"passageID"`
+	Title         string    `json:"title" bson:"title"`
+	QuestionCount int64     `json:"questionCount" bson:"questionCount"`
+	UpdatedAt     time.Time `json:"updatedAt" bson:"updatedAt"`
+}
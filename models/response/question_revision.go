@@ -0,0 +1,99 @@
+// DTOs (Data Transfer Objects) for the question editing, revision history and review workflow APIs.
+package response
+
+import "time"
+
+// QuestionItemResponse is a stored question with its answer key, for the editors
+type QuestionItemResponse struct {
+	QuestionFormatID uint32       `json:"formatID" bson:"formatID"`
+	QuestionID       uint32       `json:"questionID" bson:"questionID"`
+	Format           string       `json:"format" bson:"format"`
+	QuestionText     string       `json:"questionText" bson:"questionText"`
+	Options          []string     `json:"options,omitempty" bson:"options,omitempty"`
+	CorrectOptions   []int32      `json:"correctOptions,omitempty" bson:"correctOptions,omitempty"` // MCQ only, zero-based
+	MultiSelect      bool         `json:"multiSelect,omitempty" bson:"multiSelect,omitempty"`       // MCQ only
+	Tolerance        string       `json:"tolerance,omitempty" bson:"tolerance,omitempty"`           // NUM only, "0.01" absolute or "0.5%" of the answer
+	Unit             string       `json:"unit,omitempty" bson:"unit,omitempty"`                     // NUM only
+	Code             *CodeContent `json:"code,omitempty" bson:"code,omitempty"`                     // CODE only
+	Pairs            []MatchPair  `json:"pairs,omitempty" bson:"pairs,omitempty"`                   // MTC only
+	Sequence         []string     `json:"sequence,omitempty" bson:"sequence,omitempty"`             // ORD only, in the correct order
+	Answer           string       `json:"answer" bson:"answer"`
+	Explanation      string       `json:"explanation,omitempty" bson:"explanation,omitempty"`
+	Status           string       `json:"status" bson:"status"`                 // DRAFT, IN_REVIEW, PUBLISHED or RETIRED
+	RevisionNumber   int          `json:"revisionNumber" bson:"revisionNumber"` // Latest revision, 0 if the question has none yet
+
+	Attachments []QuestionAttachmentItem `json:"attachments,omitempty" bson:"attachments,omitempty"` // Images of the question and of its options, not revisioned
+
+	// Rendered rich text, with ?render=html only
+	QuestionHTML    string   `json:"questionHTML,omitempty" bson:"questionHTML,omitempty"`
+	OptionsHTML     []string `json:"optionsHTML,omitempty" bson:"optionsHTML,omitempty"`
+	ExplanationHTML string   `json:"explanationHTML,omitempty" bson:"explanationHTML,omitempty"`
+}
+
+// QuestionRevisionResponse is a past version of a question
+type QuestionRevisionResponse struct {
+	RevisionID        uint32       `json:"revisionID" bson:"revisionID"`
+	RevisionNumber    int          `json:"revisionNumber" bson:"revisionNumber"`
+	ChangeType        string       `json:"changeType" bson:"changeType"` // CREATE, UPDATE, RESTORE or BASELINE
+	ChangedBy         string       `json:"changedBy" bson:"changedBy"`
+	RestoredFrom      *int         `json:"restoredFrom,omitempty" bson:"restoredFrom,omitempty"`
+	CreatedAt         time.Time    `json:"createdAt" bson:"createdAt"`
+	QuestionText      string       `json:"questionText" bson:"questionText"`
+	Options           []string     `json:"options,omitempty" bson:"options,omitempty"`
+	CorrectOptions    []int32      `json:"correctOptions,omitempty" bson:"correctOptions,omitempty"`
+	MultiSelect       bool         `json:"multiSelect,omitempty" bson:"multiSelect,omitempty"`
+	Tolerance         float64      `json:"tolerance,omitempty" bson:"tolerance,omitempty"`                 // NUM only, a fraction of the answer when relativeTolerance is set
+	RelativeTolerance bool         `json:"relativeTolerance,omitempty" bson:"relativeTolerance,omitempty"` // NUM only
+	Unit              string       `json:"unit,omitempty" bson:"unit,omitempty"`                           // NUM only
+	Code              *CodeContent `json:"code,omitempty" bson:"code,omitempty"`                           // CODE only
+	Pairs             []MatchPair  `json:"pairs,omitempty" bson:"pairs,omitempty"`                         // MTC only
+	Sequence          []string     `json:"sequence,omitempty" bson:"sequence,omitempty"`                   // ORD only, in the correct order
+	Answer            string       `json:"answer" bson:"answer"`
+	Explanation       string       `json:"explanation,omitempty" bson:"explanation,omitempty"`
+}
+
+// CodeContent is the content of a CODE question besides its statement and reference solution (the answer)
+type CodeContent struct {
+	Language      string             `json:"language,omitempty" bson:"language,omitempty"` // Language of the reference solution
+	StarterCode   map[string]string  `json:"starterCode,omitempty" bson:"starterCode,omitempty"`
+	TestCases     []CodeTestCaseItem `json:"testCases,omitempty" bson:"testCases,omitempty"` // Hidden ones included
+	TimeLimitMs   int                `json:"timeLimitMs,omitempty" bson:"timeLimitMs,omitempty"`
+	MemoryLimitMB int                `json:"memoryLimitMB,omitempty" bson:"memoryLimitMB,omitempty"`
+}
+
+// CodeTestCaseItem is a test case of a CODE question
+type CodeTestCaseItem struct {
+	Input          string `json:"input" bson:"input"`
+	ExpectedOutput string `json:"expectedOutput" bson:"expectedOutput"`
+	IsSample       bool   `json:"isSample" bson:"isSample"`
+}
+
+// MatchPair is a column A item of an MTC question along with the column B item it matches
+type MatchPair struct {
+	Left  string `json:"left" bson:"left"`
+	Right string `json:"right" bson:"right"`
+}
+
+// QuestionRevisionFieldChange is a field that differs between two revisions
+type QuestionRevisionFieldChange struct {
+	Field string      `json:"field" bson:"field"` // questionText, options, correctOptions, multiSelect, tolerance, relativeTolerance, unit, language, starterCode, testCases, timeLimitMs, memoryLimitMB, pairs, sequence, answer or explanation
+	From  interface{} `json:"from" bson:"from"`
+	To    interface{} `json:"to" bson:"to"`
+}
+
+// QuestionRevisionDiffResponse compares two revisions of a question
+type QuestionRevisionDiffResponse struct {
+	From    QuestionRevisionResponse      `json:"from" bson:"from"`
+	To      QuestionRevisionResponse      `json:"to" bson:"to"`
+	Changes []QuestionRevisionFieldChange `json:"changes" bson:"changes"`
+}
+
+// QuestionQueueEntry is a question waiting in the review workflow
+type QuestionQueueEntry struct {
+	QuestionFormatID uint32    `json:"formatID" bson:"formatID"`
+	QuestionID       uint32    `json:"questionID" bson:"questionID"`
+	Format           string    `json:"format" bson:"format"`
+	QuestionText     string    `json:"questionText" bson:"questionText"`
+	Status           string    `json:"status" bson:"status"`
+	UpdatedAt        time.Time `json:"updatedAt" bson:"updatedAt"`
+}
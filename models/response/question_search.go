@@ -0,0 +1,20 @@
+// DTO (Data Transfer Object) for the results of the question search API.
+package response
+
+// QuestionSearchResult is a question matching a search, with its place in the hierarchy and the matching fragments.
+// The highlights wrap the matched words in <mark> tags around the text as stored, the client has to escape the rest.
+type QuestionSearchResult struct {
+	QuestionFormatID     uint32  `json:"formatID" bson:"formatID"`
+	QuestionID           uint32  `json:"questionID" bson:"questionID"`
+	DomainName           string  `json:"domain" bson:"domain"`
+	SubDomainName        string  `json:"subDomain" bson:"subDomain"`
+	NicheName            string  `json:"niche" bson:"niche"`
+	DifficultyLevel      string  `json:"difficulty" bson:"difficulty"`
+	Format               string  `json:"format" bson:"format"`
+	Status               string  `json:"status" bson:"status"`
+	Rank                 float64 `json:"rank" bson:"rank"`
+	QuestionText         string  `json:"questionText" bson:"questionText"`
+	QuestionHighlight    string  `json:"questionHighlight" bson:"questionHighlight"`
+	OptionsHighlight     string  `json:"optionsHighlight,omitempty" bson:"optionsHighlight,omitempty"`         // Only when an option matched
+	ExplanationHighlight string  `json:"explanationHighlight,omitempty" bson:"explanationHighlight,omitempty"` // Only when the explanation matched
+}
@@ -0,0 +1,41 @@
+package response
+
+import "time"
+
+// QuestionOptionStatistics is how often an MCQ option was chosen
+type QuestionOptionStatistics struct {
+	OptionIndex int32   `json:"optionIndex" bson:"optionIndex"` // Zero-based
+	Option      string  `json:"option" bson:"option"`
+	IsCorrect   bool    `json:"isCorrect" bson:"isCorrect"`
+	Choices     int64   `json:"choices" bson:"choices"`
+	Percent     float64 `json:"percent" bson:"percent"` // Share of the attempts that chose the option
+}
+
+// QuestionStatisticsResponse is the answer statistics of a question along with its calibration
+type QuestionStatisticsResponse struct {
+	QuestionFormatID    uint32                     `json:"formatID" bson:"formatID"`
+	QuestionID          uint32                     `json:"questionID" bson:"questionID"`
+	RevisionID          uint32                     `json:"revisionID" bson:"revisionID"` // Revision the statistics cover, the latest one when they were computed
+	Attempts            int                        `json:"attempts" bson:"attempts"`
+	Skips               int                        `json:"skips" bson:"skips"`
+	GradedAttempts      int                        `json:"gradedAttempts" bson:"gradedAttempts"`
+	CorrectAttempts     int                        `json:"correctAttempts" bson:"correctAttempts"`
+	PercentCorrect      *float64                   `json:"percentCorrect" bson:"percentCorrect"`
+	MedianTimeSeconds   *float64                   `json:"medianTimeSeconds" bson:"medianTimeSeconds"`
+	DifficultyLevel     string                     `json:"difficultyLevel" bson:"difficultyLevel"`         // Of the node of the question
+	EmpiricalDifficulty string                     `json:"empiricalDifficulty" bson:"empiricalDifficulty"` // Empty below the minimum of attempts
+	Flags               []string                   `json:"flags" bson:"flags"`
+	Options             []QuestionOptionStatistics `json:"options,omitempty" bson:"options,omitempty"` // MCQ only
+	ComputedAt          time.Time                  `json:"computedAt" bson:"computedAt"`
+}
+
+// QuestionStatisticsEntry is the statistics of a question along with its place in the hierarchy
+type QuestionStatisticsEntry struct {
+	QuestionStatisticsResponse
+	Format        string `json:"format" bson:"format"`
+	DomainName    string `json:"domain" bson:"domain"`
+	SubDomainName string `json:"subDomain" bson:"subDomain"`
+	NicheName     string `json:"niche" bson:"niche"`
+	QuestionText  string `json:"questionText" bson:"questionText"`
+	Status        string `json:"status" bson:"status"`
+}
@@ -0,0 +1,22 @@
+// DTOs (Data Transfer Objects) for the question tag APIs.
+package response
+
+// QuestionTagResponse is a tag along with the number of questions it labels
+type QuestionTagResponse struct {
+	TagID         uint32 `json:"tagID" bson:"tagID"`
+	Name          string `json:"name" bson:"name"`
+	QuestionCount int64  `json:"questionCount" bson:"questionCount"`
+}
+
+// TaggedQuestionResponse is a question found by tag, with its place in the hierarchy
+type TaggedQuestionResponse struct {
+	QuestionFormatID uint32 `json:"formatID" bson:"formatID"`
+	QuestionID       uint32 `json:"questionID" bson:"questionID"`
+	DomainName       string `json:"domain" bson:"domain"`
+	SubDomainName    string `json:"subDomain" bson:"subDomain"`
+	NicheName        string `json:"niche" bson:"niche"`
+	DifficultyLevel  string `json:"difficulty" bson:"difficulty"`
+	Format           string `json:"format" bson:"format"`
+	QuestionText     string `json:"questionText" bson:"questionText"`
+	Status           string `json:"status" bson:"status"`
+}
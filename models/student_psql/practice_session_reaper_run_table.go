@@ -0,0 +1,30 @@
+// This is an independent table that stores every run of the practice session reaper,
+// the background worker that expires abandoned practice sessions.
+package models
+
+import (
+	"time"
+)
+
+type PracticeSessionReaperRunTable struct {
+	// RunID = Unique identifier for each run
+	RunID uint32 `gorm:"primaryKey;autoIncrement" json:"runID" bson:"runID"`
+
+	// Instance = Host name of the server instance that performed the run
+	Instance string `gorm:"type:varchar(255);not null" json:"instance" bson:"instance"`
+
+	// StartedAt and FinishedAt = Time period of the run
+	StartedAt  time.Time `gorm:"type:timestamp with time zone;not null;index" json:"startedAt" bson:"startedAt"`
+	FinishedAt time.Time `gorm:"type:timestamp with time zone;not null" json:"finishedAt" bson:"finishedAt"`
+
+	// SessionsExpired = Number of active sessions expired in the run
+	SessionsExpired int `gorm:"not null;default:0" json:"sessionsExpired" bson:"sessionsExpired"`
+
+	// Error = Error that stopped the run, empty if the run succeeded
+	Error string `gorm:"type:text;not null;default:''" json:"error" bson:"error"`
+}
+
+// TableName returns the name of the table in the database
+func (PracticeSessionReaperRunTable) TableName() string {
+	return "student_schema.practice_session_reaper_runs_table"
+}
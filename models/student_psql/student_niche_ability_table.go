@@ -0,0 +1,27 @@
+// This is an independent table that stores the ability estimate of every student in every niche they practiced,
+// updated after each graded answer of their adaptive practice sessions.
+package models
+
+import (
+	"time"
+)
+
+type StudentNicheAbilityTable struct {
+	// EnrollmentNo and QuestionNicheID = Student and niche of the estimate (composite primary key)
+	EnrollmentNo    string `gorm:"type:varchar(12);size:12;primaryKey" json:"enrollmentNo" bson:"enrollmentNo"`
+	QuestionNicheID uint32 `gorm:"primaryKey;autoIncrement:false" json:"nicheID" bson:"nicheID"`
+
+	// Rating = Elo rating of the student in the niche, see utils.InitialAbilityRating
+	Rating float64 `gorm:"not null" json:"rating" bson:"rating"`
+
+	// GradedAnswers = Number of graded answers the rating was estimated from
+	GradedAnswers int `gorm:"not null;default:0" json:"gradedAnswers" bson:"gradedAnswers"`
+
+	// UpdatedAt = Time of the last update of the rating
+	UpdatedAt time.Time `gorm:"type:timestamp with time zone;autoUpdateTime" json:"updatedAt" bson:"updatedAt"`
+}
+
+// TableName returns the name of the table in the database
+func (StudentNicheAbilityTable) TableName() string {
+	return "student_schema.student_niche_abilities_table"
+}
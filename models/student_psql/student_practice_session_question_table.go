@@ -0,0 +1,37 @@
+// Stores the exact questions served in each practice session, in the order they were served.
+// Used to grade, review, resume and audit a session against the questions that were really served.
+// Depends on the StudentPracticeSessionRecordTable table.
+package models
+
+import "github.com/lib/pq"
+
+type StudentPracticeSessionQuestionTable struct {
+	// PracticeSessionID = Practice session the question was served in (part of composite primary key)
+	PracticeSessionID uint32 `gorm:"primaryKey;not null" json:"practiceSessionID" bson:"practiceSessionID"`
+
+	// ServeOrder = Position of the question in the session, starting at 1 (part of composite primary key)
+	ServeOrder int `gorm:"primaryKey;not null;autoIncrement:false" json:"serveOrder" bson:"serveOrder"`
+
+	// QuestionFormatID and QuestionID = Composite key of the served question in its question table
+	QuestionFormatID uint32 `gorm:"not null;index:idx_practice_session_served_question" json:"formatID" bson:"formatID"`
+	QuestionID       uint32 `gorm:"not null;index:idx_practice_session_served_question" json:"questionID" bson:"questionID"`
+
+	// QuestionRevisionID = Revision of the question that was served (QuestionRevision), the session is graded and
+	// reviewed against it. NULL for sessions served before revisions were tracked.
+	QuestionRevisionID *uint32 `gorm:"index" json:"revisionID,omitempty" bson:"revisionID,omitempty"`
+
+	// PassageID = Passage (QuestionPassage) the question was served under, its group is served together.
+	// NULL for standalone questions.
+	PassageID *uint32 `json:"passageID,omitempty" bson:"passageID,omitempty"`
+
+	// Permutation = Order the column B items (MTC) or the items (ORD) were shuffled into when served, as their positions
+	// in the question: the item served at position i is the item Permutation[i]. Empty for the other formats.
+	Permutation pq.Int32Array `gorm:"type:integer[];not null;default:'{}'" json:"-" bson:"-"`
+
+	// Foreign key relationships
+	PracticeSessionRecord StudentPracticeSessionRecordTable `gorm:"foreignKey:PracticeSessionID;references:PracticeSessionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-" bson:"-"`
+}
+
+func (StudentPracticeSessionQuestionTable) TableName() string {
+	return "student_schema.student_practice_session_questions_table"
+}
@@ -0,0 +1,43 @@
+// Stores the response of the student to every question served in a practice session.
+// Depends on the StudentPracticeSessionQuestionTable table.
+package models
+
+import "github.com/lib/pq"
+
+type StudentPracticeSessionResponseTable struct {
+	// PracticeSessionID and ServeOrder = The served question this response belongs to (composite primary key)
+	PracticeSessionID uint32 `gorm:"primaryKey;not null" json:"practiceSessionID" bson:"practiceSessionID"`
+	ServeOrder        int    `gorm:"primaryKey;not null;autoIncrement:false" json:"serveOrder" bson:"serveOrder"`
+
+	// ChosenAnswer = The option or text given by the student, empty if the question was skipped
+	ChosenAnswer string `gorm:"type:text;not null;default:''" json:"chosenAnswer" bson:"chosenAnswer"`
+
+	// ChosenOptions = Zero-based positions of the options chosen for an MCQ question, empty otherwise
+	ChosenOptions pq.Int32Array `gorm:"type:integer[];not null;default:'{}'" json:"chosenOptions" bson:"chosenOptions"`
+
+	// Arrangement = Answer to an MTC or ORD question, as positions in the question (unshuffled), empty otherwise.
+	// MTC: the column B item matched to each column A item, -1 when left unmatched. ORD: the items in the order given.
+	Arrangement pq.Int32Array `gorm:"type:integer[];not null;default:'{}'" json:"arrangement" bson:"arrangement"`
+
+	// Language = Language of the program submitted for a CODE question, empty otherwise
+	Language string `gorm:"type:varchar(16);not null;default:''" json:"language" bson:"language"`
+
+	// TestVerdicts = Verdict (AC, WA, TLE, RE) of the submitted program on each test case of a CODE question, empty otherwise
+	TestVerdicts pq.StringArray `gorm:"type:text[];not null;default:'{}'" json:"testVerdicts" bson:"testVerdicts"`
+
+	// IsCorrect = Result of the server-side grading, NULL for formats that can't be graded automatically (TXT)
+	IsCorrect *bool `json:"isCorrect" bson:"isCorrect"`
+
+	// Credit = Share of the marks earned, from 0 to 1. Partial for multi-select MCQ, CODE, MTC and ORD questions, NULL like IsCorrect
+	Credit *float64 `gorm:"check:credit BETWEEN 0 AND 1" json:"credit" bson:"credit"`
+
+	// TimeSpentSeconds = Time spent by the student on the question, as reported by the client
+	TimeSpentSeconds int `gorm:"not null;default:0;check:time_spent_seconds >= 0" json:"timeSpentSeconds" bson:"timeSpentSeconds"`
+
+	// Foreign key relationships
+	SessionQuestion StudentPracticeSessionQuestionTable `gorm:"foreignKey:PracticeSessionID,ServeOrder;references:PracticeSessionID,ServeOrder;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-" bson:"-"`
+}
+
+func (StudentPracticeSessionResponseTable) TableName() string {
+	return "student_schema.student_practice_session_responses_table"
+}
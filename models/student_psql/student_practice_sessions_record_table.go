@@ -6,6 +6,12 @@ import (
 	"time"
 )
 
+// Practice session modes
+const (
+	PracticeSessionModeFixed    = "FIXED"
+	PracticeSessionModeAdaptive = "ADAPTIVE"
+)
+
 type StudentPracticeSessionRecordTable struct {
 	// PracticeSessionID = Unique identifier for each practice session
 	PracticeSessionID uint32 `gorm:"primaryKey;not null" json:"sessionId" bson:"sessionId"`
@@ -16,9 +22,24 @@ type StudentPracticeSessionRecordTable struct {
 	// SubDomainID = Sub-category of the questions (e.g., Data Structures, Algebra)
 	SubDomainID uint32 `gorm:"not null" json:"subCategoryID" bson:"subCategoryID" binding:"required"`
 
-	// DifficultyLevelID = DifficultyLevelID level of the session (e.g., Easy, Medium, Hard)
+	// QuestionNicheID = Niche the ability of the student is estimated in, ADAPTIVE sessions only (0 otherwise)
+	QuestionNicheID uint32 `gorm:"not null;default:0" json:"nicheID" bson:"nicheID"`
+
+	// DifficultyLevelID = DifficultyLevelID level of the session (e.g., Easy, Medium, Hard), the one of the first question for ADAPTIVE sessions
 	DifficultyLevelID uint32 `gorm:"not null" json:"difficultyID" bson:"difficultyID" binding:"required"`
 
+	// QuestionFormatID = Format node (MCQ, TF, FIB, TXT, NUM, CODE, MTC, ORD) the questions of the session were served from,
+	// the one of the first question for ADAPTIVE sessions
+	QuestionFormatID uint32 `gorm:"not null;default:0" json:"formatID" bson:"formatID"`
+
+	// Mode = FIXED sessions are served whole at the start, ADAPTIVE sessions one question at a time at the difficulty
+	// matching the ability of the student
+	Mode string `gorm:"type:varchar(8);not null;default:'FIXED';check:mode IN ('FIXED', 'ADAPTIVE')" json:"mode" bson:"mode"`
+
+	// QuestionsServed = Number of questions served to the student at the start of the session,
+	// the number of questions the session will serve for ADAPTIVE sessions
+	QuestionsServed int `gorm:"not null;default:0" json:"questionsServed" bson:"questionsServed"`
+
 	// QuestionsAttempted = Number of questions attempted during the session
 	QuestionsAttempted int `gorm:"not null" json:"questionsAttempted" bson:"questionsAttempted" binding:"required"`
 
@@ -0,0 +1,49 @@
+package triggers
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// NormalizedQuestionTextHash is the SQL expression hashing a question text once lowercased and stripped of
+// punctuation and extra spaces, so that "What is 2 + 2?" and "what is 2+2" hash alike.
+// Takes the column or the placeholder holding the text, the lookups have to hash their text with it too.
+func NormalizedQuestionTextHash(text string) string {
+	return fmt.Sprintf(`md5(btrim(regexp_replace(lower(%s), '[^[:alnum:]]+', ' ', 'g')))`, text)
+}
+
+// MaintainQuestionDuplicateIndexes prepares the question tables for the duplicate lookups of the imports:
+// a generated question_text_hash column for the exact matches (once normalized) and a trigram index on
+// the question text for the similar ones. Rows written before the column existed are hashed when it is added.
+// Safe to run again, everything is only created when missing.
+func MaintainQuestionDuplicateIndexes(db *gorm.DB, tables []string) error {
+	statements := []string{"CREATE EXTENSION IF NOT EXISTS pg_trgm;"}
+
+	for _, table := range tables {
+		tableName := table[strings.LastIndex(table, ".")+1:] // Indexes live in the schema of their table
+		statements = append(statements, fmt.Sprintf(`
+        ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS question_text_hash TEXT
+            GENERATED ALWAYS AS (%[2]s) STORED;
+        CREATE INDEX IF NOT EXISTS idx_%[3]s_question_text_hash ON %[1]s (question_text_hash);
+        CREATE INDEX IF NOT EXISTS idx_%[3]s_question_text_trgm ON %[1]s USING GIN (question_text gin_trgm_ops);`,
+			table, NormalizedQuestionTextHash("question_text"), tableName))
+	}
+
+	return db.Exec(strings.Join(statements, "\n")).Error
+}
+
+func MaintainQuestionDuplicateIndexesRollBack(db *gorm.DB, tables []string) error {
+	// Drop the hash columns and the trigram indexes if rolling back, the extension is left for other uses
+	statements := make([]string, 0, 2*len(tables))
+	for _, table := range tables {
+		schema := table[:strings.LastIndex(table, ".")+1]
+		statements = append(statements,
+			fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS question_text_hash;", table),
+			fmt.Sprintf("DROP INDEX IF EXISTS %sidx_%s_question_text_trgm;", schema, table[len(schema):]),
+		)
+	}
+
+	return db.Exec(strings.Join(statements, "\n")).Error
+}
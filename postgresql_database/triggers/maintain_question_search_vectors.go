@@ -0,0 +1,69 @@
+package triggers
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// questionSearchTriggerName is the name of the trigger on every question table
+const questionSearchTriggerName = "maintain_question_search_vector"
+
+// QuestionSearchConfig is the text search configuration of the question search vectors, the queries have to use it too
+const QuestionSearchConfig = "english"
+
+// MaintainQuestionSearchVectors adds the search_vector column (with its GIN index) to the question tables and keeps
+// it up to date on every insert and update. The question text weighs most, then the options and the explanation.
+// The function reads the row as JSON so that it serves every table, whichever of options/explanation it has.
+// Rows written before the column existed are filled in. Safe to run again, the function and the triggers are replaced.
+func MaintainQuestionSearchVectors(db *gorm.DB, tables []string) error {
+	statements := []string{fmt.Sprintf(`
+        -- Function to compute the search vector of a question of any format
+        CREATE OR REPLACE FUNCTION maintain_question_search_vector()
+        RETURNS TRIGGER AS $$
+        DECLARE
+            question JSONB := to_jsonb(NEW);
+        BEGIN
+            NEW.search_vector :=
+                setweight(to_tsvector('%[1]s', COALESCE(question->>'question_text', '')), 'A') ||
+                setweight(to_tsvector('%[1]s', COALESCE((
+                    SELECT string_agg(option, ' ')
+                    FROM jsonb_array_elements_text(
+                        CASE WHEN jsonb_typeof(question->'options') = 'array' THEN question->'options' ELSE '[]'::JSONB END
+                    ) AS option
+                ), '')), 'B') ||
+                setweight(to_tsvector('%[1]s', COALESCE(question->>'explanation', '')), 'C');
+            RETURN NEW;
+        END;
+        $$ LANGUAGE plpgsql;`, QuestionSearchConfig)}
+
+	for _, table := range tables {
+		indexName := "idx_" + table[strings.LastIndex(table, ".")+1:] + "_search_vector" // Indexes live in the schema of their table
+		statements = append(statements, fmt.Sprintf(`
+        ALTER TABLE %[2]s ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+        CREATE INDEX IF NOT EXISTS %[3]s ON %[2]s USING GIN (search_vector);
+        DROP TRIGGER IF EXISTS %[1]s ON %[2]s;
+        CREATE TRIGGER %[1]s
+        BEFORE INSERT OR UPDATE ON %[2]s
+        FOR EACH ROW
+        EXECUTE FUNCTION maintain_question_search_vector();
+        UPDATE %[2]s SET search_vector = NULL WHERE search_vector IS NULL;`, questionSearchTriggerName, table, indexName))
+	}
+
+	return db.Exec(strings.Join(statements, "\n")).Error
+}
+
+func MaintainQuestionSearchVectorsRollBack(db *gorm.DB, tables []string) error {
+	// Drop the triggers, the columns and the function if rolling back
+	statements := make([]string, 0, 2*len(tables)+1)
+	for _, table := range tables {
+		statements = append(statements,
+			fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s;", questionSearchTriggerName, table),
+			fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS search_vector;", table),
+		)
+	}
+	statements = append(statements, "DROP FUNCTION IF EXISTS maintain_question_search_vector;")
+
+	return db.Exec(strings.Join(statements, "\n")).Error
+}
@@ -0,0 +1,49 @@
+package triggers
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// questionHierarchyTriggerName is the name of the trigger on every watched table
+const questionHierarchyTriggerName = "notify_question_hierarchy_changed"
+
+// NotifyQuestionHierarchyChanges publishes on the channel after every statement that writes one of the tables
+// (the hierarchy and the question tables), so that all the server instances drop their cached hierarchy.
+// The notification is sent on commit, and repeated notifications of a transaction are collapsed by Postgres.
+// Safe to run again, the function and the triggers are replaced.
+func NotifyQuestionHierarchyChanges(db *gorm.DB, channel string, tables []string) error {
+	statements := []string{fmt.Sprintf(`
+        -- Function to tell the listeners that the question hierarchy changed
+        CREATE OR REPLACE FUNCTION notify_question_hierarchy_changed()
+        RETURNS TRIGGER AS $$
+        BEGIN
+            PERFORM pg_notify('%s', TG_TABLE_NAME);
+            RETURN NULL;
+        END;
+        $$ LANGUAGE plpgsql;`, channel)}
+
+	for _, table := range tables {
+		statements = append(statements, fmt.Sprintf(`
+        DROP TRIGGER IF EXISTS %[1]s ON %[2]s;
+        CREATE TRIGGER %[1]s
+        AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON %[2]s
+        FOR EACH STATEMENT
+        EXECUTE FUNCTION notify_question_hierarchy_changed();`, questionHierarchyTriggerName, table))
+	}
+
+	return db.Exec(strings.Join(statements, "\n")).Error
+}
+
+func NotifyQuestionHierarchyChangesRollBack(db *gorm.DB, tables []string) error {
+	// Drop the triggers and the function if rolling back
+	statements := make([]string, 0, len(tables)+1)
+	for _, table := range tables {
+		statements = append(statements, fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s;", questionHierarchyTriggerName, table))
+	}
+	statements = append(statements, "DROP FUNCTION IF EXISTS notify_question_hierarchy_changed;")
+
+	return db.Exec(strings.Join(statements, "\n")).Error
+}
@@ -0,0 +1,190 @@
+package questionBankIO
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	question_type "server/models/question_bank/question_type"
+)
+
+// Zip imports bundle a single question file (markdown, JSON Lines, CSV or XLSX) with the images its records
+// attach to the questions. Images are referred to by their path in the zip, relative to the question file or
+// to the root of the zip. The zip exports are read back the same way, their questions.jsonl refers to the
+// images under images/.
+
+// Bounds on the content of a zip import
+const (
+	maxArchiveFiles      = 500
+	maxQuestionFileBytes = 20 << 20
+)
+
+// questionFileExtensions are the extensions of the question files, every other file of the zip is an image
+var questionFileExtensions = []string{".md", ".markdown", ".jsonl", ".csv", ".xlsx"}
+
+// QuestionArchive is an opened zip import
+type QuestionArchive struct {
+	QuestionFile string // Path of the question file in the zip
+
+	questionFile *zip.File
+	files        map[string]*zip.File // Every other file by path
+}
+
+// OpenQuestionArchive finds the question file of a zip import. The zip must hold exactly one question file,
+// hidden files and the metadata folders added by archivers are ignored.
+func OpenQuestionArchive(reader io.ReaderAt, size int64) (*QuestionArchive, error) {
+	zipReader, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip: %w", err)
+	}
+	if len(zipReader.File) > maxArchiveFiles {
+		return nil, fmt.Errorf("the zip can't hold more than %d files", maxArchiveFiles)
+	}
+
+	archive := &QuestionArchive{files: make(map[string]*zip.File)}
+	var questionFiles []string
+	for _, file := range zipReader.File {
+		name := path.Clean(file.Name)
+		if file.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), ".") {
+			continue
+		}
+
+		if slices.Contains(questionFileExtensions, strings.ToLower(path.Ext(name))) {
+			questionFiles = append(questionFiles, name)
+			archive.QuestionFile, archive.questionFile = name, file
+			continue
+		}
+		archive.files[name] = file
+	}
+
+	switch len(questionFiles) {
+	case 0:
+		return nil, errors.New("the zip holds no question file (.md, .jsonl, .csv or .xlsx)")
+	case 1:
+		return archive, nil
+	default:
+		return nil, fmt.Errorf("the zip must hold a single question file, found %s", strings.Join(questionFiles, ", "))
+	}
+}
+
+// Records parses the question file of the zip. The mapping is used by the CSV and XLSX files only.
+func (a *QuestionArchive) Records(mapping ColumnMapping) ([]QuestionRecord, []EntryError, error) {
+	if a.questionFile.UncompressedSize64 > maxQuestionFileBytes {
+		return nil, nil, fmt.Errorf("the question file can't be larger than %d MB", maxQuestionFileBytes>>20)
+	}
+	file, err := a.questionFile.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", a.QuestionFile, err)
+	}
+	defer file.Close()
+
+	switch strings.ToLower(path.Ext(a.QuestionFile)) {
+	case ".csv", ".xlsx":
+		rows, err := ReadSpreadsheet(a.QuestionFile, io.LimitReader(file, maxQuestionFileBytes), mapping.Sheet)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ParseSpreadsheet(rows, mapping)
+
+	default:
+		content, err := io.ReadAll(io.LimitReader(file, maxQuestionFileBytes))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", a.QuestionFile, err)
+		}
+		if strings.ToLower(path.Ext(a.QuestionFile)) == ".jsonl" {
+			records, entryErrors := ParseJSONLines(string(content))
+			return records, entryErrors, nil
+		}
+		records, entryErrors := ParseMarkdown(string(content))
+		return records, entryErrors, nil
+	}
+}
+
+// ReadImage reads an image of the zip, looked up next to the question file first and then from the root of the zip
+func (a *QuestionArchive) ReadImage(name string) (content []byte, contentType string, err error) {
+	file, exists := a.files[path.Join(path.Dir(a.QuestionFile), name)]
+	if !exists {
+		file, exists = a.files[path.Clean(name)]
+	}
+	if !exists {
+		return nil, "", fmt.Errorf("image %s isn't in the zip", name)
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open image %s: %w", name, err)
+	}
+	defer reader.Close()
+
+	content, contentType, err = question_type.ReadAttachment(reader)
+	if err != nil {
+		return nil, "", fmt.Errorf("image %s: %w", name, err)
+	}
+	return content, contentType, nil
+}
+
+// archiveQuestionFile is the question file of the zip exports
+const archiveQuestionFile = "questions.jsonl"
+
+// zipWriter writes the records of a zip export as a JSON Lines file, followed by the images they attach
+type zipWriter struct {
+	ctx      context.Context
+	archive  *zip.Writer
+	records  *jsonlWriter
+	readFile AttachmentDownloader
+	images   []exportedAttachment // Written on Close, once the question file is complete
+}
+
+func newZipWriter(ctx context.Context, w io.Writer, readFile AttachmentDownloader) (*zipWriter, error) {
+	if readFile == nil {
+		return nil, errors.New("zip exports need a file storage to read the images from")
+	}
+	archive := zip.NewWriter(w)
+	questionFile, err := archive.Create(archiveQuestionFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", archiveQuestionFile, err)
+	}
+	return &zipWriter{ctx: ctx, archive: archive, records: &jsonlWriter{encoder: json.NewEncoder(questionFile)}, readFile: readFile}, nil
+}
+
+// queueImages adds the images of a question to the zip once the question file is written
+func (w *zipWriter) queueImages(attachments []exportedAttachment) {
+	w.images = append(w.images, attachments...)
+}
+
+func (w *zipWriter) Write(record QuestionRecord) error {
+	return w.records.Write(record)
+}
+
+// writeImage copies an image from the file storage to the zip, images are compressed already so they are stored
+func (w *zipWriter) writeImage(image exportedAttachment) error {
+	body, err := w.readFile(w.ctx, image.StorageKey)
+	if err != nil {
+		return fmt.Errorf("failed to read image %d: %w", image.AttachmentID, err)
+	}
+	defer body.Close()
+
+	file, err := w.archive.CreateHeader(&zip.FileHeader{Name: image.archivePath(), Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("failed to add image %d: %w", image.AttachmentID, err)
+	}
+	if _, err := io.Copy(file, io.LimitReader(body, question_type.MaxAttachmentBytes)); err != nil {
+		return fmt.Errorf("failed to write image %d: %w", image.AttachmentID, err)
+	}
+	return nil
+}
+
+func (w *zipWriter) Close() error {
+	for _, image := range w.images {
+		if err := w.writeImage(image); err != nil {
+			return err
+		}
+	}
+	return w.archive.Close()
+}
@@ -0,0 +1,118 @@
+package questionBankIO
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// pngContent is enough of a PNG file for its type to be detected
+var pngContent = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestZipExportRoundTrip(t *testing.T) {
+	stored := map[string][]byte{
+		"question-attachments/3/7/aa.png": pngContent,
+		"question-attachments/3/7/bb.png": append(append([]byte{}, pngContent...), 'x'),
+	}
+	readFile := func(_ context.Context, key string) (io.ReadCloser, error) {
+		content, exists := stored[key]
+		if !exists {
+			return nil, fmt.Errorf("no file under %s", key)
+		}
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+
+	optionIndex := 1
+	row := exportedQuestionRow{
+		DomainName: "Aptitude", SubDomainName: "Data", NicheName: "Charts", DifficultyLevel: "EASY", Format: "MCQ",
+		QuestionText: "Which chart grows fastest?", Answer: "South", Options: []string{"North", "South"},
+		Status: "PUBLISHED", Tags: []string{"charts"},
+		PassageID: 5, PassageTitle: "Sales", PassageBody: "Sales by region.", PassagePosition: 2,
+		Attachments: exportedAttachments{
+			{AttachmentID: 11, StorageKey: "question-attachments/3/7/aa.png", AltText: "Sales chart"},
+			{AttachmentID: 12, StorageKey: "question-attachments/3/7/bb.png", OptionIndex: &optionIndex},
+		},
+	}
+
+	var buffer bytes.Buffer
+	writer, err := newZipWriter(context.Background(), &buffer, readFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.queueImages(row.Attachments)
+	if err := writer.Write(row.toRecord()); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := OpenQuestionArchive(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, entryErrors, err := archive.Records(ColumnMapping{})
+	if err != nil || len(entryErrors) > 0 {
+		t.Fatalf("Records() = %v, %v", entryErrors, err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+
+	record := records[0]
+	if problems := record.Validate(); len(problems) > 0 {
+		t.Fatalf("Validate() = %v", problems)
+	}
+	wantPassage := &PassageRef{Key: "passage-5", Title: "Sales", Body: "Sales by region.", Position: 2}
+	if !reflect.DeepEqual(record.Passage, wantPassage) {
+		t.Errorf("passage = %+v, want %+v", record.Passage, wantPassage)
+	}
+	if record.Status != "PUBLISHED" {
+		t.Errorf("status = %q, want PUBLISHED", record.Status)
+	}
+	wantAttachments := []AttachmentRef{{File: "images/11.png", AltText: "Sales chart"}, {File: "images/12.png", Option: 2}}
+	if !reflect.DeepEqual(record.Attachments, wantAttachments) {
+		t.Fatalf("attachments = %+v, want %+v", record.Attachments, wantAttachments)
+	}
+
+	for i, ref := range record.Attachments {
+		content, contentType, err := archive.ReadImage(ref.File)
+		if err != nil {
+			t.Fatalf("ReadImage(%s): %v", ref.File, err)
+		}
+		if want := stored[row.Attachments[i].StorageKey]; !bytes.Equal(content, want) || contentType != "image/png" {
+			t.Errorf("ReadImage(%s) = %q (%s), want %q (image/png)", ref.File, content, contentType, want)
+		}
+	}
+}
+
+func TestCheckExportedRecord(t *testing.T) {
+	withImage := QuestionRecord{Attachments: []AttachmentRef{{File: "images/1.png"}}}
+	withPassage := QuestionRecord{Passage: &PassageRef{Key: "passage-1", Body: "Body", Position: 1}}
+
+	tests := []struct {
+		name    string
+		record  QuestionRecord
+		format  string
+		wantErr bool
+	}{
+		{"plain record as csv", QuestionRecord{}, ExportFormatCSV, false},
+		{"image as zip", withImage, ExportFormatZip, false},
+		{"image as jsonl", withImage, ExportFormatJSONL, true},
+		{"image as markdown", withImage, ExportFormatMarkdown, true},
+		{"passage as jsonl", withPassage, ExportFormatJSONL, false},
+		{"passage as zip", withPassage, ExportFormatZip, false},
+		{"passage as csv", withPassage, ExportFormatCSV, true},
+		{"passage as markdown", withPassage, ExportFormatMarkdown, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := checkExportedRecord(test.record, test.format); (err != nil) != test.wantErr {
+				t.Errorf("checkExportedRecord() = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package questionBankIO
+
+import (
+	"fmt"
+	"slices"
+
+	question_type "server/models/question_bank/question_type"
+	"server/postgresql_database/triggers"
+
+	"gorm.io/gorm"
+)
+
+// Policies for the entries that look like a question already in the bank
+const (
+	DuplicatePolicyReject = "reject" // Skip the entry and report the questions it duplicates
+	DuplicatePolicyForce  = "force"  // Insert the entry anyway, the questions it duplicates are still reported
+)
+
+// DuplicatePolicies lists the accepted duplicate policies, the first one is the default
+var DuplicatePolicies = []string{DuplicatePolicyReject, DuplicatePolicyForce}
+
+const (
+	// DuplicateSimilarityThreshold is the trigram similarity (0 to 1) from which two question texts are likely duplicates
+	DuplicateSimilarityThreshold = 0.7
+	// maxDuplicateMatches caps the questions reported for a single entry
+	maxDuplicateMatches = 5
+)
+
+// DuplicateMatch is a stored question that an entry likely duplicates
+type DuplicateMatch struct {
+	FormatID     uint32  `json:"formatID"`
+	QuestionID   uint32  `json:"questionID,omitempty"` // Missing for the entries of a dry run, they are never stored
+	Line         int     `json:"line,omitempty"`       // Entry of the same import the question comes from
+	Exact        bool    `json:"exact"`                // Same text once lowercased and stripped of punctuation
+	Similarity   float64 `json:"similarity"`
+	QuestionText string  `json:"questionText"`
+	Link         string  `json:"link,omitempty"` // Endpoint of the stored question
+}
+
+// questionKey identifies a question across the format tables
+type questionKey struct {
+	Format     string
+	QuestionID uint32
+}
+
+// QuestionItemLink is the endpoint serving a stored question
+func QuestionItemLink(formatID uint32, questionID uint32) string {
+	return fmt.Sprintf("/questions/items/%d/%d", formatID, questionID)
+}
+
+// FindDuplicateQuestions looks for the questions of the same format whose text matches questionText once
+// normalized, or is at least DuplicateSimilarityThreshold similar to it, anywhere in the hierarchy.
+// Exact matches come first, then the most similar ones.
+func FindDuplicateQuestions(tx *gorm.DB, format string, questionText string) ([]DuplicateMatch, error) {
+	questionTable, err := question_type.QuestionTableForFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	// The % operator narrows the rows through the trigram index (at its lower default threshold)
+	var matches []DuplicateMatch
+	err = tx.Raw(fmt.Sprintf(`
+		SELECT question_format_id AS format_id, question_id, question_text,
+			question_text_hash = %[2]s AS exact,
+			similarity(question_text, @text) AS similarity
+		FROM %[1]s
+		WHERE question_text_hash = %[2]s
+			OR (question_text %% @text AND similarity(question_text, @text) >= @threshold)
+		ORDER BY exact DESC, similarity DESC, question_id
+		LIMIT @limit`, questionTable, triggers.NormalizedQuestionTextHash("@text")),
+		map[string]interface{}{"text": questionText, "threshold": DuplicateSimilarityThreshold, "limit": maxDuplicateMatches},
+	).Scan(&matches).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to look for duplicates of the %s question: %w", format, err)
+	}
+
+	for i := range matches {
+		matches[i].Link = QuestionItemLink(matches[i].FormatID, matches[i].QuestionID)
+	}
+	return matches, nil
+}
+
+// duplicateProblem describes the matches of a rejected entry
+func duplicateProblem(matches []DuplicateMatch) string {
+	closest := matches[0]
+	source := fmt.Sprintf("question %d of format node %d", closest.QuestionID, closest.FormatID)
+	if closest.Line != 0 {
+		source = fmt.Sprintf("the entry at line %d", closest.Line)
+	}
+
+	problem := fmt.Sprintf("likely duplicate of %s (%.0f%% similar)", source, closest.Similarity*100)
+	if closest.Exact {
+		problem = fmt.Sprintf("duplicate of %s (same text)", source)
+	}
+	if others := len(matches) - 1; others > 0 {
+		problem += fmt.Sprintf(" and %d more", others)
+	}
+	return problem + ", import with duplicates=force to add it anyway"
+}
+
+// ValidateDuplicatePolicy checks a duplicate policy, empty falls back to rejecting the duplicates
+func ValidateDuplicatePolicy(policy string) (string, error) {
+	if policy == "" {
+		return DuplicatePolicies[0], nil
+	}
+	if !slices.Contains(DuplicatePolicies, policy) {
+		return "", fmt.Errorf("invalid duplicates policy %q, expected reject or force", policy)
+	}
+	return policy, nil
+}
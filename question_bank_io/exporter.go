@@ -0,0 +1,679 @@
+package questionBankIO
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	question_hierarchy "server/models/question_bank/question_hierarchy"
+	question_type "server/models/question_bank/question_type"
+	"server/utils"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// Export formats, each one can be imported back without losing anything it carries. JSON Lines carries every field
+// of the questions, their passages included, and the zip bundles it with the images of the questions for the zip
+// import. CSV and markdown leave the passages and the images out, CheckExportScope refuses the scopes that hold any.
+const (
+	ExportFormatJSONL    = "jsonl"
+	ExportFormatZip      = "zip"
+	ExportFormatCSV      = "csv"
+	ExportFormatMarkdown = "markdown"
+)
+
+// ExportFormats lists the supported export formats
+var ExportFormats = []string{ExportFormatJSONL, ExportFormatZip, ExportFormatCSV, ExportFormatMarkdown}
+
+// ErrLossyExport is returned by CheckExportScope when the format can't carry some of the questions of the scope
+var ErrLossyExport = errors.New("the export format can't carry every question of the scope")
+
+// AttachmentDownloader reads the content of an attachment stored under its storage key
+type AttachmentDownloader func(ctx context.Context, key string) (io.ReadCloser, error)
+
+// ExportContentType and ExportFileExtension describe the file of an export format
+var (
+	ExportContentType = map[string]string{
+		ExportFormatJSONL:    "application/x-ndjson",
+		ExportFormatZip:      "application/zip",
+		ExportFormatCSV:      "text/csv",
+		ExportFormatMarkdown: "text/markdown",
+	}
+	ExportFileExtension = map[string]string{
+		ExportFormatJSONL:    ".jsonl",
+		ExportFormatZip:      ".zip",
+		ExportFormatCSV:      ".csv",
+		ExportFormatMarkdown: ".md",
+	}
+)
+
+// exportScopeColumns maps the hierarchy levels to the column that identifies their nodes in the export query
+var exportScopeColumns = map[string]string{
+	"domain":     "d.question_domain_id",
+	"subDomain":  "sd.question_sub_domain_id",
+	"niche":      "n.question_niche_id",
+	"difficulty": "dl.question_difficulty_level_id",
+	"format":     "f.question_format_id",
+}
+
+// ExportScope selects the hierarchy node whose questions are exported, an empty level exports everything
+type ExportScope struct {
+	Level string // domain, subDomain, niche, difficulty or format
+	ID    uint32
+}
+
+// Validate checks the level of the scope
+func (s ExportScope) Validate() error {
+	if s.Level == "" {
+		return nil
+	}
+	if _, exists := exportScopeColumns[s.Level]; !exists {
+		return fmt.Errorf("invalid level %q, expected domain, subDomain, niche, difficulty or format", s.Level)
+	}
+	if s.ID == 0 {
+		return fmt.Errorf("a node ID is required to export a %s", s.Level)
+	}
+	return nil
+}
+
+// noNumericColumns selects empty tolerance and unit for the formats other than NUM
+const noNumericColumns = "0::double precision AS tolerance, FALSE AS is_relative_tolerance, '' AS unit"
+
+// noCodeColumns selects empty language, starter code, test cases and limits for the formats other than CODE
+const noCodeColumns = "'' AS language, '{}'::jsonb AS starter_code, '[]'::jsonb AS test_cases, 0::bigint AS time_limit_ms, 0::bigint AS memory_limit_mb"
+
+// exportedQuestionColumns selects the options, the tolerance and unit, the code content and the explanation of
+// each question table, the formats without them select empty values so that the tables can be combined.
+// The pairs (MTC) and the sequence (ORD) are exported through their answer text.
+var exportedQuestionColumns = map[string]string{
+	"MCQ":  "q.options, q.is_multi_select, " + noNumericColumns + ", " + noCodeColumns + ", q.explanation",
+	"TF":   "NULL::text[] AS options, FALSE AS is_multi_select, " + noNumericColumns + ", " + noCodeColumns + ", q.explanation",
+	"FIB":  "NULL::text[] AS options, FALSE AS is_multi_select, " + noNumericColumns + ", " + noCodeColumns + ", COALESCE(q.explanation, '') AS explanation",
+	"TXT":  "NULL::text[] AS options, FALSE AS is_multi_select, " + noNumericColumns + ", " + noCodeColumns + ", '' AS explanation",
+	"NUM":  "NULL::text[] AS options, FALSE AS is_multi_select, q.tolerance, q.is_relative_tolerance, q.unit, " + noCodeColumns + ", q.explanation",
+	"CODE": "NULL::text[] AS options, FALSE AS is_multi_select, " + noNumericColumns + ", q.language, q.starter_code, q.test_cases, q.time_limit_ms, q.memory_limit_mb, q.explanation",
+	"MTC":  "NULL::text[] AS options, FALSE AS is_multi_select, " + noNumericColumns + ", " + noCodeColumns + ", q.explanation",
+	"ORD":  "NULL::text[] AS options, FALSE AS is_multi_select, " + noNumericColumns + ", " + noCodeColumns + ", q.explanation",
+}
+
+// exportedAttachment is an image of an exported question, read from the JSON array of its attachments
+type exportedAttachment struct {
+	AttachmentID uint32 `json:"attachmentID"`
+	StorageKey   string `json:"storageKey"`
+	OptionIndex  *int   `json:"optionIndex"`
+	AltText      string `json:"altText"`
+}
+
+// archivePath is the path of the image in a zip export, relative to the question file
+func (a exportedAttachment) archivePath() string {
+	return fmt.Sprintf("images/%d%s", a.AttachmentID, path.Ext(a.StorageKey))
+}
+
+// exportedAttachments are the images of an exported question
+type exportedAttachments []exportedAttachment
+
+// Scan reads the attachments from their JSON array
+func (a *exportedAttachments) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("unsupported attachments type %T", value)
+	}
+}
+
+// exportedQuestionRow is a question of any format joined with its hierarchy path, its passage and its images
+type exportedQuestionRow struct {
+	DomainName          string
+	SubDomainName       string
+	NicheName           string
+	DifficultyLevel     string
+	Format              string
+	QuestionFormatID    uint32
+	QuestionID          uint32
+	QuestionText        string
+	Answer              string
+	Options             pq.StringArray
+	IsMultiSelect       bool
+	Tolerance           float64
+	IsRelativeTolerance bool
+	Unit                string
+	Language            string
+	StarterCode         question_type.StarterCode
+	TestCases           question_type.CodeTestCases
+	TimeLimitMs         int
+	MemoryLimitMB       int
+	Explanation         string
+	Status              string
+	Tags                pq.StringArray
+	PassageID           uint32 // 0 when the question isn't grouped under a passage
+	PassageTitle        string
+	PassageBody         string
+	PassagePosition     int
+	Attachments         exportedAttachments
+}
+
+func (row exportedQuestionRow) toRecord() QuestionRecord {
+	record := QuestionRecord{
+		Format:       row.Format,
+		Domain:       row.DomainName,
+		SubDomain:    row.SubDomainName,
+		Niche:        row.NicheName,
+		Difficulty:   row.DifficultyLevel,
+		QuestionText: row.QuestionText,
+		Options:      row.Options,
+		MultiSelect:  row.IsMultiSelect,
+		Answer:       row.Answer,
+		Unit:         row.Unit,
+		Explanation:  row.Explanation,
+		Tags:         row.Tags,
+		Status:       row.Status,
+	}
+	if row.PassageID != 0 {
+		record.Passage = &PassageRef{
+			Key:      fmt.Sprintf("passage-%d", row.PassageID),
+			Title:    row.PassageTitle,
+			Body:     row.PassageBody,
+			Position: row.PassagePosition,
+		}
+	}
+	for _, attachment := range row.Attachments {
+		ref := AttachmentRef{File: attachment.archivePath(), AltText: attachment.AltText}
+		if attachment.OptionIndex != nil {
+			ref.Option = *attachment.OptionIndex + 1
+		}
+		record.Attachments = append(record.Attachments, ref)
+	}
+	if row.Format == "NUM" {
+		record.Tolerance = utils.FormatTolerance(row.Tolerance, row.IsRelativeTolerance)
+	}
+	if row.Format == "CODE" {
+		record.Language, record.StarterCode, record.TestCases = row.Language, row.StarterCode, row.TestCases
+		record.TimeLimitMs, record.MemoryLimitMB = row.TimeLimitMs, row.MemoryLimitMB
+	}
+	return record
+}
+
+// hierarchyJoins joins the hierarchy path of the format node of the rows aliased by alias, as f, dl, n, sd and d
+func hierarchyJoins(alias string) string {
+	return fmt.Sprintf(`
+		JOIN %s f ON f.question_format_id = %s.question_format_id
+		JOIN %s dl ON dl.question_difficulty_level_id = f.question_difficulty_level_id
+		JOIN %s n ON n.question_niche_id = dl.question_niche_id
+		JOIN %s sd ON sd.question_sub_domain_id = n.question_sub_domain_id
+		JOIN %s d ON d.question_domain_id = sd.question_domain_id`,
+		question_hierarchy.QuestionFormatTable{}.TableName(), alias,
+		question_hierarchy.QuestionDifficultyLevelTable{}.TableName(),
+		question_hierarchy.QuestionNicheTable{}.TableName(),
+		question_hierarchy.QuestionSubDomainsTable{}.TableName(),
+		question_hierarchy.QuestionDomainsTable{}.TableName(),
+	)
+}
+
+// exportQuery builds the query of every question under the scope, ordered by their place in the hierarchy
+func exportQuery(scope ExportScope) (string, []interface{}, error) {
+	// The passage of the question, if any
+	passageJoins := fmt.Sprintf(`
+		LEFT JOIN %s pi ON pi.question_format_id = q.question_format_id AND pi.question_id = q.question_id
+		LEFT JOIN %s p ON p.passage_id = pi.passage_id`,
+		question_type.QuestionPassageItem{}.TableName(),
+		question_type.QuestionPassage{}.TableName(),
+	)
+	passageColumns := "COALESCE(p.passage_id, 0) AS passage_id, COALESCE(p.title, '') AS passage_title, " +
+		"COALESCE(p.body, '') AS passage_body, COALESCE(pi.position, 0) AS passage_position"
+
+	// The images of the question as a JSON array, empty when it has none
+	attachmentsColumn := fmt.Sprintf(`
+		COALESCE((
+			SELECT jsonb_agg(jsonb_build_object(
+				'attachmentID', a.attachment_id, 'storageKey', a.storage_key, 'optionIndex', a.option_index, 'altText', a.alt_text
+			) ORDER BY a.attachment_id)
+			FROM %s a
+			WHERE a.question_format_id = q.question_format_id AND a.question_id = q.question_id
+		), '[]'::jsonb) AS attachments`,
+		question_type.QuestionAttachment{}.TableName(),
+	)
+
+	// The tags of the question by name, empty when it has none
+	tagsColumn := fmt.Sprintf(`
+		ARRAY(
+			SELECT t.name FROM %s tl JOIN %s t ON t.tag_id = tl.tag_id
+			WHERE tl.question_format_id = q.question_format_id AND tl.question_id = q.question_id
+			ORDER BY LOWER(t.name)
+		) AS tags`,
+		question_type.QuestionTagLink{}.TableName(),
+		question_type.QuestionTag{}.TableName(),
+	)
+
+	where := ""
+	var args []interface{}
+	if scope.Level != "" {
+		where = fmt.Sprintf("WHERE %s = ?", exportScopeColumns[scope.Level])
+	}
+
+	selects := make([]string, 0, len(question_type.QuestionFormats))
+	for _, format := range question_type.QuestionFormats {
+		questionTable, err := question_type.QuestionTableForFormat(format)
+		if err != nil {
+			return "", nil, err
+		}
+		selects = append(selects, fmt.Sprintf(`
+			SELECT d.domain_name, sd.sub_domain_name, n.niche_name, dl.difficulty_level, f.format,
+				q.question_format_id, q.question_id, q.question_text, q.answer, %s, q.status, %s, %s, %s
+			FROM %s q %s %s
+			%s`,
+			exportedQuestionColumns[format], tagsColumn, passageColumns, attachmentsColumn,
+			questionTable, hierarchyJoins("q"), passageJoins, where,
+		))
+		if scope.Level != "" {
+			args = append(args, scope.ID)
+		}
+	}
+
+	query := strings.Join(selects, " UNION ALL ") +
+		" ORDER BY domain_name, sub_domain_name, niche_name, difficulty_level, format, question_format_id, question_id"
+	return query, args, nil
+}
+
+// recordWriter writes the exported records in one of the export formats
+type recordWriter interface {
+	Write(record QuestionRecord) error
+	Close() error
+}
+
+// CheckExportScope returns ErrLossyExport when questions of the scope hold what the format can't carry:
+// images are only exported by the zip format, passages by the JSON Lines and zip formats.
+// ExportQuestions writes as it reads, so the scope is checked before the export starts.
+func CheckExportScope(db *gorm.DB, scope ExportScope, format string) error {
+	if err := scope.Validate(); err != nil {
+		return err
+	}
+	if format == ExportFormatZip {
+		return nil
+	}
+
+	count := func(table string) (int64, error) {
+		query := db.Table(table + " x").Joins(hierarchyJoins("x"))
+		if scope.Level != "" {
+			query = query.Where(exportScopeColumns[scope.Level]+" = ?", scope.ID)
+		}
+		var count int64
+		err := query.Count(&count).Error
+		return count, err
+	}
+
+	var problems []string
+	images, err := count(question_type.QuestionAttachment{}.TableName())
+	if err != nil {
+		return fmt.Errorf("failed to count images: %w", err)
+	}
+	if images > 0 {
+		problems = append(problems, fmt.Sprintf("%d images are only exported by the zip format", images))
+	}
+	if format != ExportFormatJSONL {
+		grouped, err := count(question_type.QuestionPassageItem{}.TableName())
+		if err != nil {
+			return fmt.Errorf("failed to count passage questions: %w", err)
+		}
+		if grouped > 0 {
+			problems = append(problems, fmt.Sprintf("%d questions grouped under passages are only exported by the jsonl and zip formats", grouped))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrLossyExport, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// newRecordWriter returns the writer of the records in the export format
+func newRecordWriter(ctx context.Context, format string, w io.Writer, readFile AttachmentDownloader) (recordWriter, error) {
+	switch format {
+	case ExportFormatJSONL:
+		return &jsonlWriter{encoder: json.NewEncoder(w)}, nil
+	case ExportFormatZip:
+		return newZipWriter(ctx, w, readFile)
+	case ExportFormatCSV:
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	case ExportFormatMarkdown:
+		return &markdownWriter{writer: w}, nil
+	default:
+		return nil, fmt.Errorf("invalid export format %q, expected jsonl, zip, csv or markdown", format)
+	}
+}
+
+// checkExportedRecord refuses a record holding what the format can't carry, for the questions changed since the
+// scope was checked
+func checkExportedRecord(record QuestionRecord, format string) error {
+	if len(record.Attachments) > 0 && format != ExportFormatZip {
+		return fmt.Errorf("%w: images are only exported by the zip format", ErrLossyExport)
+	}
+	if record.Passage != nil && format != ExportFormatZip && format != ExportFormatJSONL {
+		return fmt.Errorf("%w: passages are only exported by the jsonl and zip formats", ErrLossyExport)
+	}
+	return nil
+}
+
+// ExportQuestions streams the questions under the scope to the writer in the given format, readFile reads the
+// images of the zip exports. Rows are written as they are read, so the export of a large bank doesn't sit in
+// memory (the images of a zip export are read once every question is written).
+func ExportQuestions(db *gorm.DB, scope ExportScope, format string, readFile AttachmentDownloader, w io.Writer) (int, error) {
+	if err := scope.Validate(); err != nil {
+		return 0, err
+	}
+
+	buffered := bufio.NewWriter(w)
+	writer, err := newRecordWriter(db.Statement.Context, format, buffered, readFile)
+	if err != nil {
+		return 0, err
+	}
+
+	query, args, err := exportQuery(scope)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := db.Raw(query, args...).Rows()
+	if err != nil {
+		return 0, fmt.Errorf("failed to query questions: %w", err)
+	}
+	defer rows.Close()
+
+	exported := 0
+	for rows.Next() {
+		var row exportedQuestionRow
+		if err := db.ScanRows(rows, &row); err != nil {
+			return exported, fmt.Errorf("failed to read question: %w", err)
+		}
+		if zipped, isZip := writer.(*zipWriter); isZip {
+			zipped.queueImages(row.Attachments)
+		}
+		record := row.toRecord()
+		if err := checkExportedRecord(record, format); err != nil {
+			return exported, fmt.Errorf("question %d of format node %d: %w", row.QuestionID, row.QuestionFormatID, err)
+		}
+		if err := writer.Write(record); err != nil {
+			return exported, fmt.Errorf("failed to write question %d of format node %d: %w", row.QuestionID, row.QuestionFormatID, err)
+		}
+		exported++
+	}
+	if err := rows.Err(); err != nil {
+		return exported, fmt.Errorf("failed to read questions: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return exported, err
+	}
+	return exported, buffered.Flush()
+}
+
+// maxExportedOptions is the number of option columns of a CSV export, enough for any MCQ question
+const maxExportedOptions = question_type.MaxMCQOptions
+
+// ExportColumnMapping is the column mapping of the CSV exports, used when an import doesn't give one
+var ExportColumnMapping = ColumnMapping{
+	Format:        "Question Type",
+	Domain:        "Category",
+	SubDomain:     "Sub-Domain",
+	Niche:         "Niche",
+	Difficulty:    "Difficulty",
+	QuestionText:  "Question",
+	Options:       exportOptionColumns(),
+	MultiSelect:   "Multi-Select",
+	Answer:        "Answer",
+	Tolerance:     "Tolerance",
+	Unit:          "Unit",
+	Explanation:   "Explanation",
+	Language:      "Language",
+	StarterCode:   "Starter Code",
+	TestCases:     "Test Cases",
+	TimeLimitMs:   "Time Limit (ms)",
+	MemoryLimitMB: "Memory Limit (MB)",
+	Tags:          "Tags",
+	Status:        "Status",
+}
+
+func exportOptionColumns() []string {
+	columns := make([]string, maxExportedOptions)
+	for i := range columns {
+		columns[i] = "Option " + strconv.Itoa(i+1)
+	}
+	return columns
+}
+
+// csvWriter writes the records as rows with the ExportColumnMapping header
+type csvWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func (w *csvWriter) writeHeader() error {
+	header := []string{
+		ExportColumnMapping.Format,
+		ExportColumnMapping.Domain,
+		ExportColumnMapping.SubDomain,
+		ExportColumnMapping.Niche,
+		ExportColumnMapping.Difficulty,
+		ExportColumnMapping.QuestionText,
+	}
+	header = append(header, ExportColumnMapping.Options...)
+	header = append(header, ExportColumnMapping.MultiSelect, ExportColumnMapping.Answer,
+		ExportColumnMapping.Tolerance, ExportColumnMapping.Unit, ExportColumnMapping.Explanation,
+		ExportColumnMapping.Language, ExportColumnMapping.StarterCode, ExportColumnMapping.TestCases,
+		ExportColumnMapping.TimeLimitMs, ExportColumnMapping.MemoryLimitMB, ExportColumnMapping.Tags, ExportColumnMapping.Status)
+
+	w.headerWritten = true
+	return w.writer.Write(header)
+}
+
+func (w *csvWriter) Write(record QuestionRecord) error {
+	if !w.headerWritten {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	if len(record.Options) > maxExportedOptions {
+		return fmt.Errorf("%d options don't fit the %d option columns", len(record.Options), maxExportedOptions)
+	}
+
+	options := make([]string, maxExportedOptions)
+	copy(options, record.Options)
+
+	multiSelect := ""
+	if record.MultiSelect {
+		multiSelect = "true"
+	}
+
+	// The code content of the CODE questions is written as JSON cells
+	var starterCode, testCases, timeLimit, memoryLimit string
+	if record.Format == "CODE" {
+		encodedStarterCode, err := json.Marshal(record.StarterCode)
+		if err != nil {
+			return fmt.Errorf("failed to encode starter code: %w", err)
+		}
+		encodedTestCases, err := json.Marshal(record.TestCases)
+		if err != nil {
+			return fmt.Errorf("failed to encode test cases: %w", err)
+		}
+		starterCode, testCases = string(encodedStarterCode), string(encodedTestCases)
+		timeLimit, memoryLimit = strconv.Itoa(record.TimeLimitMs), strconv.Itoa(record.MemoryLimitMB)
+	}
+
+	row := []string{record.Format, record.Domain, record.SubDomain, record.Niche, record.Difficulty, record.QuestionText}
+	row = append(row, options...)
+	row = append(row, multiSelect, record.Answer, record.Tolerance, record.Unit, record.Explanation,
+		record.Language, starterCode, testCases, timeLimit, memoryLimit, joinTags(record.Tags), record.Status)
+	return w.writer.Write(row)
+}
+
+func (w *csvWriter) Close() error {
+	// An empty export still gets its header so that it can be imported back
+	if !w.headerWritten {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// markdownWriter writes the records in the bulk markdown format read by ParseMarkdown
+type markdownWriter struct {
+	writer io.Writer
+}
+
+// escapeSubcategory escapes the commas of a sub-domain, niche or tag name
+func escapeSubcategory(name string) string {
+	return strings.ReplaceAll(name, ",", `\,`)
+}
+
+// joinTags writes the tags separated by commas, as read by splitSubcategories
+func joinTags(tags []string) string {
+	escaped := make([]string, len(tags))
+	for i, tag := range tags {
+		escaped[i] = escapeSubcategory(tag)
+	}
+	return strings.Join(escaped, ", ")
+}
+
+// writeField writes a field and its continuation lines, escaping the lines the parser would take for
+// a field, a separator or an escape
+func (w *markdownWriter) writeField(field, value string) error {
+	lines := strings.Split(value, "\n")
+	if _, err := fmt.Fprintln(w.writer, strings.TrimRight(field+" "+lines[0], " ")); err != nil {
+		return err
+	}
+
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimSpace(line)
+		if _, _, isField := markdownField(trimmed); isField || trimmed == markdownEntrySeparator || strings.HasPrefix(line, `\`) {
+			line = `\` + line
+		}
+		if _, err := fmt.Fprintln(w.writer, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *markdownWriter) Write(record QuestionRecord) error {
+	if _, err := fmt.Fprintf(w.writer, "%s %s\nCategory: %s\nSubcategories: %s, %s\nDifficulty: %s\n",
+		markdownQuestionType, record.Format, record.Domain,
+		escapeSubcategory(record.SubDomain), escapeSubcategory(record.Niche), record.Difficulty); err != nil {
+		return err
+	}
+	if len(record.Tags) > 0 {
+		if _, err := fmt.Fprintf(w.writer, "Tags: %s\n", joinTags(record.Tags)); err != nil {
+			return err
+		}
+	}
+	if record.Status != "" {
+		if _, err := fmt.Fprintf(w.writer, "Status: %s\n", record.Status); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintln(w.writer); err != nil {
+		return err
+	}
+
+	if err := w.writeField("Question:", record.QuestionText); err != nil {
+		return err
+	}
+	for _, option := range record.Options {
+		if err := w.writeField("Option:", option); err != nil {
+			return err
+		}
+	}
+	if record.MultiSelect {
+		if _, err := fmt.Fprintln(w.writer, "Select: multiple"); err != nil {
+			return err
+		}
+	}
+	if record.Format == "CODE" {
+		if err := w.writeCode(record); err != nil {
+			return err
+		}
+	} else if record.Format == "MCQ" || record.Format == "MTC" || record.Format == "ORD" {
+		// One Answer line per correct option, pair or item
+		for _, answer := range strings.Split(record.Answer, question_type.MCQAnswerSeparator) {
+			if err := w.writeField("Answer:", answer); err != nil {
+				return err
+			}
+		}
+	} else if err := w.writeField("Answer:", record.Answer); err != nil {
+		return err
+	}
+	if record.Tolerance != "" {
+		if err := w.writeField("Tolerance:", record.Tolerance); err != nil {
+			return err
+		}
+	}
+	if record.Unit != "" {
+		if err := w.writeField("Unit:", record.Unit); err != nil {
+			return err
+		}
+	}
+	if record.Explanation != "" {
+		if err := w.writeField("Explanation:", record.Explanation); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w.writer, "%s\n\n", markdownEntrySeparator)
+	return err
+}
+
+// writeCode writes the reference solution and the code content of a CODE question. Code and test data start on
+// the line after their field so that their indentation and leading spaces are kept.
+func (w *markdownWriter) writeCode(record QuestionRecord) error {
+	if err := w.writeField("Language:", record.Language); err != nil {
+		return err
+	}
+	if err := w.writeField("Answer:", "\n"+record.Answer); err != nil {
+		return err
+	}
+	for _, language := range slices.Sorted(maps.Keys(record.StarterCode)) {
+		if err := w.writeField("Starter:", language+"\n"+record.StarterCode[language]); err != nil {
+			return err
+		}
+	}
+	for _, test := range record.TestCases {
+		inputField, outputField := "Test Input:", "Test Output:"
+		if test.IsSample {
+			inputField, outputField = "Sample Input:", "Sample Output:"
+		}
+		if err := w.writeField(inputField, "\n"+test.Input); err != nil {
+			return err
+		}
+		if err := w.writeField(outputField, "\n"+test.ExpectedOutput); err != nil {
+			return err
+		}
+	}
+	if record.TimeLimitMs != 0 {
+		if err := w.writeField("Time Limit:", strconv.Itoa(record.TimeLimitMs)+" ms"); err != nil {
+			return err
+		}
+	}
+	if record.MemoryLimitMB != 0 {
+		if err := w.writeField("Memory Limit:", strconv.Itoa(record.MemoryLimitMB)+" MB"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *markdownWriter) Close() error {
+	return nil
+}
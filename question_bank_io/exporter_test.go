@@ -0,0 +1,176 @@
+package questionBankIO
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	question_type "server/models/question_bank/question_type"
+)
+
+// exportedRecords covers every question format, along with the texts the markdown writer has to escape
+var exportedRecords = []QuestionRecord{
+	{
+		Format: "MCQ", Domain: "Quantitative Aptitude", SubDomain: "Numbers, Primes", Niche: "Basics", Difficulty: "EASY",
+		QuestionText: "Which of these are primes?\n\n---\nAnswer: the primes below 6", Options: []string{"2", "4", "5"},
+		MultiSelect: true, Answer: "2\n5", Explanation: "4 = 2 × 2.", Tags: []string{"primes", "a, b"}, Status: "PUBLISHED",
+	},
+	{
+		Format: "NUM", Domain: "Physics", SubDomain: "Motion", Niche: "Speed", Difficulty: "MEDIUM",
+		QuestionText: "A car covers 25 km in 2 hours. Its speed?", Answer: "12.5", Tolerance: "0.5%", Unit: "km/h",
+		Explanation: "Speed is distance over time.", Status: "PUBLISHED",
+	},
+	{
+		Format: "CODE", Domain: "Programming", SubDomain: "Basics", Niche: "Input", Difficulty: "EASY",
+		QuestionText: "Print the sum of two numbers.", Language: "python",
+		Answer:      "a, b = map(int, input().split())\nprint(a + b)",
+		StarterCode: map[string]string{"python": "def solve():\n    pass", "c": "int main() {\n}"},
+		TestCases: []question_type.CodeTestCase{
+			{Input: "1 2", ExpectedOutput: "3", IsSample: true},
+			{Input: "  5 6", ExpectedOutput: "11"},
+		},
+		TimeLimitMs: 1000, MemoryLimitMB: 128, Status: "IN_REVIEW",
+	},
+	{
+		Format: "MTC", Domain: "General Knowledge", SubDomain: "Geography", Niche: "Capitals", Difficulty: "EASY",
+		QuestionText: "Match the countries to their capitals.", Answer: "France => Paris\nJapan => Tokyo", Status: "DRAFT",
+	},
+	{
+		Format: "ORD", Domain: "Verbal", SubDomain: "Reading", Niche: "Sequences", Difficulty: "HARD",
+		QuestionText: "Arrange the sentences.", Answer: "The alarm rang.\nShe got up.\nShe made coffee.", Status: "RETIRED",
+	},
+	{
+		Format: "TF", Domain: "Physics", SubDomain: "Light", Niche: "Speed", Difficulty: "EASY",
+		QuestionText: "Light is faster than sound.", Answer: "True", Explanation: "By far.", Status: "PUBLISHED",
+	},
+	{
+		Format: "FIB", Domain: "Biology", SubDomain: "Cells", Niche: "Organelles", Difficulty: "EASY",
+		QuestionText: "The ___ is the powerhouse of the cell.", Answer: "mitochondria", Status: "PUBLISHED",
+	},
+	{
+		Format: "TXT", Domain: "Verbal", SubDomain: "Writing", Niche: "Essays", Difficulty: "HARD",
+		QuestionText: "Discuss the quote:\n\\Option: a backslash line\nOption: not an option", Answer: "Any well-argued essay.",
+		Tags: []string{"essays"}, Status: "DRAFT",
+	},
+}
+
+// comparableRecord clears what differs between the parsers without changing the question: the line
+// and empty lists or maps
+func comparableRecord(record QuestionRecord) QuestionRecord {
+	record.Line = 0
+	if len(record.Options) == 0 {
+		record.Options = nil
+	}
+	if len(record.Tags) == 0 {
+		record.Tags = nil
+	}
+	if len(record.StarterCode) == 0 {
+		record.StarterCode = nil
+	}
+	if len(record.TestCases) == 0 {
+		record.TestCases = nil
+	}
+	if len(record.Attachments) == 0 {
+		record.Attachments = nil
+	}
+	return record
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	for i, record := range exportedRecords {
+		if problems := record.Validate(); len(problems) > 0 {
+			t.Fatalf("exported record %d is invalid: %v", i, problems)
+		}
+	}
+
+	tests := []struct {
+		format string
+		parse  func(t *testing.T, content []byte) ([]QuestionRecord, []EntryError)
+	}{
+		{ExportFormatMarkdown, func(t *testing.T, content []byte) ([]QuestionRecord, []EntryError) {
+			return ParseMarkdown(string(content))
+		}},
+		{ExportFormatJSONL, func(t *testing.T, content []byte) ([]QuestionRecord, []EntryError) {
+			return ParseJSONLines(string(content))
+		}},
+		{ExportFormatCSV, func(t *testing.T, content []byte) ([]QuestionRecord, []EntryError) {
+			rows, err := ReadSpreadsheet("questions.csv", bytes.NewReader(content), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			records, entryErrors, err := ParseSpreadsheet(rows, ExportColumnMapping)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return records, entryErrors
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			var buffer bytes.Buffer
+			writer, err := newRecordWriter(context.Background(), test.format, &buffer, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, record := range exportedRecords {
+				if err := writer.Write(record); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			records, entryErrors := test.parse(t, buffer.Bytes())
+			if len(entryErrors) > 0 {
+				t.Fatalf("entry errors = %+v\n%s", entryErrors, buffer.String())
+			}
+			if len(records) != len(exportedRecords) {
+				t.Fatalf("got %d records back, want %d\n%s", len(records), len(exportedRecords), buffer.String())
+			}
+			for i, record := range records {
+				if got, want := comparableRecord(record), comparableRecord(exportedRecords[i]); !reflect.DeepEqual(got, want) {
+					t.Errorf("record %d = %+v\nwant %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExportEmptyCSVKeepsHeader(t *testing.T) {
+	var buffer bytes.Buffer
+	writer, err := newRecordWriter(context.Background(), ExportFormatCSV, &buffer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ReadSpreadsheet("questions.csv", &buffer, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, entryErrors, err := ParseSpreadsheet(rows, ExportColumnMapping)
+	if err != nil || len(records) > 0 || len(entryErrors) > 0 {
+		t.Errorf("ParseSpreadsheet() = %v, %v, %v, want no records", records, entryErrors, err)
+	}
+}
+
+func TestExportScopeValidate(t *testing.T) {
+	tests := []struct {
+		scope   ExportScope
+		wantErr bool
+	}{
+		{ExportScope{}, false},
+		{ExportScope{Level: "niche", ID: 4}, false},
+		{ExportScope{Level: "niche"}, true},
+		{ExportScope{Level: "planet", ID: 1}, true},
+	}
+	for _, test := range tests {
+		if err := test.scope.Validate(); (err != nil) != test.wantErr {
+			t.Errorf("%+v.Validate() = %v, want error %v", test.scope, err, test.wantErr)
+		}
+	}
+}
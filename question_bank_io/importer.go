@@ -0,0 +1,612 @@
+package questionBankIO
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"server/cache"
+	question_hierarchy "server/models/question_bank/question_hierarchy"
+	question_type "server/models/question_bank/question_type"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Statuses of an entry in the import report
+const (
+	EntryStatusAdded   = "added"   // Inserted in the question bank
+	EntryStatusValid   = "valid"   // Would be inserted, reported by dry runs only
+	EntryStatusSkipped = "skipped" // Not inserted, see the errors of the entry
+)
+
+// errDryRunRollback rolls back the import transaction of a dry run
+var errDryRunRollback = errors.New("dry run, rolling back")
+
+// ImportOptions changes how the records are imported
+type ImportOptions struct {
+	DryRun      bool   // Validate and resolve everything without writing to the database
+	Duplicates  string // What to do with the likely duplicates, one of DuplicatePolicies (reject by default)
+	CreateNodes bool   // Create the missing nodes of the hierarchy paths, the entries on unknown paths are skipped otherwise
+	KeepStatus  bool   // Insert the questions in the status of their record (restores of exports), as DRAFT otherwise
+
+	// Zip imports only, the records of the other imports can't have attachments
+	Archive    *QuestionArchive   // Holds the images the records attach
+	StoreFile  AttachmentUploader // Stores the images, not called by dry runs
+	DeleteFile AttachmentRemover  // Removes the images stored for the entries that were rolled back
+	UploadedBy string             // Username recorded on the attachments
+}
+
+// AttachmentUploader stores the content of an attachment under its storage key
+type AttachmentUploader func(ctx context.Context, body io.Reader, key, contentType string) (string, error)
+
+// AttachmentRemover deletes the stored content of an attachment
+type AttachmentRemover func(ctx context.Context, key string) error
+
+// attachmentFile is an image of the zip read for an entry, ready to be stored
+type attachmentFile struct {
+	ref         AttachmentRef
+	content     []byte
+	contentType string
+}
+
+// EntryResult is the outcome of a single entry of the import file
+type EntryResult struct {
+	Line       int      `json:"line"`
+	Status     string   `json:"status"`
+	Errors     []string `json:"errors,omitempty"`
+	FormatID   uint32   `json:"formatID,omitempty"`
+	QuestionID uint32   `json:"questionID,omitempty"`
+	Images     int      `json:"images,omitempty"` // Attachments stored (or that would be stored) with the question
+
+	Duplicates []DuplicateMatch `json:"duplicates,omitempty"` // Questions the entry likely duplicates
+}
+
+// ImportReport summarizes an import, entries are ordered by their line in the file
+type ImportReport struct {
+	DryRun       bool          `json:"dryRun"`
+	TotalEntries int           `json:"totalEntries"`
+	Added        int           `json:"added"`
+	Skipped      int           `json:"skipped"`
+	Duplicates   int           `json:"duplicates"` // Entries flagged as likely duplicates, whether skipped or forced in
+	Entries      []EntryResult `json:"entries"`
+	CreatedNodes []string      `json:"createdNodes"` // Hierarchy nodes created (or that would be created) for the import
+}
+
+// unknownNodeError is returned by the resolvers that aren't allowed to create the missing nodes
+type unknownNodeError struct {
+	path string
+}
+
+func (e *unknownNodeError) Error() string {
+	return fmt.Sprintf("%s doesn't exist in the question hierarchy", e.path)
+}
+
+// hierarchyResolver finds the format node of a record, creating the missing nodes of its path if createNodes is set.
+// Nodes are cached per import so that the entries sharing a path hit the database once.
+type hierarchyResolver struct {
+	tx           *gorm.DB
+	createNodes  bool
+	nodeIDs      map[string]uint32 // Lowercased path of a node → its ID
+	formatPaths  map[uint32]formatNodePath
+	createdNodes []string
+}
+
+// formatNodePath is the hierarchy path of an existing format node
+type formatNodePath struct {
+	DomainName      string
+	SubDomainName   string
+	NicheName       string
+	DifficultyLevel string
+	Format          string
+}
+
+// fillPathFromFormatID fills the hierarchy path of a record given by format ID.
+// problem is set when the record doesn't fit the node, err when the node can't be fetched.
+func (h *hierarchyResolver) fillPathFromFormatID(record *QuestionRecord) (problem string, err error) {
+	path, cached := h.formatPaths[record.FormatID]
+	if !cached {
+		result := h.tx.Table(question_hierarchy.QuestionFormatTable{}.TableName()+" f").
+			Select("d.domain_name, sd.sub_domain_name, n.niche_name, dl.difficulty_level, f.format").
+			Joins("JOIN "+question_hierarchy.QuestionDifficultyLevelTable{}.TableName()+" dl ON dl.question_difficulty_level_id = f.question_difficulty_level_id").
+			Joins("JOIN "+question_hierarchy.QuestionNicheTable{}.TableName()+" n ON n.question_niche_id = dl.question_niche_id").
+			Joins("JOIN "+question_hierarchy.QuestionSubDomainsTable{}.TableName()+" sd ON sd.question_sub_domain_id = n.question_sub_domain_id").
+			Joins("JOIN "+question_hierarchy.QuestionDomainsTable{}.TableName()+" d ON d.question_domain_id = sd.question_domain_id").
+			Where("f.question_format_id = ?", record.FormatID).
+			Scan(&path)
+		if result.Error != nil {
+			return "", fmt.Errorf("failed to fetch format node %d: %w", record.FormatID, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Sprintf("format node %d does not exist", record.FormatID), nil
+		}
+		h.formatPaths[record.FormatID] = path
+	}
+
+	if record.Format != "" && record.Format != path.Format {
+		return fmt.Sprintf("question type %s doesn't match format node %d (%s)", record.Format, record.FormatID, path.Format), nil
+	}
+
+	record.Domain = path.DomainName
+	record.SubDomain = path.SubDomainName
+	record.Niche = path.NicheName
+	record.Difficulty = path.DifficultyLevel
+	record.Format = path.Format
+	return "", nil
+}
+
+// resolveNode returns the ID of the node at the path, creating it with create if no node matches.
+// Names are matched case-insensitively, an unknownNodeError is returned for a missing node the resolver can't create.
+func (h *hierarchyResolver) resolveNode(path []string, find func(id *uint32) *gorm.DB, create func() (uint32, error)) (uint32, error) {
+	key := strings.ToLower(strings.Join(path, "\x00"))
+	if id, cached := h.nodeIDs[key]; cached {
+		return id, nil
+	}
+
+	var id uint32
+	result := find(&id)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if !h.createNodes {
+			return 0, &unknownNodeError{path: strings.Join(path, " > ")}
+		}
+		createdID, err := create()
+		if err != nil {
+			return 0, err
+		}
+		id = createdID
+		h.createdNodes = append(h.createdNodes, strings.Join(path, " > "))
+	}
+
+	h.nodeIDs[key] = id
+	return id, nil
+}
+
+// resolveFormatID walks the path of the record from the domain down to the format node
+func (h *hierarchyResolver) resolveFormatID(record QuestionRecord) (uint32, error) {
+	path := []string{record.Domain}
+	domainID, err := h.resolveNode(path,
+		func(id *uint32) *gorm.DB {
+			return h.tx.Model(&question_hierarchy.QuestionDomainsTable{}).
+				Where("LOWER(domain_name) = LOWER(?)", record.Domain).
+				Limit(1).Pluck("question_domain_id", id)
+		},
+		func() (uint32, error) {
+			domain := question_hierarchy.QuestionDomainsTable{DomainName: record.Domain}
+			err := h.tx.Omit("Domain").Create(&domain).Error
+			return domain.QuestionDomainID, err
+		})
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve domain %q: %w", record.Domain, err)
+	}
+
+	path = append(path, record.SubDomain)
+	subDomainID, err := h.resolveNode(path,
+		func(id *uint32) *gorm.DB {
+			return h.tx.Model(&question_hierarchy.QuestionSubDomainsTable{}).
+				Where("question_domain_id = ? AND LOWER(sub_domain_name) = LOWER(?)", domainID, record.SubDomain).
+				Limit(1).Pluck("question_sub_domain_id", id)
+		},
+		func() (uint32, error) {
+			subDomain := question_hierarchy.QuestionSubDomainsTable{QuestionDomainID: domainID, SubDomainName: record.SubDomain}
+			err := h.tx.Omit("SubDomain").Create(&subDomain).Error
+			return subDomain.QuestionSubDomainID, err
+		})
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve sub-domain %q: %w", record.SubDomain, err)
+	}
+
+	path = append(path, record.Niche)
+	nicheID, err := h.resolveNode(path,
+		func(id *uint32) *gorm.DB {
+			return h.tx.Model(&question_hierarchy.QuestionNicheTable{}).
+				Where("question_sub_domain_id = ? AND LOWER(niche_name) = LOWER(?)", subDomainID, record.Niche).
+				Limit(1).Pluck("question_niche_id", id)
+		},
+		func() (uint32, error) {
+			niche := question_hierarchy.QuestionNicheTable{QuestionSubDomainID: subDomainID, NicheName: record.Niche}
+			err := h.tx.Omit("Niche").Create(&niche).Error
+			return niche.QuestionNicheID, err
+		})
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve niche %q: %w", record.Niche, err)
+	}
+
+	path = append(path, record.Difficulty)
+	difficultyID, err := h.resolveNode(path,
+		func(id *uint32) *gorm.DB {
+			return h.tx.Model(&question_hierarchy.QuestionDifficultyLevelTable{}).
+				Where("question_niche_id = ? AND difficulty_level = ?", nicheID, record.Difficulty).
+				Limit(1).Pluck("question_difficulty_level_id", id)
+		},
+		func() (uint32, error) {
+			difficulty := question_hierarchy.QuestionDifficultyLevelTable{QuestionNicheID: nicheID, DifficultyLevel: record.Difficulty}
+			err := h.tx.Omit("Difficulty").Create(&difficulty).Error
+			return difficulty.QuestionDifficultyLevelID, err
+		})
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve difficulty %q: %w", record.Difficulty, err)
+	}
+
+	path = append(path, record.Format)
+	formatID, err := h.resolveNode(path,
+		func(id *uint32) *gorm.DB {
+			return h.tx.Model(&question_hierarchy.QuestionFormatTable{}).
+				Where("question_difficulty_level_id = ? AND format = ?", difficultyID, record.Format).
+				Limit(1).Pluck("question_format_id", id)
+		},
+		func() (uint32, error) {
+			format := question_hierarchy.QuestionFormatTable{QuestionDifficultyLevelID: difficultyID, Format: record.Format}
+			err := h.tx.Omit("QuestionFormat").Create(&format).Error
+			return format.QuestionFormatID, err
+		})
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve format %q: %w", record.Format, err)
+	}
+
+	return formatID, nil
+}
+
+// importedStatusNote is the note of the status changes logged for the questions imported past DRAFT
+const importedStatusNote = "imported"
+
+// recordImportedStatus logs the status of a question imported past DRAFT, as if it had been moved there
+// by the author of the import
+func recordImportedStatus(tx *gorm.DB, formatID, questionID uint32, status string) error {
+	if status == question_type.QuestionStatusDraft {
+		return nil
+	}
+	change := question_type.QuestionStatusChange{
+		QuestionFormatID: formatID,
+		QuestionID:       questionID,
+		FromStatus:       question_type.QuestionStatusDraft,
+		ToStatus:         status,
+		ChangedBy:        question_type.RevisionAuthor(tx.Statement.Context),
+		Note:             importedStatusNote,
+	}
+	if err := tx.Create(&change).Error; err != nil {
+		return fmt.Errorf("failed to record the %s status: %v", status, err)
+	}
+	return nil
+}
+
+// importedPassage is a passage created by the import, along with the entry that created it
+type importedPassage struct {
+	passageID uint32
+	line      int
+}
+
+// passageResolver creates the passages of an import, once per key, and groups the questions under them
+type passageResolver struct {
+	tx         *gorm.DB
+	passageIDs map[string]importedPassage
+}
+
+// add places an inserted question in the group of the passage of its record, creating the passage on first use
+func (p *passageResolver) add(record QuestionRecord, formatID, questionID uint32) error {
+	if record.Passage == nil {
+		return nil
+	}
+
+	passage, created := p.passageIDs[record.Passage.Key]
+	if !created {
+		model := question_type.QuestionPassage{Title: record.Passage.Title, Body: record.Passage.Body}
+		if err := p.tx.Create(&model).Error; err != nil {
+			return fmt.Errorf("failed to create passage %q: %v", record.Passage.Key, err)
+		}
+		passage = importedPassage{passageID: model.PassageID, line: record.Line}
+		p.passageIDs[record.Passage.Key] = passage
+	}
+
+	var count int64
+	if err := p.tx.Model(&question_type.QuestionPassageItem{}).Where("passage_id = ?", passage.passageID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count the questions of passage %q: %v", record.Passage.Key, err)
+	}
+	if count >= question_type.MaxPassageQuestions {
+		return fmt.Errorf("passage %q can't group more than %d questions", record.Passage.Key, question_type.MaxPassageQuestions)
+	}
+
+	item := question_type.QuestionPassageItem{
+		QuestionFormatID: formatID,
+		QuestionID:       questionID,
+		PassageID:        passage.passageID,
+		Position:         record.Passage.Position,
+	}
+	if err := p.tx.Omit(clause.Associations).Create(&item).Error; err != nil {
+		return fmt.Errorf("failed to add the question to passage %q at position %d: %v", record.Passage.Key, record.Passage.Position, err)
+	}
+	return nil
+}
+
+// forget drops the passages created by an entry that was rolled back, the next entry sharing their key creates them again
+func (p *passageResolver) forget(line int) {
+	maps.DeleteFunc(p.passageIDs, func(_ string, passage importedPassage) bool { return passage.line == line })
+}
+
+// readAttachmentFiles reads the images the record attaches from the zip of the import
+func readAttachmentFiles(record QuestionRecord, archive *QuestionArchive) ([]attachmentFile, []string) {
+	if len(record.Attachments) == 0 {
+		return nil, nil
+	}
+	if archive == nil {
+		return nil, []string{"images can only be imported from a zip that bundles them with the question file"}
+	}
+
+	files := make([]attachmentFile, 0, len(record.Attachments))
+	var problems []string
+	for _, ref := range record.Attachments {
+		content, contentType, err := archive.ReadImage(ref.File)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		files = append(files, attachmentFile{ref: ref, content: content, contentType: contentType})
+	}
+	return files, problems
+}
+
+// storeAttachmentFiles uploads the images of an inserted question and records them as its attachments.
+// The storage keys of the uploaded files are returned on failure too, for the caller to delete them.
+func storeAttachmentFiles(tx *gorm.DB, formatID, questionID uint32, files []attachmentFile, options ImportOptions) ([]string, error) {
+	storageKeys := make([]string, 0, len(files))
+	for _, file := range files {
+		attachment := question_type.QuestionAttachment{
+			QuestionFormatID: formatID,
+			QuestionID:       questionID,
+			FileName:         path.Base(file.ref.File),
+			ContentType:      file.contentType,
+			SizeBytes:        int64(len(file.content)),
+			AltText:          file.ref.AltText,
+			UploadedBy:       options.UploadedBy,
+		}
+		if file.ref.Option > 0 {
+			optionIndex := int32(file.ref.Option - 1)
+			attachment.OptionIndex = &optionIndex
+		}
+
+		var err error
+		if attachment.StorageKey, err = question_type.NewAttachmentStorageKey(formatID, questionID, file.contentType); err != nil {
+			return storageKeys, err
+		}
+		if err := tx.Create(&attachment).Error; err != nil {
+			return storageKeys, fmt.Errorf("failed to store image %s: %v", file.ref.File, err)
+		}
+		if options.StoreFile == nil {
+			return storageKeys, fmt.Errorf("failed to upload image %s: no file storage", file.ref.File)
+		}
+		if _, err := options.StoreFile(tx.Statement.Context, bytes.NewReader(file.content), attachment.StorageKey, file.contentType); err != nil {
+			return storageKeys, fmt.Errorf("failed to upload image %s: %v", file.ref.File, err)
+		}
+		storageKeys = append(storageKeys, attachment.StorageKey)
+	}
+	return storageKeys, nil
+}
+
+// deleteStoredFiles removes the images uploaded for entries that were rolled back.
+// The rows are gone with the rollback, a file that can't be deleted is only logged.
+func deleteStoredFiles(ctx context.Context, storageKeys []string, options ImportOptions) {
+	if options.DeleteFile == nil {
+		return
+	}
+	for _, key := range storageKeys {
+		if err := options.DeleteFile(ctx, key); err != nil {
+			log.Printf("Failed to delete imported image %s: %v", key, err)
+		}
+	}
+}
+
+// ImportRecords validates the parsed records and inserts them in the question bank in a single transaction.
+// The entries that failed parsing (parseErrors) are reported as skipped along with the invalid records
+// and the ones that fail to insert.
+// Every entry is checked for duplicates among the questions of its format, including the earlier entries
+// of the same import, and options.Duplicates tells whether the likely duplicates are skipped or inserted.
+// The images attached by the records are read from options.Archive and stored with their question, an entry
+// whose images can't be read or stored is skipped, and the images stored for the entries rolled back are deleted.
+// The tags of the records are linked to their question, the missing tags are created.
+// The records sharing a passage key are grouped under a passage created by the import.
+// Questions are inserted as DRAFT, unless options.KeepStatus restores the status of the records; a question imported
+// in another status than DRAFT gets the change logged under the revision author of the context.
+// The missing hierarchy nodes are only created with options.CreateNodes, the entries on unknown paths are skipped otherwise.
+// Errors returned are the ones that stop the whole import, like a failure to create a hierarchy node.
+func ImportRecords(db *gorm.DB, records []QuestionRecord, parseErrors []EntryError, options ImportOptions) (ImportReport, error) {
+	report := ImportReport{DryRun: options.DryRun, CreatedNodes: []string{}}
+
+	duplicatePolicy, err := ValidateDuplicatePolicy(options.Duplicates)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	for _, parseError := range parseErrors {
+		report.Entries = append(report.Entries, EntryResult{Line: parseError.Line, Status: EntryStatusSkipped, Errors: parseError.Errors})
+	}
+
+	addedStatus := EntryStatusAdded
+	if options.DryRun {
+		addedStatus = EntryStatusValid
+	}
+
+	var storedFiles []string // Storage keys of the images uploaded by the import, deleted if it fails
+	err = db.Transaction(func(tx *gorm.DB) error {
+		resolver := &hierarchyResolver{tx: tx, createNodes: options.CreateNodes, nodeIDs: make(map[string]uint32), formatPaths: make(map[uint32]formatNodePath)}
+		insertedLines := make(map[questionKey]int) // Questions inserted by this import → line of their entry
+		passages := &passageResolver{tx: tx, passageIDs: make(map[string]importedPassage)}
+
+		for _, record := range records {
+			skip := func(problems ...string) {
+				report.Entries = append(report.Entries, EntryResult{Line: record.Line, Status: EntryStatusSkipped, Errors: problems})
+			}
+
+			// Imported questions go through review like the written ones
+			if !options.KeepStatus {
+				record.Status = ""
+			}
+
+			// Records placed by format ID take their path from the existing node
+			if record.FormatID != 0 {
+				problem, err := resolver.fillPathFromFormatID(&record)
+				if err != nil {
+					return fmt.Errorf("entry at line %d: %w", record.Line, err)
+				}
+				if problem != "" {
+					skip(problem)
+					continue
+				}
+			}
+
+			if problems := record.Validate(); len(problems) > 0 {
+				skip(problems...)
+				continue
+			}
+
+			formatID := record.FormatID
+			if formatID == 0 {
+				var err error
+				if formatID, err = resolver.resolveFormatID(record); err != nil {
+					var unknownNode *unknownNodeError
+					if errors.As(err, &unknownNode) {
+						skip(unknownNode.Error() + ", only admins can create hierarchy nodes")
+						continue
+					}
+					return fmt.Errorf("entry at line %d: %w", record.Line, err)
+				}
+			}
+
+			question, err := record.toModel(formatID)
+			if err != nil {
+				skip(err.Error())
+				continue
+			}
+
+			// The images are read before the insert so that a missing or invalid one skips the entry
+			files, problems := readAttachmentFiles(record, options.Archive)
+			if len(problems) > 0 {
+				skip(problems...)
+				continue
+			}
+
+			// The entries of the import are inserted as they go (rolled back at the end of a dry run),
+			// so the lookup finds the duplicates within the import too
+			duplicates, err := FindDuplicateQuestions(tx, record.Format, record.QuestionText)
+			if err != nil {
+				return fmt.Errorf("entry at line %d: %w", record.Line, err)
+			}
+			for i, duplicate := range duplicates {
+				if line, fromImport := insertedLines[questionKey{record.Format, duplicate.QuestionID}]; fromImport {
+					duplicates[i].Line = line
+					if options.DryRun {
+						duplicates[i].QuestionID, duplicates[i].Link = 0, ""
+					}
+				}
+			}
+			if len(duplicates) > 0 && duplicatePolicy == DuplicatePolicyReject {
+				report.Entries = append(report.Entries, EntryResult{
+					Line:       record.Line,
+					Status:     EntryStatusSkipped,
+					Errors:     []string{duplicateProblem(duplicates)},
+					Duplicates: duplicates,
+				})
+				continue
+			}
+
+			// A failed insert only rolls back its own entry, the rest of the import goes on
+			savePoint := fmt.Sprintf("entry_%d", record.Line)
+			if err := tx.SavePoint(savePoint).Error; err != nil {
+				return fmt.Errorf("failed to create savepoint: %w", err)
+			}
+			skipAndRollBack := func(problem string) error {
+				if err := tx.RollbackTo(savePoint).Error; err != nil {
+					return fmt.Errorf("failed to roll back entry at line %d: %w", record.Line, err)
+				}
+				passages.forget(record.Line)
+				skip(problem)
+				return nil
+			}
+
+			if err := tx.Create(question).Error; err != nil {
+				if err := skipAndRollBack(fmt.Sprintf("failed to insert %s question: %v", record.hierarchyPath(), err)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			questionID := insertedQuestionID(question)
+			if err := recordImportedStatus(tx, formatID, questionID, record.status()); err != nil {
+				if err := skipAndRollBack(err.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+			tags, _ := question_type.NormalizeTagNames(record.Tags) // Validated with the record
+			if err := question_type.TagQuestion(tx, formatID, questionID, tags); err != nil {
+				if err := skipAndRollBack(err.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := passages.add(record, formatID, questionID); err != nil {
+				if err := skipAndRollBack(err.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+			if !options.DryRun {
+				storageKeys, err := storeAttachmentFiles(tx, formatID, questionID, files, options)
+				if err != nil {
+					deleteStoredFiles(tx.Statement.Context, storageKeys, options)
+					if err := skipAndRollBack(err.Error()); err != nil {
+						return err
+					}
+					continue
+				}
+				storedFiles = append(storedFiles, storageKeys...)
+			}
+			insertedLines[questionKey{record.Format, questionID}] = record.Line
+
+			entry := EntryResult{Line: record.Line, Status: addedStatus, FormatID: formatID, Images: len(files), Duplicates: duplicates}
+			if !options.DryRun {
+				entry.QuestionID = questionID
+			}
+			report.Entries = append(report.Entries, entry)
+		}
+
+		report.CreatedNodes = resolver.createdNodes
+		if options.DryRun {
+			return errDryRunRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRunRollback) {
+		deleteStoredFiles(db.Statement.Context, storedFiles, options)
+		return ImportReport{}, err
+	}
+	if !options.DryRun {
+		cache.InvalidateQuestionHierarchy()
+	}
+
+	slices.SortFunc(report.Entries, func(a, b EntryResult) int { return a.Line - b.Line })
+	report.TotalEntries = len(report.Entries)
+	for _, entry := range report.Entries {
+		if entry.Status == EntryStatusSkipped {
+			report.Skipped++
+		} else {
+			report.Added++
+		}
+		if len(entry.Duplicates) > 0 {
+			report.Duplicates++
+		}
+	}
+	if report.CreatedNodes == nil {
+		report.CreatedNodes = []string{}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,49 @@
+package questionBankIO
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonlWriter writes one QuestionRecord JSON object per line
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonlWriter) Write(record QuestionRecord) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *jsonlWriter) Close() error {
+	return nil
+}
+
+// ParseJSONLines reads one QuestionRecord JSON object per line, blank lines are ignored.
+// Lines that aren't valid JSON are returned as entry errors.
+func ParseJSONLines(input string) ([]QuestionRecord, []EntryError) {
+	var records []QuestionRecord
+	var entryErrors []EntryError
+
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	scanner.Buffer(nil, len(input)+1) // A single question may be longer than the default line limit
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var record QuestionRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			entryErrors = append(entryErrors, EntryError{Line: lineNumber, Errors: []string{fmt.Sprintf("invalid JSON: %v", err)}})
+			continue
+		}
+		record.Line = lineNumber
+		record.Normalize()
+		records = append(records, record)
+	}
+
+	return records, entryErrors
+}
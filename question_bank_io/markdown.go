@@ -0,0 +1,375 @@
+package questionBankIO
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	question_type "server/models/question_bank/question_type"
+)
+
+// Markdown bulk format, entries are separated by a line holding only "---":
+//
+// ### Question Type: MCQ
+// Category: Quantitative Aptitude
+// Subcategories: Arithmetic, Basic Operations
+// Difficulty: Easy
+// Tags: arithmetic, simplification
+// Status: active
+//
+// Question: Simplify: (10+6)×2−4÷2
+// Option: 20
+// Option: 30
+// Answer: 30
+// Explanation: Use BODMAS rules to simplify the expression.
+// ---
+//
+// MCQ questions take 2 to 6 options. A multi-select question repeats "Answer:" for every correct option,
+// "Select: multiple" makes it multi-select even with a single correct option.
+//
+// NUM questions take a number as their answer ("1/6", "0.25", "12.5%"), with an optional "Tolerance:"
+// ("0.01" absolute or "0.5%" of the answer, 0.1% when left out) and an optional "Unit:" (e.g. "km/h").
+//
+// MTC questions repeat "Answer:" for every pair, written "<column A> => <column B>", in any order.
+// ORD questions repeat "Answer:" for every item, in their correct order. Both take 2 to 10 pairs or items,
+// which are shuffled when served:
+//
+// ### Question Type: ORD
+// Question: Arrange the sentences to form a coherent paragraph.
+// Answer: The alarm rang at six.
+// Answer: She got out of bed.
+// Answer: She made herself a coffee.
+//
+// CODE questions take their reference solution as the answer, written in the language given by "Language:"
+// (one of python, c, cpp, java, javascript). "Starter:" gives the starter code of the language named on its line.
+// Every test case is an input followed by its expected output, "Sample Input:"/"Sample Output:" for the ones
+// shown to the students and "Test Input:"/"Test Output:" for the hidden ones. "Time Limit:" (ms, 2000 when left
+// out) and "Memory Limit:" (MB, 256 when left out) apply to every test case. Code and test data go on the lines
+// after their field so that their indentation is kept:
+//
+// Language: python
+// Answer:
+// a, b = map(int, input().split())
+// print(a + b)
+// Sample Input:
+// 1 2
+// Sample Output:
+// 3
+//
+// Zip imports bundle the file with the images of the questions. "Image:" gives the path of an image in the zip,
+// optionally followed by its alt text after "|". It belongs to the option it follows, to the question otherwise:
+//
+// Question: Which chart shows the highest growth in 2023?
+// Image: charts/sales.png | Sales by region, 2019 to 2023
+// Option: North
+// Image: charts/north.png
+//
+// "Status:" gives the lifecycle status of the question (DRAFT, IN_REVIEW, PUBLISHED or RETIRED), questions without
+// one land as drafts. "active", found in the older files, is read as DRAFT.
+//
+// Questions, options and explanations are rich text, Markdown along with $...$ math (see utils.ValidateRichText).
+//
+// A line that doesn't start with a known field continues the previous text field, so question texts
+// and explanations can span multiple lines (blank lines included). A continuation line that would
+// otherwise look like a field (or a separator) is escaped with a backslash at its very start.
+// Commas in the names of the subcategories and of the tags are escaped as "\,".
+
+const (
+	markdownEntrySeparator = "---"
+	markdownQuestionType   = "### Question Type:"
+)
+
+// markdownFields are the field prefixes of an entry, in the order they are written
+var markdownFields = []string{
+	markdownQuestionType,
+	"Category:",
+	"Subcategories:",
+	"Difficulty:",
+	"Tags:",
+	"Status:",
+	"Question:",
+	"Option:",
+	"Image:",
+	"Select:",
+	"Answer:",
+	"Tolerance:",
+	"Unit:",
+	"Language:",
+	"Starter:",
+	"Sample Input:",
+	"Sample Output:",
+	"Test Input:",
+	"Test Output:",
+	"Time Limit:",
+	"Memory Limit:",
+	"Explanation:",
+}
+
+// repeatedMarkdownFields are the fields an entry can give more than once
+var repeatedMarkdownFields = map[string]bool{
+	"Option:":        true,
+	"Image:":         true,
+	"Answer:":        true,
+	"Starter:":       true,
+	"Sample Input:":  true,
+	"Sample Output:": true,
+	"Test Input:":    true,
+	"Test Output:":   true,
+}
+
+// EntryError holds the problems found in an entry of an import file
+type EntryError struct {
+	Line   int      `json:"line"`
+	Errors []string `json:"errors"`
+}
+
+// markdownField splits a line into its field prefix and value, ok is false for continuation lines
+func markdownField(line string) (field string, value string, ok bool) {
+	for _, prefix := range markdownFields {
+		if strings.HasPrefix(line, prefix) {
+			return prefix, strings.TrimSpace(strings.TrimPrefix(line, prefix)), true
+		}
+	}
+	return "", "", false
+}
+
+// markdownEntry accumulates the lines of one entry while it is parsed
+type markdownEntry struct {
+	record       QuestionRecord
+	seen         map[string]bool
+	lastField    string
+	problems     []string
+	hasContent   bool
+	pendingBlank int    // Blank lines seen since the last line, kept only if the text field goes on
+	starter      string // Language of the starter code being read
+	hasOutput    bool   // The last test case has its expected output
+	imageOption  int    // Option the images being read belong to, counting from 1, the question itself when 0
+}
+
+func newMarkdownEntry(line int) *markdownEntry {
+	return &markdownEntry{record: QuestionRecord{Line: line}, seen: make(map[string]bool)}
+}
+
+// appendContinuation appends a continuation line to the text field that precedes it
+func (e *markdownEntry) appendContinuation(line string) {
+	line = strings.Repeat("\n", e.pendingBlank) + line
+	e.pendingBlank = 0
+
+	appendLine := func(text string) string {
+		if text == "" {
+			return line
+		}
+		return text + "\n" + line
+	}
+
+	switch e.lastField {
+	case "Question:":
+		e.record.QuestionText = appendLine(e.record.QuestionText)
+	case "Answer:":
+		e.record.Answer = appendLine(e.record.Answer)
+	case "Explanation:":
+		e.record.Explanation = appendLine(e.record.Explanation)
+	case "Option:":
+		last := len(e.record.Options) - 1
+		e.record.Options[last] = appendLine(e.record.Options[last])
+	case "Starter:":
+		e.record.StarterCode[e.starter] = appendLine(e.record.StarterCode[e.starter])
+	case "Sample Input:", "Test Input:":
+		last := len(e.record.TestCases) - 1
+		e.record.TestCases[last].Input = appendLine(e.record.TestCases[last].Input)
+	case "Sample Output:", "Test Output:":
+		last := len(e.record.TestCases) - 1
+		e.record.TestCases[last].ExpectedOutput = appendLine(e.record.TestCases[last].ExpectedOutput)
+	default:
+		e.problems = append(e.problems, "unexpected line outside of a field: "+line)
+	}
+}
+
+// setField stores the value of a field line in the record
+func (e *markdownEntry) setField(field, value string) {
+	if e.seen[field] && !repeatedMarkdownFields[field] {
+		e.problems = append(e.problems, "duplicate field "+strings.TrimSuffix(field, ":"))
+	}
+	e.seen[field] = true
+	e.lastField = field
+	e.pendingBlank = 0
+	if field != "Image:" {
+		e.imageOption = 0
+	}
+
+	switch field {
+	case markdownQuestionType:
+		e.record.Format = value
+	case "Category:":
+		e.record.Domain = value
+	case "Subcategories:":
+		subcategories := splitSubcategories(value)
+		if len(subcategories) > 2 {
+			e.problems = append(e.problems, "Subcategories must be \"<sub-domain>, <niche>\"")
+		}
+		if len(subcategories) > 0 {
+			e.record.SubDomain = subcategories[0]
+		}
+		if len(subcategories) > 1 {
+			e.record.Niche = subcategories[1]
+		}
+	case "Difficulty:":
+		e.record.Difficulty = value
+	case "Tags:":
+		e.record.Tags = splitSubcategories(value)
+	case "Question:":
+		e.record.QuestionText = value
+	case "Option:":
+		e.record.Options = append(e.record.Options, value)
+		e.imageOption = len(e.record.Options)
+	case "Image:":
+		attachment := parseAttachmentRef(value)
+		attachment.Option = e.imageOption
+		e.record.Attachments = append(e.record.Attachments, attachment)
+	case "Select:":
+		switch strings.ToLower(value) {
+		case "single":
+		case "multiple":
+			e.record.MultiSelect = true
+		default:
+			e.problems = append(e.problems, "Select must be single or multiple, got "+value)
+		}
+	case "Answer:":
+		// Every correct option of a multi-select question, pair (MTC) or item (ORD) has its own Answer line
+		if e.record.Answer != "" {
+			value = e.record.Answer + question_type.MCQAnswerSeparator + value
+		}
+		e.record.Answer = value
+	case "Tolerance:":
+		e.record.Tolerance = value
+	case "Unit:":
+		e.record.Unit = value
+	case "Language:":
+		e.record.Language = value
+	case "Starter:":
+		e.starter = strings.ToLower(value)
+		if e.record.StarterCode == nil {
+			e.record.StarterCode = make(map[string]string)
+		}
+		if _, exists := e.record.StarterCode[e.starter]; exists {
+			e.problems = append(e.problems, "duplicate starter code for "+value)
+		}
+		e.record.StarterCode[e.starter] = ""
+	case "Sample Input:", "Test Input:":
+		e.record.TestCases = append(e.record.TestCases, question_type.CodeTestCase{Input: value, IsSample: field == "Sample Input:"})
+		e.hasOutput = false
+	case "Sample Output:", "Test Output:":
+		last := len(e.record.TestCases) - 1
+		if last < 0 || e.hasOutput || e.record.TestCases[last].IsSample != (field == "Sample Output:") {
+			inputField := strings.Replace(field, "Output", "Input", 1)
+			e.problems = append(e.problems, fmt.Sprintf("%s must follow its %s", strings.TrimSuffix(field, ":"), strings.TrimSuffix(inputField, ":")))
+			e.lastField = "" // Its continuation lines are reported, not stored
+			return
+		}
+		e.record.TestCases[last].ExpectedOutput = value
+		e.hasOutput = true
+	case "Time Limit:":
+		e.record.TimeLimitMs = e.parseLimit(field, value, "ms")
+	case "Memory Limit:":
+		e.record.MemoryLimitMB = e.parseLimit(field, value, "mb")
+	case "Explanation:":
+		e.record.Explanation = value
+	case "Status:":
+		e.record.Status = value
+	}
+}
+
+// parseLimit reads a limit given as a positive number, optionally followed by its unit
+func (e *markdownEntry) parseLimit(field, value, unit string) int {
+	number := strings.TrimSpace(strings.TrimSuffix(strings.ToLower(value), unit))
+	limit, err := strconv.Atoi(number)
+	if err != nil || limit <= 0 {
+		e.problems = append(e.problems, fmt.Sprintf("%s must be a positive number of %s, got %q", strings.TrimSuffix(field, ":"), unit, value))
+		return 0
+	}
+	return limit
+}
+
+// splitSubcategories splits the subcategories (or the tags) on the commas that aren't escaped
+func splitSubcategories(value string) []string {
+	var subcategories []string
+	var current strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && i+1 < len(value) && value[i+1] == ',':
+			current.WriteByte(',')
+			i++
+		case value[i] == ',':
+			subcategories = append(subcategories, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(value[i])
+		}
+	}
+	return append(subcategories, current.String())
+}
+
+// finish returns the record of the entry or the problems found while reading it.
+// The record itself is validated on import.
+func (e *markdownEntry) finish() (QuestionRecord, *EntryError) {
+	if !e.seen[markdownQuestionType] {
+		e.problems = append(e.problems, "missing \"### Question Type:\" header")
+	}
+
+	e.record.Normalize()
+	if len(e.problems) > 0 {
+		return e.record, &EntryError{Line: e.record.Line, Errors: e.problems}
+	}
+	return e.record, nil
+}
+
+// ParseMarkdown parses the bulk markdown format into question records.
+// Entries that can't be read are returned as entry errors along with the line they start at.
+func ParseMarkdown(input string) ([]QuestionRecord, []EntryError) {
+	var records []QuestionRecord
+	var entryErrors []EntryError
+
+	var entry *markdownEntry
+	finishEntry := func() {
+		if entry != nil && entry.hasContent {
+			record, entryError := entry.finish()
+			if entryError != nil {
+				entryErrors = append(entryErrors, *entryError)
+			} else {
+				records = append(records, record)
+			}
+		}
+		entry = nil
+	}
+
+	for i, rawLine := range strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n") {
+		lineNumber := i + 1
+		line := strings.TrimSpace(rawLine)
+
+		if line == markdownEntrySeparator {
+			finishEntry()
+			continue
+		}
+		if line == "" {
+			if entry != nil {
+				entry.pendingBlank++
+			}
+			continue
+		}
+
+		if entry == nil {
+			entry = newMarkdownEntry(lineNumber)
+		}
+		entry.hasContent = true
+
+		if field, value, ok := markdownField(line); ok {
+			entry.setField(field, value)
+		} else {
+			// Continuation lines keep their indentation
+			entry.appendContinuation(strings.TrimPrefix(strings.TrimRight(rawLine, " \t\r"), `\`))
+		}
+	}
+	finishEntry()
+
+	return records, entryErrors
+}
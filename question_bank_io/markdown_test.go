@@ -0,0 +1,177 @@
+package questionBankIO
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantRecords []QuestionRecord
+		wantErrors  []int // Lines of the entries that fail to parse
+	}{
+		{
+			name: "MCQ entry",
+			input: `### Question Type: MCQ
+Category: Quantitative Aptitude
+Subcategories: Arithmetic, Basic Operations
+Difficulty: Easy
+Status: active
+
+Question: Simplify: (10+6)×2−4÷2
+Option: 20
+Option: 30
+Answer: 30
+Explanation: Use BODMAS rules to simplify the expression.
+---
+`,
+			wantRecords: []QuestionRecord{{
+				Line: 1, Format: "MCQ", Domain: "Quantitative Aptitude", SubDomain: "Arithmetic", Niche: "Basic Operations",
+				Difficulty: "EASY", QuestionText: "Simplify: (10+6)×2−4÷2", Options: []string{"20", "30"}, Answer: "30",
+				Explanation: "Use BODMAS rules to simplify the expression.", Status: "DRAFT",
+			}},
+		},
+		{
+			name: "format alias, escaped commas and tags",
+			input: `### Question Type: FB
+Category: Verbal
+Subcategories: Grammar\, Usage, Articles
+Difficulty: medium
+Tags: basics, a\,b
+Question: ___ apple a day.
+Answer: An
+`,
+			wantRecords: []QuestionRecord{{
+				Line: 1, Format: "FIB", Domain: "Verbal", SubDomain: "Grammar, Usage", Niche: "Articles",
+				Difficulty: "MEDIUM", QuestionText: "___ apple a day.", Answer: "An", Tags: []string{"basics", "a,b"},
+			}},
+		},
+		{
+			name: "multi-line texts and escaped field lines",
+			input: `### Question Type: TXT
+Category: Verbal
+Subcategories: Writing, Essays
+Difficulty: Hard
+Question: Write about the quote:
+
+\Answer: is not a field here
+    indented line
+Answer: Any
+`,
+			wantRecords: []QuestionRecord{{
+				Line: 1, Format: "TXT", Domain: "Verbal", SubDomain: "Writing", Niche: "Essays", Difficulty: "HARD",
+				QuestionText: "Write about the quote:\n\nAnswer: is not a field here\n    indented line", Answer: "Any",
+			}},
+		},
+		{
+			name: "multi-select answers",
+			input: `### Question Type: MCQ
+Category: Science
+Subcategories: Chemistry, Elements
+Difficulty: Easy
+Question: Which are noble gases?
+Option: Neon
+Option: Oxygen
+Option: Argon
+Answer: Neon
+Answer: Argon
+`,
+			wantRecords: []QuestionRecord{{
+				Line: 1, Format: "MCQ", Domain: "Science", SubDomain: "Chemistry", Niche: "Elements", Difficulty: "EASY",
+				QuestionText: "Which are noble gases?", Options: []string{"Neon", "Oxygen", "Argon"}, Answer: "Neon\nArgon",
+			}},
+		},
+		{
+			name: "entries report the line they start at",
+			input: `### Question Type: TF
+Category: Science
+Subcategories: Physics, Motion
+Difficulty: Easy
+Question: Light is faster than sound.
+Answer: True
+---
+Category: Science
+Question: No header here
+Answer: True
+---
+### Question Type: TF
+Question: Twice
+Question: Again
+Answer: False
+`,
+			wantRecords: []QuestionRecord{{
+				Line: 1, Format: "TF", Domain: "Science", SubDomain: "Physics", Niche: "Motion", Difficulty: "EASY",
+				QuestionText: "Light is faster than sound.", Answer: "True",
+			}},
+			wantErrors: []int{8, 12},
+		},
+		{
+			name:       "text before any field",
+			input:      "Just some text\n",
+			wantErrors: []int{1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			records, entryErrors := ParseMarkdown(test.input)
+			if !reflect.DeepEqual(records, test.wantRecords) {
+				t.Errorf("records = %+v\nwant %+v", records, test.wantRecords)
+			}
+			var errorLines []int
+			for _, entryError := range entryErrors {
+				errorLines = append(errorLines, entryError.Line)
+			}
+			if !reflect.DeepEqual(errorLines, test.wantErrors) {
+				t.Errorf("entry errors = %+v, want errors at lines %v", entryErrors, test.wantErrors)
+			}
+		})
+	}
+}
+
+func TestQuestionRecordValidate(t *testing.T) {
+	valid := QuestionRecord{
+		Format: "MCQ", Domain: "Science", SubDomain: "Chemistry", Niche: "Elements", Difficulty: "EASY",
+		QuestionText: "Which is a noble gas?", Options: []string{"Neon", "Oxygen"}, Answer: "Neon",
+	}
+
+	tests := []struct {
+		name        string
+		change      func(r *QuestionRecord)
+		wantProblem string // Part of the problem expected, none when empty
+	}{
+		{"valid record", func(r *QuestionRecord) {}, ""},
+		{"unknown format", func(r *QuestionRecord) { r.Format = "XYZ" }, "unknown question type"},
+		{"missing domain", func(r *QuestionRecord) { r.Domain = "" }, "missing Category"},
+		{"missing niche", func(r *QuestionRecord) { r.Niche = "" }, "Subcategories must name"},
+		{"invalid difficulty", func(r *QuestionRecord) { r.Difficulty = "EXTREME" }, "difficulty must be"},
+		{"answer isn't an option", func(r *QuestionRecord) { r.Answer = "Helium" }, "Helium"},
+		{"missing question", func(r *QuestionRecord) { r.QuestionText = "" }, "missing Question"},
+		{"invalid status", func(r *QuestionRecord) { r.Status = "LIVE" }, "status must be"},
+		{"published status", func(r *QuestionRecord) { r.Status = "PUBLISHED" }, ""},
+		{"tolerance outside NUM", func(r *QuestionRecord) { r.Tolerance = "0.1" }, "only allowed for NUM"},
+		{"image of a missing option", func(r *QuestionRecord) { r.Attachments = []AttachmentRef{{File: "a.png", Option: 3}} }, "has 2 options"},
+		{"passage without body", func(r *QuestionRecord) { r.Passage = &PassageRef{Key: "p", Position: 1} }, "missing passage body"},
+		{"passage position out of range", func(r *QuestionRecord) { r.Passage = &PassageRef{Key: "p", Body: "Body", Position: 11} }, "passage position"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			record := valid
+			record.Options = append([]string(nil), valid.Options...)
+			test.change(&record)
+
+			problems := record.Validate()
+			if test.wantProblem == "" {
+				if len(problems) > 0 {
+					t.Errorf("Validate() = %v, want no problem", problems)
+				}
+				return
+			}
+			if !strings.Contains(strings.Join(problems, "\n"), test.wantProblem) {
+				t.Errorf("Validate() = %v, want a problem containing %q", problems, test.wantProblem)
+			}
+		})
+	}
+}
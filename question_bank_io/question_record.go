@@ -0,0 +1,423 @@
+// Package questionBankIO moves questions in and out of the question bank through files
+// (bulk markdown, spreadsheets) while keeping their place in the question hierarchy.
+package questionBankIO
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	question_type "server/models/question_bank/question_type"
+	"server/utils"
+)
+
+// QuestionRecord is a single question read from an import file, along with the hierarchy path
+// (domain → sub-domain → niche → difficulty → format) it belongs to.
+type QuestionRecord struct {
+	Line         int      `json:"-"`                  // Line or row the record starts at in the source file
+	FormatID     uint32   `json:"formatID,omitempty"` // Existing format node, replaces the path when set
+	Format       string   `json:"format"`
+	Domain       string   `json:"domain"`
+	SubDomain    string   `json:"subDomain"`
+	Niche        string   `json:"niche"`
+	Difficulty   string   `json:"difficulty"`
+	QuestionText string   `json:"questionText"`
+	Options      []string `json:"options,omitempty"`
+	MultiSelect  bool     `json:"multiSelect,omitempty"` // MCQ only, implied by several answers
+	Answer       string   `json:"answer"`                // The correct options of an MCQ question, the pairs of an MTC question or the items of an ORD question, one per line
+	Tolerance    string   `json:"tolerance,omitempty"`   // NUM only, "0.01" absolute or "0.5%" of the answer, defaults to 0.1%
+	Unit         string   `json:"unit,omitempty"`        // NUM only, e.g. "km/h"
+	Explanation  string   `json:"explanation,omitempty"`
+	Tags         []string `json:"tags,omitempty"`   // Created when they don't exist yet
+	Status       string   `json:"status,omitempty"` // Lifecycle status, DRAFT when left out
+
+	// CODE only, Answer is the reference solution written in Language
+	Language      string                       `json:"language,omitempty"`
+	StarterCode   map[string]string            `json:"starterCode,omitempty"` // Code the students start from, by language
+	TestCases     []question_type.CodeTestCase `json:"testCases,omitempty"`
+	TimeLimitMs   int                          `json:"timeLimitMs,omitempty"`   // Per test case, defaults to 2000
+	MemoryLimitMB int                          `json:"memoryLimitMB,omitempty"` // Per test case, defaults to 256
+
+	Attachments []AttachmentRef `json:"attachments,omitempty"` // Images bundled with the file in a zip import
+	Passage     *PassageRef     `json:"passage,omitempty"`     // Passage the question is grouped under
+}
+
+// PassageRef places the question in the group of a passage. The records sharing a key are grouped under a single
+// passage created by the import, the passage is given in full by each of them.
+type PassageRef struct {
+	Key      string `json:"key"` // Identifies the passage within the import file
+	Title    string `json:"title,omitempty"`
+	Body     string `json:"body"`
+	Position int    `json:"position"` // Order of the question in the group, starting at 1
+}
+
+// maxPassageTitleLength is the length of the title column of the passages
+const maxPassageTitleLength = 200
+
+// AttachmentRef attaches an image bundled with the import file to the question or to one of its options
+type AttachmentRef struct {
+	File    string `json:"file"`             // Path of the image in the zip
+	Option  int    `json:"option,omitempty"` // MCQ only, the option the image belongs to counting from 1, the question itself when 0
+	AltText string `json:"altText,omitempty"`
+}
+
+// parseAttachmentRef reads an attachment written "<file>" or "<file> | <alt text>"
+func parseAttachmentRef(value string) AttachmentRef {
+	file, altText, _ := strings.Cut(value, "|")
+	return AttachmentRef{File: strings.TrimSpace(file), AltText: strings.TrimSpace(altText)}
+}
+
+// formatAliases maps the alternative spellings used by the volunteers to the stored formats
+var formatAliases = map[string]string{
+	"FB": "FIB",
+}
+
+// statusAliases maps the statuses written by the volunteers to the stored ones. Their markdown files mark every
+// question "active", which was never read, so those questions land as drafts.
+var statusAliases = map[string]string{
+	"ACTIVE": question_type.QuestionStatusDraft,
+}
+
+// Normalize trims the fields and brings the format and difficulty to the stored spelling
+func (r *QuestionRecord) Normalize() {
+	r.Format = strings.ToUpper(strings.TrimSpace(r.Format))
+	if format, isAlias := formatAliases[r.Format]; isAlias {
+		r.Format = format
+	}
+	r.Difficulty = strings.ToUpper(strings.TrimSpace(r.Difficulty))
+	r.Status = strings.ToUpper(strings.TrimSpace(r.Status))
+	if status, isAlias := statusAliases[r.Status]; isAlias {
+		r.Status = status
+	}
+	r.Domain = strings.TrimSpace(r.Domain)
+	r.SubDomain = strings.TrimSpace(r.SubDomain)
+	r.Niche = strings.TrimSpace(r.Niche)
+	r.QuestionText = strings.TrimSpace(r.QuestionText)
+	r.Answer = strings.TrimSpace(r.Answer)
+	r.Tolerance = strings.TrimSpace(r.Tolerance)
+	r.Unit = strings.TrimSpace(r.Unit)
+	r.Explanation = strings.TrimSpace(r.Explanation)
+	r.Language = strings.ToLower(strings.TrimSpace(r.Language))
+	if len(r.StarterCode) > 0 {
+		starterCode := make(map[string]string, len(r.StarterCode))
+		for language, code := range r.StarterCode {
+			starterCode[strings.ToLower(strings.TrimSpace(language))] = code
+		}
+		r.StarterCode = starterCode
+	}
+
+	tags := r.Tags[:0]
+	for _, tag := range r.Tags {
+		if tag = strings.Join(strings.Fields(tag), " "); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	r.Tags = tags
+
+	for i := range r.Attachments {
+		r.Attachments[i].File = strings.TrimSpace(r.Attachments[i].File)
+		r.Attachments[i].AltText = strings.TrimSpace(r.Attachments[i].AltText)
+	}
+
+	if r.Passage != nil {
+		r.Passage.Key = strings.TrimSpace(r.Passage.Key)
+		r.Passage.Title = strings.TrimSpace(r.Passage.Title)
+		r.Passage.Body = strings.TrimSpace(r.Passage.Body)
+	}
+
+	options := r.Options[:0]
+	for _, option := range r.Options {
+		if option = strings.TrimSpace(option); option != "" {
+			options = append(options, option)
+		}
+	}
+	r.Options = options
+}
+
+// Validate checks the record against the rules of the question models and the hierarchy constraints.
+// Returns every problem found, empty if the record can be inserted.
+func (r QuestionRecord) Validate() []string {
+	var problems []string
+
+	if !slices.Contains(question_type.QuestionFormats, r.Format) {
+		problems = append(problems, fmt.Sprintf("unknown question type %q", r.Format))
+	}
+	if r.Domain == "" {
+		problems = append(problems, "missing Category (domain)")
+	}
+	if r.SubDomain == "" || r.Niche == "" {
+		problems = append(problems, "Subcategories must name a sub-domain and a niche")
+	}
+	if !slices.Contains([]string{"EASY", "MEDIUM", "HARD"}, r.Difficulty) {
+		problems = append(problems, fmt.Sprintf("difficulty must be EASY, MEDIUM or HARD, got %q", r.Difficulty))
+	}
+
+	if _, err := question_type.NormalizeTagNames(r.Tags); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if r.Status != "" && !slices.Contains(question_type.QuestionStatuses, r.Status) {
+		problems = append(problems, fmt.Sprintf("status must be DRAFT, IN_REVIEW, PUBLISHED or RETIRED, got %q", r.Status))
+	}
+
+	problems = append(problems, r.ValidateContent()...)
+	problems = append(problems, r.validatePassage()...)
+	return append(problems, r.validateAttachments()...)
+}
+
+// validatePassage checks the passage the record is grouped under
+func (r QuestionRecord) validatePassage() []string {
+	if r.Passage == nil {
+		return nil
+	}
+
+	var problems []string
+	if r.Passage.Key == "" {
+		problems = append(problems, "passage without a key")
+	}
+	if r.Passage.Body == "" {
+		problems = append(problems, "missing passage body")
+	} else if err := utils.ValidateRichText(r.Passage.Body); err != nil {
+		problems = append(problems, fmt.Sprintf("Passage: %v", err))
+	}
+	if utf8.RuneCountInString(r.Passage.Title) > maxPassageTitleLength {
+		problems = append(problems, fmt.Sprintf("passage title can't be longer than %d characters", maxPassageTitleLength))
+	}
+	if r.Passage.Position < 1 || r.Passage.Position > question_type.MaxPassageQuestions {
+		problems = append(problems, fmt.Sprintf("passage position must be between 1 and %d, got %d", question_type.MaxPassageQuestions, r.Passage.Position))
+	}
+	return problems
+}
+
+// validateAttachments checks that the attachments of the record name their file and an existing option
+func (r QuestionRecord) validateAttachments() []string {
+	var problems []string
+	if len(r.Attachments) > question_type.MaxQuestionAttachments {
+		problems = append(problems, fmt.Sprintf("a question can't have more than %d attachments", question_type.MaxQuestionAttachments))
+	}
+	for _, attachment := range r.Attachments {
+		switch {
+		case attachment.File == "":
+			problems = append(problems, "attachment without a file")
+		case attachment.Option < 0:
+			problems = append(problems, fmt.Sprintf("invalid option %d for image %s", attachment.Option, attachment.File))
+		case attachment.Option > 0 && r.Format != "MCQ":
+			problems = append(problems, fmt.Sprintf("only MCQ options can have images, not %s", r.Format))
+		case attachment.Option > len(r.Options):
+			problems = append(problems, fmt.Sprintf("image %s belongs to option %d but the question has %d options", attachment.File, attachment.Option, len(r.Options)))
+		}
+	}
+	return problems
+}
+
+// ValidateContent checks the question itself (text, answer, options) against the rules of its format and its texts
+// against the supported rich text, leaving the hierarchy path out. Used on its own when a stored question is edited.
+func (r QuestionRecord) ValidateContent() []string {
+	var problems []string
+
+	if r.QuestionText == "" {
+		problems = append(problems, "missing Question")
+	}
+	if r.Answer == "" {
+		problems = append(problems, "missing Answer")
+	}
+
+	switch r.Format {
+	case "MCQ":
+		if r.Answer != "" {
+			mcqQuestion := r.mcqModel(question_type.BaseQuestion{Answer: r.Answer})
+			if err := mcqQuestion.ResolveAnswer(); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+	case "TF":
+		if !utils.IsTrueFalseAnswer(r.Answer) {
+			problems = append(problems, fmt.Sprintf("True/False answer must be True or False, got %q", r.Answer))
+		}
+	case "NUM":
+		numericQuestion, err := r.numericModel(question_type.BaseQuestion{Answer: r.Answer})
+		if err == nil && r.Answer != "" {
+			err = numericQuestion.ResolveAnswer()
+		}
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	case "CODE":
+		if r.Answer != "" {
+			if err := r.codingModel(question_type.BaseQuestion{Answer: r.Answer}).ValidateCode(); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+	case "MTC":
+		if r.Answer != "" {
+			matchingQuestion := question_type.MatchingQuestion{BaseQuestion: question_type.BaseQuestion{Answer: r.Answer}}
+			if err := matchingQuestion.ResolveAnswer(); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+	case "ORD":
+		if r.Answer != "" {
+			orderingQuestion := question_type.OrderingQuestion{BaseQuestion: question_type.BaseQuestion{Answer: r.Answer}}
+			if err := orderingQuestion.ResolveAnswer(); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+	}
+	if len(r.Options) > 0 && r.Format != "MCQ" {
+		problems = append(problems, fmt.Sprintf("options are only allowed for MCQ questions, not %s", r.Format))
+	}
+	if r.MultiSelect && r.Format != "MCQ" {
+		problems = append(problems, fmt.Sprintf("only MCQ questions can be multi-select, not %s", r.Format))
+	}
+	if (r.Tolerance != "" || r.Unit != "") && r.Format != "NUM" {
+		problems = append(problems, fmt.Sprintf("tolerance and unit are only allowed for NUM questions, not %s", r.Format))
+	}
+	if (r.Language != "" || len(r.StarterCode) > 0 || len(r.TestCases) > 0 || r.TimeLimitMs != 0 || r.MemoryLimitMB != 0) && r.Format != "CODE" {
+		problems = append(problems, fmt.Sprintf("language, starter code, test cases and limits are only allowed for CODE questions, not %s", r.Format))
+	}
+
+	return append(problems, r.validateRichText()...)
+}
+
+// validateRichText checks the texts shown to the students against the supported rich text (Markdown and math)
+func (r QuestionRecord) validateRichText() []string {
+	var problems []string
+	check := func(field, content string) {
+		if err := utils.ValidateRichText(content); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", field, err))
+		}
+	}
+
+	check("Question", r.QuestionText)
+	for i, option := range r.Options {
+		check(fmt.Sprintf("Option %d", i+1), option)
+	}
+	check("Explanation", r.Explanation)
+	return problems
+}
+
+// toModel builds the question model of the record's format, ready to be inserted under the given format node.
+// Records without a status land as drafts, they go live once reviewed.
+func (r QuestionRecord) toModel(formatID uint32) (interface{}, error) {
+	base := question_type.BaseQuestion{
+		QuestionFormatID: formatID,
+		QuestionText:     r.QuestionText,
+		Answer:           r.Answer,
+		Status:           r.status(),
+	}
+
+	switch r.Format {
+	case "MCQ":
+		return r.mcqModel(base), nil
+	case "TF":
+		return &question_type.TrueFalseQuestion{BaseQuestion: base, Explanation: r.Explanation}, nil
+	case "FIB":
+		explanation := r.Explanation
+		return &question_type.FillInTheBlankQuestion{BaseQuestion: base, Explanation: &explanation}, nil
+	case "TXT":
+		return &question_type.TextBasedQuestion{BaseQuestion: base}, nil
+	case "NUM":
+		return r.numericModel(base)
+	case "CODE":
+		return r.codingModel(base), nil
+	case "MTC": // The pairs are read from the answer on save
+		return &question_type.MatchingQuestion{BaseQuestion: base, Explanation: r.Explanation}, nil
+	case "ORD": // The sequence is read from the answer on save
+		return &question_type.OrderingQuestion{BaseQuestion: base, Explanation: r.Explanation}, nil
+	default:
+		return nil, fmt.Errorf("invalid question format: %s", r.Format)
+	}
+}
+
+// status is the lifecycle status the question is inserted with
+func (r QuestionRecord) status() string {
+	if r.Status == "" {
+		return question_type.QuestionStatusDraft
+	}
+	return r.Status
+}
+
+// mcqModel builds the MCQ model of the record, its correct options are resolved from the answer on save
+func (r QuestionRecord) mcqModel(base question_type.BaseQuestion) *question_type.MCQQuestion {
+	return &question_type.MCQQuestion{BaseQuestion: base, Explanation: r.Explanation, Options: r.Options, IsMultiSelect: r.MultiSelect}
+}
+
+// numericModel builds the NUM model of the record, the value of its answer is computed on save
+func (r QuestionRecord) numericModel(base question_type.BaseQuestion) (*question_type.NumericQuestion, error) {
+	tolerance, relative, err := utils.ParseTolerance(r.Tolerance)
+	if err != nil {
+		return nil, err
+	}
+	return &question_type.NumericQuestion{
+		BaseQuestion:        base,
+		Explanation:         r.Explanation,
+		Tolerance:           tolerance,
+		IsRelativeTolerance: relative,
+		Unit:                r.Unit,
+	}, nil
+}
+
+// codingModel builds the CODE model of the record, the limits left out get their defaults on save
+func (r QuestionRecord) codingModel(base question_type.BaseQuestion) *question_type.CodingQuestion {
+	return &question_type.CodingQuestion{
+		BaseQuestion:  base,
+		Explanation:   r.Explanation,
+		Language:      r.Language,
+		StarterCode:   r.StarterCode,
+		TestCases:     r.TestCases,
+		TimeLimitMs:   r.TimeLimitMs,
+		MemoryLimitMB: r.MemoryLimitMB,
+	}
+}
+
+// Snapshot is the content of the record as stored in a question of its format, used to edit a stored question
+func (r QuestionRecord) Snapshot() question_type.QuestionSnapshot {
+	snapshot := question_type.QuestionSnapshot{
+		QuestionText: r.QuestionText,
+		Answer:       r.Answer,
+		Options:      r.Options,
+		MultiSelect:  r.MultiSelect,
+		Explanation:  r.Explanation,
+	}
+	if r.Format == "NUM" {
+		snapshot.Tolerance, snapshot.RelativeTolerance, _ = utils.ParseTolerance(r.Tolerance)
+		snapshot.Unit = r.Unit
+	}
+	if r.Format == "CODE" {
+		// The limits left out get their defaults, as on insert
+		codingQuestion := r.codingModel(question_type.BaseQuestion{Answer: r.Answer})
+		_ = codingQuestion.ValidateCode()
+		snapshot.Language, snapshot.StarterCode, snapshot.TestCases = codingQuestion.Language, codingQuestion.StarterCode, codingQuestion.TestCases
+		snapshot.TimeLimitMs, snapshot.MemoryLimitMB = codingQuestion.TimeLimitMs, codingQuestion.MemoryLimitMB
+	}
+	return snapshot
+}
+
+// insertedQuestionID returns the ID given by the database to a question model built by toModel
+func insertedQuestionID(question interface{}) uint32 {
+	switch q := question.(type) {
+	case *question_type.MCQQuestion:
+		return q.QuestionID
+	case *question_type.TrueFalseQuestion:
+		return q.QuestionID
+	case *question_type.FillInTheBlankQuestion:
+		return q.QuestionID
+	case *question_type.TextBasedQuestion:
+		return q.QuestionID
+	case *question_type.NumericQuestion:
+		return q.QuestionID
+	case *question_type.CodingQuestion:
+		return q.QuestionID
+	case *question_type.MatchingQuestion:
+		return q.QuestionID
+	case *question_type.OrderingQuestion:
+		return q.QuestionID
+	default:
+		return 0
+	}
+}
+
+// hierarchyPath is the readable path of the record in the question hierarchy
+func (r QuestionRecord) hierarchyPath() string {
+	return strings.Join([]string{r.Domain, r.SubDomain, r.Niche, r.Difficulty, r.Format}, " > ")
+}
@@ -0,0 +1,327 @@
+package questionBankIO
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ColumnMapping tells which spreadsheet column (by its header) holds each field of a question.
+// The hierarchy is given either as a path (Format, Domain, SubDomain, Niche and Difficulty columns)
+// or as the ID of an existing format node (FormatID column).
+type ColumnMapping struct {
+	QuestionText string   `json:"questionText"`
+	Options      []string `json:"options"`               // One column per option, empty cells are ignored
+	MultiSelect  string   `json:"multiSelect,omitempty"` // Optional, true for the multi-select MCQ questions
+	Answer       string   `json:"answer"`                // One correct option per line for the multi-select MCQ questions, one pair (MTC) or item (ORD) per line
+	Tolerance    string   `json:"tolerance,omitempty"`   // Optional, for the NUM questions
+	Unit         string   `json:"unit,omitempty"`        // Optional, for the NUM questions
+	Explanation  string   `json:"explanation"`
+
+	// Optional, for the CODE questions, whose answer is the reference solution
+	Language      string `json:"language,omitempty"`
+	StarterCode   string `json:"starterCode,omitempty"` // JSON object of the code by language, e.g. {"python": "..."}
+	TestCases     string `json:"testCases,omitempty"`   // JSON array of {"input", "expectedOutput", "isSample"}
+	TimeLimitMs   string `json:"timeLimitMs,omitempty"`
+	MemoryLimitMB string `json:"memoryLimitMB,omitempty"`
+
+	// Optional, the tags of the question separated by commas, "\," escapes a comma in a tag name
+	Tags string `json:"tags,omitempty"`
+
+	// Optional, the lifecycle status of the question (DRAFT when empty)
+	Status string `json:"status,omitempty"`
+
+	// Optional, zip imports only, the images of the question one per line ("<file> | <alt text>",
+	// "Option 2: <file>" for the images of the MCQ options)
+	Attachments string `json:"attachments,omitempty"`
+
+	// Hierarchy path
+	Format     string `json:"format"`
+	Domain     string `json:"domain"`
+	SubDomain  string `json:"subDomain"`
+	Niche      string `json:"niche"`
+	Difficulty string `json:"difficulty"`
+
+	// Hierarchy by ID, used instead of the path
+	FormatID string `json:"formatID"`
+
+	Sheet string `json:"sheet"` // XLSX only, the first sheet is read if empty
+}
+
+// Validate checks that the mapping names every required column
+func (m ColumnMapping) Validate() error {
+	var missing []string
+	if m.QuestionText == "" {
+		missing = append(missing, "questionText")
+	}
+	if m.Answer == "" {
+		missing = append(missing, "answer")
+	}
+	if m.FormatID == "" {
+		pathColumns := []struct{ field, column string }{
+			{"format", m.Format}, {"domain", m.Domain}, {"subDomain", m.SubDomain}, {"niche", m.Niche}, {"difficulty", m.Difficulty},
+		}
+		for _, pathColumn := range pathColumns {
+			if pathColumn.column == "" {
+				missing = append(missing, pathColumn.field)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("column mapping is missing %s (the hierarchy path can be replaced by formatID)", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// ReadSpreadsheet reads the rows of a CSV or XLSX file, the format is picked from the file name
+func ReadSpreadsheet(fileName string, file io.Reader, sheet string) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1 // Rows may leave the trailing cells out
+
+		var rows [][]string
+		for {
+			row, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return rows, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CSV: %w", err)
+			}
+
+			// The reader skips blank lines, keep them as empty rows so that row numbers match the file
+			line, _ := reader.FieldPos(0)
+			for len(rows) < line-1 {
+				rows = append(rows, nil)
+			}
+			rows = append(rows, row)
+		}
+
+	case ".xlsx":
+		workbook, err := excelize.OpenReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open XLSX: %w", err)
+		}
+		defer workbook.Close()
+
+		if sheet == "" {
+			sheet = workbook.GetSheetName(0)
+		}
+		rows, err := workbook.GetRows(sheet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sheet %q: %w", sheet, err)
+		}
+		return rows, nil
+
+	default:
+		return nil, errors.New("only .csv and .xlsx files are supported")
+	}
+}
+
+// ParseSpreadsheet maps the rows of a spreadsheet (header row first) to question records.
+// Record lines are the row numbers as shown by spreadsheet programs, the header being row 1.
+func ParseSpreadsheet(rows [][]string, mapping ColumnMapping) ([]QuestionRecord, []EntryError, error) {
+	if err := mapping.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, errors.New("the spreadsheet is empty")
+	}
+
+	// Headers are matched case-insensitively
+	columnIndex := make(map[string]int, len(rows[0]))
+	for i, header := range rows[0] {
+		columnIndex[strings.ToLower(strings.TrimSpace(header))] = i
+	}
+
+	// Every mapped column has to exist in the header row
+	var unknownColumns []string
+	lookup := func(column string) int {
+		if column == "" {
+			return -1
+		}
+		index, exists := columnIndex[strings.ToLower(strings.TrimSpace(column))]
+		if !exists {
+			unknownColumns = append(unknownColumns, column)
+			return -1
+		}
+		return index
+	}
+
+	questionTextColumn := lookup(mapping.QuestionText)
+	answerColumn := lookup(mapping.Answer)
+	multiSelectColumn := lookup(mapping.MultiSelect)
+	toleranceColumn := lookup(mapping.Tolerance)
+	unitColumn := lookup(mapping.Unit)
+	explanationColumn := lookup(mapping.Explanation)
+	languageColumn := lookup(mapping.Language)
+	starterCodeColumn := lookup(mapping.StarterCode)
+	testCasesColumn := lookup(mapping.TestCases)
+	timeLimitColumn := lookup(mapping.TimeLimitMs)
+	memoryLimitColumn := lookup(mapping.MemoryLimitMB)
+	tagsColumn := lookup(mapping.Tags)
+	statusColumn := lookup(mapping.Status)
+	attachmentsColumn := lookup(mapping.Attachments)
+	formatColumn := lookup(mapping.Format)
+	domainColumn := lookup(mapping.Domain)
+	subDomainColumn := lookup(mapping.SubDomain)
+	nicheColumn := lookup(mapping.Niche)
+	difficultyColumn := lookup(mapping.Difficulty)
+	formatIDColumn := lookup(mapping.FormatID)
+	optionColumns := make([]int, 0, len(mapping.Options))
+	for _, column := range mapping.Options {
+		optionColumns = append(optionColumns, lookup(column))
+	}
+
+	if len(unknownColumns) > 0 {
+		return nil, nil, fmt.Errorf("columns not found in the header row: %s", strings.Join(unknownColumns, ", "))
+	}
+
+	var records []QuestionRecord
+	var entryErrors []EntryError
+
+	for i, row := range rows[1:] {
+		cell := func(column int) string {
+			if column < 0 || column >= len(row) {
+				return ""
+			}
+			return row[column]
+		}
+
+		// Blank rows are left out
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		record := QuestionRecord{
+			Line:         i + 2,
+			Format:       cell(formatColumn),
+			Domain:       cell(domainColumn),
+			SubDomain:    cell(subDomainColumn),
+			Niche:        cell(nicheColumn),
+			Difficulty:   cell(difficultyColumn),
+			QuestionText: cell(questionTextColumn),
+			Answer:       cell(answerColumn),
+			Tolerance:    cell(toleranceColumn),
+			Unit:         cell(unitColumn),
+			Explanation:  cell(explanationColumn),
+			Language:     cell(languageColumn),
+			Status:       cell(statusColumn),
+		}
+		for _, column := range optionColumns {
+			record.Options = append(record.Options, cell(column))
+		}
+		if tags := strings.TrimSpace(cell(tagsColumn)); tags != "" {
+			record.Tags = splitSubcategories(tags)
+		}
+
+		if multiSelect := strings.TrimSpace(cell(multiSelectColumn)); multiSelect != "" {
+			value, err := strconv.ParseBool(multiSelect)
+			if err != nil {
+				entryErrors = append(entryErrors, EntryError{Line: record.Line, Errors: []string{fmt.Sprintf("invalid multi-select value %q, expected true or false", multiSelect)}})
+				continue
+			}
+			record.MultiSelect = value
+		}
+
+		if problems := parseCodeCells(&record, cell(starterCodeColumn), cell(testCasesColumn), cell(timeLimitColumn), cell(memoryLimitColumn)); len(problems) > 0 {
+			entryErrors = append(entryErrors, EntryError{Line: record.Line, Errors: problems})
+			continue
+		}
+
+		attachments, problems := parseAttachmentLines(cell(attachmentsColumn))
+		if len(problems) > 0 {
+			entryErrors = append(entryErrors, EntryError{Line: record.Line, Errors: problems})
+			continue
+		}
+		record.Attachments = attachments
+
+		// A format ID, when given, takes precedence over the path
+		if formatID := strings.TrimSpace(cell(formatIDColumn)); formatID != "" {
+			id, err := strconv.ParseUint(formatID, 10, 32)
+			if err != nil || id == 0 {
+				entryErrors = append(entryErrors, EntryError{Line: record.Line, Errors: []string{fmt.Sprintf("invalid format ID %q", formatID)}})
+				continue
+			}
+			record.FormatID = uint32(id)
+		}
+
+		record.Normalize()
+		records = append(records, record)
+	}
+
+	return records, entryErrors, nil
+}
+
+// attachmentOptionPrefix starts the attachment lines of the spreadsheets that belong to an option, e.g. "Option 2: b.png"
+const attachmentOptionPrefix = "option "
+
+// parseAttachmentLines reads the attachments of a spreadsheet cell, one per line. The ones that belong
+// to an option start with its number, "Option 2: <file> | <alt text>".
+func parseAttachmentLines(cell string) ([]AttachmentRef, []string) {
+	var attachments []AttachmentRef
+	var problems []string
+	for _, line := range strings.Split(cell, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+
+		option := 0
+		if strings.HasPrefix(strings.ToLower(line), attachmentOptionPrefix) {
+			number, value, found := strings.Cut(line[len(attachmentOptionPrefix):], ":")
+			parsed, err := strconv.Atoi(strings.TrimSpace(number))
+			if !found || err != nil || parsed < 1 {
+				problems = append(problems, fmt.Sprintf("invalid attachment %q, expected \"Option <number>: <file>\"", line))
+				continue
+			}
+			option, line = parsed, value
+		}
+
+		attachment := parseAttachmentRef(line)
+		attachment.Option = option
+		attachments = append(attachments, attachment)
+	}
+	return attachments, problems
+}
+
+// parseCodeCells reads the cells of a CODE question into the record, the starter code and the test cases are JSON
+func parseCodeCells(record *QuestionRecord, starterCode, testCases, timeLimitMs, memoryLimitMB string) []string {
+	var problems []string
+	if starterCode = strings.TrimSpace(starterCode); starterCode != "" {
+		if err := json.Unmarshal([]byte(starterCode), &record.StarterCode); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid starter code, expected a JSON object of the code by language: %v", err))
+		}
+	}
+	if testCases = strings.TrimSpace(testCases); testCases != "" {
+		if err := json.Unmarshal([]byte(testCases), &record.TestCases); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid test cases, expected a JSON array of {\"input\", \"expectedOutput\", \"isSample\"}: %v", err))
+		}
+	}
+	for _, limit := range []struct {
+		name  string
+		value string
+		field *int
+	}{
+		{"time limit", timeLimitMs, &record.TimeLimitMs},
+		{"memory limit", memoryLimitMB, &record.MemoryLimitMB},
+	} {
+		if value := strings.TrimSpace(limit.value); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				problems = append(problems, fmt.Sprintf("invalid %s %q, expected a positive number", limit.name, value))
+				continue
+			}
+			*limit.field = parsed
+		}
+	}
+	return problems
+}
@@ -0,0 +1,142 @@
+package questionBankIO
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSpreadsheet(t *testing.T) {
+	mapping := ColumnMapping{
+		QuestionText: "Question",
+		Options:      []string{"Option A", "Option B", "Option C"},
+		MultiSelect:  "Multi",
+		Answer:       "Answer",
+		Explanation:  "Explanation",
+		Tags:         "Tags",
+		Status:       "Status",
+		Attachments:  "Images",
+		Format:       "Type",
+		Domain:       "Category",
+		SubDomain:    "Sub-Domain",
+		Niche:        "Niche",
+		Difficulty:   "Difficulty",
+		FormatID:     "Format ID",
+	}
+	header := []string{"type", "CATEGORY", "Sub-Domain", "Niche", "Difficulty", "Format ID", "Question",
+		"Option A", "Option B", "Option C", "Multi", "Answer", "Explanation", "Tags", "Status", "Images"}
+
+	tests := []struct {
+		name        string
+		rows        [][]string
+		wantRecords []QuestionRecord
+		wantErrors  []int // Rows that fail to parse
+	}{
+		{
+			name: "path row",
+			rows: [][]string{header,
+				{"mcq", "Science", "Chemistry", "Elements", "easy", "", "Which is a noble gas?", "Neon", "Oxygen", "", "", "Neon", "Neon is inert.", "gases, basics", "published", ""},
+			},
+			wantRecords: []QuestionRecord{{
+				Line: 2, Format: "MCQ", Domain: "Science", SubDomain: "Chemistry", Niche: "Elements", Difficulty: "EASY",
+				QuestionText: "Which is a noble gas?", Options: []string{"Neon", "Oxygen"}, Answer: "Neon",
+				Explanation: "Neon is inert.", Tags: []string{"gases", "basics"}, Status: "PUBLISHED",
+			}},
+		},
+		{
+			name: "format ID row, short row and blank row",
+			rows: [][]string{header,
+				{"", "", "", "", "", "", "", "", ""},
+				{"", "", "", "", "", "42", "Is light a wave?", "", "", "", "", "True"},
+			},
+			// The empty option cells leave an empty list of options
+			wantRecords: []QuestionRecord{{Line: 3, FormatID: 42, QuestionText: "Is light a wave?", Options: []string{}, Answer: "True"}},
+		},
+		{
+			name: "multi-select and option images",
+			rows: [][]string{header,
+				{"MCQ", "Science", "Chemistry", "Elements", "Easy", "", "Pick the gases", "Neon", "Iron", "Argon", "true", "Neon\nArgon", "", "", "",
+					"chart.png | Periodic table\nOption 2: iron.png"},
+			},
+			wantRecords: []QuestionRecord{{
+				Line: 2, Format: "MCQ", Domain: "Science", SubDomain: "Chemistry", Niche: "Elements", Difficulty: "EASY",
+				QuestionText: "Pick the gases", Options: []string{"Neon", "Iron", "Argon"}, MultiSelect: true, Answer: "Neon\nArgon",
+				Attachments: []AttachmentRef{{File: "chart.png", AltText: "Periodic table"}, {File: "iron.png", Option: 2}},
+			}},
+		},
+		{
+			name: "invalid cells",
+			rows: [][]string{header,
+				{"MCQ", "Science", "Chemistry", "Elements", "Easy", "", "Q", "A", "B", "", "sometimes", "A"},
+				{"MCQ", "Science", "Chemistry", "Elements", "Easy", "abc", "Q", "A", "B", "", "", "A"},
+				{"MCQ", "Science", "Chemistry", "Elements", "Easy", "", "Q", "A", "B", "", "", "A", "", "", "", "Option two: b.png"},
+			},
+			wantErrors: []int{2, 3, 4},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			records, entryErrors, err := ParseSpreadsheet(test.rows, mapping)
+			if err != nil {
+				t.Fatalf("ParseSpreadsheet() error = %v", err)
+			}
+			if !reflect.DeepEqual(records, test.wantRecords) {
+				t.Errorf("records = %+v\nwant %+v", records, test.wantRecords)
+			}
+			var errorLines []int
+			for _, entryError := range entryErrors {
+				errorLines = append(errorLines, entryError.Line)
+			}
+			if !reflect.DeepEqual(errorLines, test.wantErrors) {
+				t.Errorf("entry errors = %+v, want errors at rows %v", entryErrors, test.wantErrors)
+			}
+		})
+	}
+}
+
+func TestParseSpreadsheetMapping(t *testing.T) {
+	rows := [][]string{{"Question", "Answer", "Format ID"}}
+
+	tests := []struct {
+		name    string
+		mapping ColumnMapping
+		wantErr string
+	}{
+		{"format ID replaces the path", ColumnMapping{QuestionText: "Question", Answer: "Answer", FormatID: "Format ID"}, ""},
+		{"missing answer", ColumnMapping{QuestionText: "Question", FormatID: "Format ID"}, "missing answer"},
+		{"missing path", ColumnMapping{QuestionText: "Question", Answer: "Answer"}, "format, domain, subDomain, niche, difficulty"},
+		{"unknown column", ColumnMapping{QuestionText: "Question", Answer: "Answer", FormatID: "Format ID", Tags: "Labels"}, "Labels"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := ParseSpreadsheet(rows, test.mapping)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("ParseSpreadsheet() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("ParseSpreadsheet() error = %v, want one containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadSpreadsheetCSV(t *testing.T) {
+	input := "Question,Answer\n\nWhat is 2+2?,4\n\"Multi\nline\",yes\n"
+	rows, err := ReadSpreadsheet("questions.CSV", strings.NewReader(input), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Blank lines are kept as empty rows so that the row numbers match the file
+	want := [][]string{{"Question", "Answer"}, nil, {"What is 2+2?", "4"}, {"Multi\nline", "yes"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+
+	if _, err := ReadSpreadsheet("questions.txt", strings.NewReader(input), ""); err == nil {
+		t.Error("ReadSpreadsheet() of a .txt file succeeded, want an error")
+	}
+}
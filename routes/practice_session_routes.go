@@ -2,7 +2,7 @@ package routes
 
 import (
 	controllersNew "server/controllers/psql"
-	// "server/middlewares"
+	"server/middlewares"
 	reqMiddleware "server/middlewares/requests"
 
 	"github.com/gin-gonic/gin"
@@ -17,8 +17,49 @@ func PracticeSessionRoutes(router *gin.Engine) {
 	{
 		// NOT NEEDED AS IT IS TAKEN CARE OF BY THE QUESTION FETCH HANDLER.
 		// session.POST("/start", controllersNew.StartPracticeSessionHandler)
-		session.POST("/submit", controllersNew.SubmitPracticeSessionHandler)
+
+		// Submitting runs the programs answered to the CODE questions, students only
+		session.POST(
+			"/submit",
+			middlewares.PrivilegedMiddleware("common"), // Privileges check for "common", students submit their own sessions only
+			controllersNew.SubmitPracticeSessionHandler,
+		)
 		session.POST("/end-forcefully", controllersNew.ForcefullyEndPracticeSessionHandler)
+		session.GET(
+			"/:id/resume", // ?render=html adds the rich texts rendered to HTML
+			middlewares.PrivilegedMiddleware("common"), // Privileges check for "common", students resume their own sessions only
+			controllersNew.ResumePracticeSessionHandler,
+		)
+		session.GET(
+			"/:id/review", // ?render=html adds the rich texts rendered to HTML
+			middlewares.PrivilegedMiddleware("common"), // Privileges check for "common", students review their own sessions only
+			controllersNew.ReviewPracticeSessionHandler,
+		)
+
+		// Runs a program against the sample test cases of a served CODE question, students only.
+		session.POST(
+			"/:id/run",
+			middlewares.PrivilegedMiddleware("common"), // Privileges check for "common", students run code in their own sessions only
+			controllersNew.RunPracticeCodeHandler,
+		)
+
+		// Adaptive sessions serve one question of a niche at a time, at the difficulty matching the ability of the student
+		// in the niche. /submit ends them early and /:id/resume returns the question they are waiting on.
+		// The student is the one of the token, answers move their ability estimates.
+		adaptive := session.Group("")
+		adaptive.Use(middlewares.PrivilegedMiddleware("common")) // Privileges check for "common"
+		{
+			adaptive.POST("/adaptive/start", controllersNew.StartAdaptivePracticeSessionHandler)    // ?render=html adds the rich texts rendered to HTML
+			adaptive.POST("/adaptive/answer", controllersNew.AnswerAdaptivePracticeQuestionHandler) // ?render=html adds the rich texts rendered to HTML, runs the program of a CODE answer
+			adaptive.GET("/abilities", controllersNew.GetNicheAbilitiesHandler)                     // Ability estimates of the student per niche
+		}
+
+		// Last run and counts of the background worker that expires abandoned sessions.
+		session.GET(
+			"/reaper/status",
+			middlewares.PrivilegedMiddleware("admin"), // Privileges check for "admin"
+			controllersNew.GetPracticeSessionReaperStatusHandler,
+		)
 	}
 }
 
@@ -1,10 +1,11 @@
 package routes
 
 import (
+	"server/cache"
 	"server/controllers"
 	controllersNew "server/controllers/psql"
 
-	// "server/middlewares"
+	"server/middlewares"
 	reqMiddleware "server/middlewares/requests"
 
 	"github.com/gin-gonic/gin"
@@ -18,42 +19,170 @@ func QuestionRoutes(router *gin.Engine) {
 	// Question routes
 	{
 		// Question routes
-		questions.GET("/hierarchy", controllersNew.GetQuestionHierarchy)
-		questions.GET("/domains", controllersNew.GetDomains)
-		questions.GET("/subdomains/:domainID", controllersNew.GetSubDomains)
-		questions.GET("/niches/:subDomainsID", controllersNew.GetNiches)
-		questions.GET("/difficulty-levels/:nicheID", controllersNew.GetDifficultyLevels)
-		questions.GET("/formats/:difficultyLevelID", controllersNew.GetFormats)
-
-		questions.POST("/fetch", controllersNew.GetQuestions)
+		// Hierarchy routes, served from the in-process cache with ETag/If-None-Match support
+		hierarchyCache := cache.QuestionHierarchyCacheMiddleware()
+		questions.GET("/hierarchy", hierarchyCache, controllersNew.GetQuestionHierarchy)
+		questions.GET("/hierarchy/tree", hierarchyCache, controllersNew.GetQuestionHierarchyTree) // ?level=&id= subtree, ?depth=, ?nonEmpty=true
+		questions.GET("/domains", hierarchyCache, controllersNew.GetDomains)
+		questions.GET("/subdomains/:domainID", hierarchyCache, controllersNew.GetSubDomains)
+		questions.GET("/niches/:subDomainsID", hierarchyCache, controllersNew.GetNiches)
+		questions.GET("/difficulty-levels/:nicheID", hierarchyCache, controllersNew.GetDifficultyLevels)
+		questions.GET("/formats/:difficultyLevelID", hierarchyCache, controllersNew.GetFormats)
+
+		questions.POST("/fetch", controllersNew.GetQuestions) // "tags" and "tagMatch" (any/all) narrow the questions of the format node, passage groups are served whole, ?render=html adds the rich texts rendered to HTML
+
+		// Hierarchy admin routes, :level is one of domains, subdomains, niches, difficulty-levels, formats.
+		hierarchyAdmin := questions.Group("/hierarchy")
+		hierarchyAdmin.Use(middlewares.PrivilegedMiddleware("admin")) // Privileges check for "admin"
+		{
+			hierarchyAdmin.POST("/:level", controllersNew.CreateHierarchyNodeHandler)
+			hierarchyAdmin.PUT("/:level/:id", controllersNew.RenameHierarchyNodeHandler)
+			hierarchyAdmin.POST("/:level/:id/move", controllersNew.MoveHierarchyNodeHandler)
+			hierarchyAdmin.GET("/:level/:id/delete-preview", controllersNew.PreviewDeleteHierarchyNodeHandler)
+			hierarchyAdmin.DELETE("/:level/:id", controllersNew.DeleteHierarchyNodeHandler)
+		}
+
+		// Question editing routes, every change is kept in the revision history of the question.
+		// Volunteers edit and restore their own drafts only, coordinators any question.
+		// The diff takes ?from=<n>&to=<n> revision numbers, by default the latest revision and the one before it.
+		questionItems := questions.Group("/items/:formatID/:questionID")
+		questionItems.Use(middlewares.PrivilegedMiddleware("volunteer")) // Privileges check for "volunteer", edits check the author and status
+		{
+			questionItems.GET("", controllersNew.GetQuestionItemHandler) // ?render=html adds the rich texts rendered to HTML
+			questionItems.PUT("", controllersNew.UpdateQuestionHandler)
+			questionItems.GET("/revisions", controllersNew.ListQuestionRevisionsHandler)
+			questionItems.GET("/revisions/diff", controllersNew.DiffQuestionRevisionsHandler)
+			questionItems.POST("/revisions/:revision/restore", controllersNew.RestoreQuestionRevisionHandler)
+			questionItems.GET("/statistics", middlewares.PrivilegedMiddleware("admin"), controllersNew.GetQuestionStatisticsHandler)
+		}
+
+		// Answer statistics routes, computed periodically from the submitted practice sessions.
+		// The report lists the questions flagged for review: empirical difficulty that disagrees with their node
+		// (DIFFICULTY_MISMATCH), distractors almost nobody picks (WEAK_DISTRACTOR) and likely wrong answer keys (LIKELY_WRONG_KEY).
+		questionStatistics := questions.Group("/statistics")
+		questionStatistics.Use(middlewares.PrivilegedMiddleware("admin")) // Privileges check for "admin"
+		{
+			questionStatistics.GET("/flagged", controllersNew.GetFlaggedQuestionsHandler) // ?flag=&formatID=&limit=&offset=
+			questionStatistics.POST("/recompute", controllersNew.RecomputeQuestionStatisticsHandler)
+		}
+
+		// Review workflow routes, volunteers submit drafts and coordinators approve, reject, retire or reopen them.
+		// Only PUBLISHED questions are served in practice sessions.
+		questionReview := questions.Group("")
+		questionReview.Use(middlewares.PrivilegedMiddleware("volunteer")) // Privileges check for "volunteer", actions check their own role
+		{
+			questionReview.GET("/review-queue", controllersNew.GetReviewQueueHandler) // ?status=IN_REVIEW&formatID=&limit=&offset=
+			questionReview.GET("/items/:formatID/:questionID/status", controllersNew.ListQuestionStatusChangesHandler)
+			questionReview.POST("/items/:formatID/:questionID/status", controllersNew.ChangeQuestionStatusHandler)
+			questionReview.POST("/items/:formatID/:questionID/run", controllersNew.RunQuestionCodeHandler) // CODE only, every test case, the reference solution without code
+		}
+
+		// Tag routes, tags label questions across the hierarchy (e.g. "TCS-PYQ", "probability") and filter /fetch.
+		questions.GET("/tags", controllersNew.ListQuestionTagsHandler) // ?search=
+		questionTags := questions.Group("")
+		questionTags.Use(middlewares.PrivilegedMiddleware("volunteer")) // Privileges check for "volunteer"
+		{
+			questionTags.POST("/tags", controllersNew.CreateQuestionTagHandler)
+			questionTags.PUT("/tags/:tagID", middlewares.PrivilegedMiddleware("coordinator"), controllersNew.RenameQuestionTagHandler)
+			questionTags.DELETE("/tags/:tagID", middlewares.PrivilegedMiddleware("coordinator"), controllersNew.DeleteQuestionTagHandler)
+			questionTags.GET("/tags/:tagID/questions", controllersNew.ListTaggedQuestionsHandler) // ?status=&limit=&offset=
+			questionTags.GET("/items/:formatID/:questionID/tags", controllersNew.GetQuestionTagsHandler)
+			questionTags.PUT("/items/:formatID/:questionID/tags", controllersNew.SetQuestionTagsHandler)
+		}
+
+		// Passage routes, a passage (e.g. a reading comprehension) owns an ordered group of questions of any format.
+		// The group is served whole in practice sessions, with the passage sent once.
+		questionPassages := questions.Group("/passages")
+		questionPassages.Use(middlewares.PrivilegedMiddleware("volunteer")) // Privileges check for "volunteer"
+		{
+			questionPassages.GET("", controllersNew.ListQuestionPassagesHandler) // ?search=&limit=&offset=
+			questionPassages.POST("", controllersNew.CreateQuestionPassageHandler)
+			questionPassages.GET("/:passageID", controllersNew.GetQuestionPassageHandler)
+			questionPassages.PUT("/:passageID", controllersNew.UpdateQuestionPassageHandler)
+			questionPassages.DELETE("/:passageID", middlewares.PrivilegedMiddleware("coordinator"), controllersNew.DeleteQuestionPassageHandler)
+		}
+
+		// Full-text search over the question text, options and explanations of every format.
+		questions.GET(
+			"/search", // ?q=&level=&id=&format=&status=&limit=&offset=
+			middlewares.PrivilegedMiddleware("volunteer"), // Privileges check for "volunteer"
+			controllersNew.SearchQuestionsHandler,
+		)
 
 		// Endpoint to add single/individual question.
+		// Likely duplicates of stored questions are refused with 409, ?duplicates=force adds them anyway.
 		questions.POST(
 			"/add-question",
-			// middlewares.PrivilegedMiddleware("admin"), // Privileges check for "admin"
+			middlewares.PrivilegedMiddleware("volunteer"), // Privileges check for "volunteer", only admins create the missing hierarchy nodes
 			controllers.AddSingleQuestionHandler,
 		)
+	}
 
+	// File routes take or return files (markdown text, spreadsheets, exports) instead of JSON,
+	// so they are kept out of the JSON-only group.
+	questionFiles := router.Group("/questions")
+	{
 		// Endpoint to add bulk/multiple questions in a go.
-		questions.POST(
+		// ?dryRun=true validates the entries without writing them, ?format=jsonl reads JSON Lines exports.
+		// The questions land as DRAFT, ?keepStatus=true (admins only) keeps the status of the exported questions.
+		// Likely duplicates are skipped and reported, ?duplicates=force imports them anyway.
+		questionFiles.POST(
 			"/add-bulk-questions",
-			// middlewares.PrivilegedMiddleware("admin"), // Privileges check for "admin"
+			middlewares.PrivilegedMiddleware("volunteer"), // Privileges check for "volunteer", only admins create the missing hierarchy nodes
 			controllers.AddBulkQuestionHandler,
 		)
+
+		// Endpoint to import questions from a CSV/XLSX file (multipart form: file + JSON column mapping).
+		// ?dryRun=true validates the rows without writing them, ?duplicates=force imports the likely duplicates.
+		// The questions land as DRAFT, ?keepStatus=true (admins only) keeps the status of the exported questions.
+		questionFiles.POST(
+			"/import-spreadsheet",
+			middlewares.PrivilegedMiddleware("volunteer"), // Privileges check for "volunteer", only admins create the missing hierarchy nodes
+			controllersNew.ImportQuestionSpreadsheet,
+		)
+
+		// Endpoint to import a zip holding a markdown, JSON Lines, CSV or XLSX question file along with the images
+		// it refers to (multipart form: file + JSON column mapping for CSV/XLSX files).
+		// ?dryRun=true validates the entries and their images without writing them, ?duplicates=force imports the likely duplicates.
+		// The questions land as DRAFT, ?keepStatus=true (admins only) keeps the status of the exported questions.
+		questionFiles.POST(
+			"/import-archive",
+			middlewares.PrivilegedMiddleware("volunteer"), // Privileges check for "volunteer", only admins create the missing hierarchy nodes
+			controllersNew.ImportQuestionArchive,
+		)
+
+		// Image attachments of a question or of its MCQ options, served through short-lived signed URLs.
+		// Uploads take a multipart form: file, optionIndex (zero-based, MCQ only) and altText.
+		questionAttachments := questionFiles.Group("/items/:formatID/:questionID/attachments")
+		questionAttachments.Use(middlewares.PrivilegedMiddleware("admin")) // Privileges check for "admin"
+		{
+			questionAttachments.GET("", controllersNew.ListQuestionAttachmentsHandler)
+			questionAttachments.POST("", controllersNew.UploadQuestionAttachmentHandler)
+			questionAttachments.DELETE("/:attachmentID", controllersNew.DeleteQuestionAttachmentHandler)
+		}
+
+		// Endpoint to export the questions under a hierarchy node as JSON Lines, CSV or markdown, or as a zip of
+		// JSON Lines and images read back by /import-archive.
+		questionFiles.GET(
+			"/export",
+			middlewares.PrivilegedMiddleware("admin"), // Privileges check for "admin"
+			controllersNew.ExportQuestionsHandler,
+		)
 	}
 }
 
 // Example Requests:
 
-// POST /add_question?type=MCQ
+// POST /questions/add-question?type=MCQ
 // Content-Type: application/json
 // {
-//   "question": "What is 2+2?",
-//   "options": ["2", "3", "4", "5"],
-//   "correct_option": 2,
-//   "category": "Math",
+//   "domain": "Math",
+//   "subDomain": "Arithmetic",
+//   "niche": "Basic Operations",
 //   "difficulty": "Easy",
-//   "tags": ["basic", "arithmetic"]
+//   "questionText": "What is 2+2?",
+//   "options": ["2", "3", "4", "5"],
+//   "answer": "4"
 // }
 
 // Fetch all questions of any type:
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"math"
+	"slices"
+)
+
+// Abilities are Elo ratings: a student and a question of the same rating have even odds of a correct answer,
+// every 400 points of difference multiply the odds by 10. Questions are rated by their difficulty level.
+
+// InitialAbilityRating is the rating of a student who hasn't answered any question of a niche yet
+const InitialAbilityRating = 1500.0
+
+// DifficultyRatings is the rating of the questions of each difficulty level
+var DifficultyRatings = map[string]float64{
+	"EASY":   1300,
+	"MEDIUM": 1500,
+	"HARD":   1700,
+}
+
+// Steps of the rating updates, larger while the estimate is provisional so that it converges quickly
+const (
+	provisionalAbilityK       = 48.0
+	establishedAbilityK       = 24.0
+	provisionalAbilityAnswers = 10 // Graded answers after which the estimate is established
+)
+
+// ExpectedScore returns the chance that a student of the ability answers a question of the difficulty rating correctly
+func ExpectedScore(ability, difficultyRating float64) float64 {
+	return 1 / (1 + math.Pow(10, (difficultyRating-ability)/400))
+}
+
+// UpdateAbility returns the ability after a graded answer to a question of the difficulty rating.
+// credit is the share of the marks earned (0 to 1) and gradedAnswers the number of answers graded before this one.
+func UpdateAbility(ability, difficultyRating, credit float64, gradedAnswers int) float64 {
+	k := establishedAbilityK
+	if gradedAnswers < provisionalAbilityAnswers {
+		k = provisionalAbilityK
+	}
+	return ability + k*(credit-ExpectedScore(ability, difficultyRating))
+}
+
+// DifficultyLevelsForAbility returns the difficulty levels from the best to the worst match for the ability,
+// the closest rating first. Ties go to the easier level.
+func DifficultyLevelsForAbility(ability float64) []string {
+	levels := []string{"EASY", "MEDIUM", "HARD"}
+	slices.SortStableFunc(levels, func(a, b string) int {
+		distanceA := math.Abs(DifficultyRatings[a] - ability)
+		distanceB := math.Abs(DifficultyRatings[b] - ability)
+		switch {
+		case distanceA < distanceB:
+			return -1
+		case distanceA > distanceB:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return levels
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestExpectedScore(t *testing.T) {
+	tests := []struct {
+		ability          float64
+		difficultyRating float64
+		want             float64
+	}{
+		{1500, 1500, 0.5},
+		{1900, 1500, 10.0 / 11},
+		{1100, 1500, 1.0 / 11},
+	}
+	for _, test := range tests {
+		if got := ExpectedScore(test.ability, test.difficultyRating); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("ExpectedScore(%v, %v) = %v, want %v", test.ability, test.difficultyRating, got, test.want)
+		}
+	}
+}
+
+func TestUpdateAbility(t *testing.T) {
+	tests := []struct {
+		name          string
+		ability       float64
+		difficulty    string
+		credit        float64
+		gradedAnswers int
+		want          float64
+	}{
+		{"provisional correct answer at even odds", 1500, "MEDIUM", 1, 0, 1524},
+		{"provisional wrong answer at even odds", 1500, "MEDIUM", 0, 9, 1476},
+		{"established correct answer at even odds", 1500, "MEDIUM", 1, 10, 1512},
+		{"half credit at even odds leaves the ability", 1500, "MEDIUM", 0.5, 30, 1500},
+		{"correct answer to an easier question earns less", 1500, "EASY", 1, 30, 1500 + 24*(1-ExpectedScore(1500, 1300))},
+		{"wrong answer to a harder question costs less", 1500, "HARD", 0, 30, 1500 - 24*ExpectedScore(1500, 1700)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := UpdateAbility(test.ability, DifficultyRatings[test.difficulty], test.credit, test.gradedAnswers)
+			if math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("UpdateAbility(%v, %s, %v, %d) = %v, want %v",
+					test.ability, test.difficulty, test.credit, test.gradedAnswers, got, test.want)
+			}
+		})
+	}
+}
+
+func TestDifficultyLevelsForAbility(t *testing.T) {
+	tests := []struct {
+		ability float64
+		want    []string
+	}{
+		{InitialAbilityRating, []string{"MEDIUM", "EASY", "HARD"}},
+		{1000, []string{"EASY", "MEDIUM", "HARD"}},
+		{1650, []string{"HARD", "MEDIUM", "EASY"}},
+		{1400, []string{"EASY", "MEDIUM", "HARD"}}, // Ties go to the easier level
+		{1600, []string{"MEDIUM", "HARD", "EASY"}},
+	}
+	for _, test := range tests {
+		if got := DifficultyLevelsForAbility(test.ability); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("DifficultyLevelsForAbility(%v) = %v, want %v", test.ability, got, test.want)
+		}
+	}
+}
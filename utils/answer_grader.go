@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+)
+
+// normalizeAnswer lowercases the answer and collapses all the whitespace so that
+// "  Paris " and "paris" are treated as the same answer.
+func normalizeAnswer(answer string) string {
+	return strings.Join(strings.Fields(strings.ToLower(answer)), " ")
+}
+
+// parseTrueFalse maps the accepted spellings of a true/false answer to a boolean.
+func parseTrueFalse(answer string) (value bool, ok bool) {
+	switch normalizeAnswer(answer) {
+	case "true", "t", "yes", "1":
+		return true, true
+	case "false", "f", "no", "0":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// IsTrueFalseAnswer reports whether the answer is one of the accepted spellings of true or false
+func IsTrueFalseAnswer(answer string) bool {
+	_, ok := parseTrueFalse(answer)
+	return ok
+}
+
+// IsAutoGradable reports whether answers of the given question format can be graded by the server.
+func IsAutoGradable(questionFormat string) bool {
+	switch questionFormat {
+	case "MCQ", "TF", "FIB", "NUM":
+		return true
+	default:
+		return false
+	}
+}
+
+// GradeAnswer grades the answer given by the student against the stored answer key.
+// gradable is false for formats that can't be graded automatically (TXT), in which case
+// correct is always false.
+func GradeAnswer(questionFormat, expectedAnswer, givenAnswer string) (correct bool, gradable bool) {
+	if !IsAutoGradable(questionFormat) {
+		return false, false
+	}
+
+	// A skipped question is never correct
+	if strings.TrimSpace(givenAnswer) == "" {
+		return false, true
+	}
+
+	switch questionFormat {
+	case "TF":
+		expected, okExpected := parseTrueFalse(expectedAnswer)
+		given, okGiven := parseTrueFalse(givenAnswer)
+		return okExpected && okGiven && expected == given, true
+	case "NUM": // Graded with the default tolerance, use GradeNumericAnswer to grade against the tolerance of the question
+		value, percent, err := ParseNumericAnswer(expectedAnswer, "")
+		if err != nil {
+			return false, true
+		}
+		key := NumericAnswerKey{Value: value, Tolerance: DefaultRelativeTolerance, RelativeTolerance: true, Percent: percent}
+		return GradeNumericAnswer(key, givenAnswer), true
+	default: // MCQ and FIB compare the normalized text
+		return normalizeAnswer(expectedAnswer) == normalizeAnswer(givenAnswer), true
+	}
+}
+
+// GradeOptionSelection grades the options chosen for an MCQ question, as a credit between 0 and 1.
+// A single-select question is worth 1 when the correct option alone is chosen. A multi-select question
+// earns a share per correct option chosen, minus a share per wrong option chosen, never below 0.
+func GradeOptionSelection(correctOptions, chosenOptions []int32, multiSelect bool) float64 {
+	if len(correctOptions) == 0 || len(chosenOptions) == 0 {
+		return 0
+	}
+
+	if !multiSelect {
+		if len(chosenOptions) == 1 && chosenOptions[0] == correctOptions[0] {
+			return 1
+		}
+		return 0
+	}
+
+	hits, misses := 0, 0
+	seen := make(map[int32]bool, len(chosenOptions))
+	for _, option := range chosenOptions {
+		if seen[option] {
+			continue // An option chosen twice counts once
+		}
+		seen[option] = true
+		if slices.Contains(correctOptions, option) {
+			hits++
+		} else {
+			misses++
+		}
+	}
+	return max(0, float64(hits-misses)/float64(len(correctOptions)))
+}
+
+// GradeArrangement grades the answer to a matching or ordering question, as a credit between 0 and 1.
+// The arrangement holds the position in the question of the item given at each place: the column B item matched
+// to each column A item, or the item put at each position of the sequence. The correct arrangement leaves every
+// item in its place, each of the size places earns an equal share.
+func GradeArrangement(arrangement []int32, size int) float64 {
+	if size == 0 {
+		return 0
+	}
+
+	hits := 0
+	for place, item := range arrangement {
+		if place < size && int(item) == place {
+			hits++
+		}
+	}
+	return float64(hits) / float64(size)
+}
@@ -0,0 +1,110 @@
+package utils
+
+import "testing"
+
+func TestGradeAnswer(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       string
+		expected     string
+		given        string
+		wantCorrect  bool
+		wantGradable bool
+	}{
+		{"MCQ same text", "MCQ", "Paris", "Paris", true, true},
+		{"MCQ ignores case and spaces", "MCQ", "New  Delhi", "  new delhi ", true, true},
+		{"MCQ wrong option", "MCQ", "Paris", "Rome", false, true},
+		{"FIB same text", "FIB", "photosynthesis", "Photosynthesis", true, true},
+		{"FIB wrong word", "FIB", "photosynthesis", "respiration", false, true},
+		{"TF spellings", "TF", "True", "yes", true, true},
+		{"TF numeric spelling", "TF", "False", "0", true, true},
+		{"TF wrong value", "TF", "True", "F", false, true},
+		{"TF unknown spelling", "TF", "True", "maybe", false, true},
+		{"NUM within default tolerance", "NUM", "100", "100.05", true, true},
+		{"NUM outside default tolerance", "NUM", "100", "101", false, true},
+		{"NUM fraction", "NUM", "1/4", "0.25", true, true},
+		{"skipped question", "MCQ", "Paris", "   ", false, true},
+		{"TXT isn't gradable", "TXT", "Any essay", "Any essay", false, false},
+		{"unknown format isn't gradable", "XYZ", "a", "a", false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			correct, gradable := GradeAnswer(test.format, test.expected, test.given)
+			if correct != test.wantCorrect || gradable != test.wantGradable {
+				t.Errorf("GradeAnswer(%q, %q, %q) = %v, %v, want %v, %v",
+					test.format, test.expected, test.given, correct, gradable, test.wantCorrect, test.wantGradable)
+			}
+		})
+	}
+}
+
+func TestIsTrueFalseAnswer(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   bool
+	}{
+		{"True", true},
+		{" false ", true},
+		{"T", true},
+		{"no", true},
+		{"1", true},
+		{"", false},
+		{"truth", false},
+	}
+	for _, test := range tests {
+		if got := IsTrueFalseAnswer(test.answer); got != test.want {
+			t.Errorf("IsTrueFalseAnswer(%q) = %v, want %v", test.answer, got, test.want)
+		}
+	}
+}
+
+func TestGradeOptionSelection(t *testing.T) {
+	tests := []struct {
+		name        string
+		correct     []int32
+		chosen      []int32
+		multiSelect bool
+		want        float64
+	}{
+		{"single-select correct option", []int32{2}, []int32{2}, false, 1},
+		{"single-select wrong option", []int32{2}, []int32{1}, false, 0},
+		{"single-select several options", []int32{2}, []int32{2, 1}, false, 0},
+		{"nothing chosen", []int32{0, 2}, nil, true, 0},
+		{"multi-select all correct options", []int32{0, 2}, []int32{2, 0}, true, 1},
+		{"multi-select half the correct options", []int32{0, 2}, []int32{0}, true, 0.5},
+		{"a wrong option cancels a right one", []int32{0, 2}, []int32{0, 1}, true, 0},
+		{"credit never goes below zero", []int32{0, 2}, []int32{1, 3}, true, 0},
+		{"an option chosen twice counts once", []int32{0, 1, 2}, []int32{0, 0, 0}, true, 1.0 / 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := GradeOptionSelection(test.correct, test.chosen, test.multiSelect); got != test.want {
+				t.Errorf("GradeOptionSelection(%v, %v, %v) = %v, want %v",
+					test.correct, test.chosen, test.multiSelect, got, test.want)
+			}
+		})
+	}
+}
+
+func TestGradeArrangement(t *testing.T) {
+	tests := []struct {
+		name        string
+		arrangement []int32
+		size        int
+		want        float64
+	}{
+		{"every item in its place", []int32{0, 1, 2, 3}, 4, 1},
+		{"half the items in their place", []int32{0, 1, 3, 2}, 4, 0.5},
+		{"no item in its place", []int32{1, 2, 3, 0}, 4, 0},
+		{"places left empty", []int32{0, -1}, 4, 0.25},
+		{"places beyond the size are ignored", []int32{0, 1, 2}, 2, 1},
+		{"no items", nil, 0, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := GradeArrangement(test.arrangement, test.size); got != test.want {
+				t.Errorf("GradeArrangement(%v, %d) = %v, want %v", test.arrangement, test.size, got, test.want)
+			}
+		})
+	}
+}
@@ -56,10 +56,23 @@ func loadJWTKey() ([]byte, error) {
 
 // GenerateToken creates a JWT with user role or privileges
 func GenerateToken(username, role string, expiryHours float32) (string, error) {
+	return generateToken(username, role, expiryHours, nil)
+}
+
+// GenerateStudentToken creates a JWT with the "common" role for a student, carrying their enrollment number
+// so that the student routes act on behalf of that student only
+func GenerateStudentToken(enrollmentNo string, expiryHours float32) (string, error) {
+	return generateToken("student_user", "common", expiryHours, jwt.MapClaims{"enrollmentNo": enrollmentNo})
+}
+
+func generateToken(username, role string, expiryHours float32, extraClaims jwt.MapClaims) (string, error) {
 	token := jwt.New(jwt.SigningMethodHS256)
 	expirationTime := time.Now().Add(time.Duration(expiryHours) * time.Hour).Unix()
 
 	claims := token.Claims.(jwt.MapClaims)
+	for name, value := range extraClaims {
+		claims[name] = value
+	}
 	claims["authorized"] = true
 	claims["username"] = username
 	claims["role"] = role // Add role to token claims
@@ -121,9 +134,11 @@ func HasPrivilege(claims jwt.MapClaims, requiredRole string) bool {
 
 	// Define role hierarchy
 	roleHierarchy := map[string]int{
-		"common": 1, // Basic users
-		"admin":  2, // Admin users
-		"master": 3, // Master users
+		"common":      1, // Basic users
+		"volunteer":   2, // Volunteers writing questions, submit them for review
+		"coordinator": 3, // Coordinators reviewing questions, publish and retire them
+		"admin":       4, // Admin users
+		"master":      5, // Master users
 	}
 
 	// Check if the user's role has sufficient privilege
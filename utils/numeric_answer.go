@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultRelativeTolerance is the tolerance of the numeric questions that don't give one, 0.1% of the value.
+// Enough for "0.1667" to match 1/6, not enough to accept a wrong rounding like "0.17".
+const DefaultRelativeTolerance = 0.001
+
+// thousandsSeparated matches the numbers written with comma thousands separators, e.g. "1,250.5"
+var thousandsSeparated = regexp.MustCompile(`^[+-]?\d{1,3}(,\d{3})+(\.\d+)?$`)
+
+// NumericAnswerKey is what the answers to a numeric question are graded against
+type NumericAnswerKey struct {
+	Value             float64
+	Tolerance         float64 // Absolute, or a fraction of Value when RelativeTolerance is set
+	RelativeTolerance bool
+	Unit              string // Unit the answers may be followed by, e.g. "km/h"
+	Percent           bool   // The answer key is a percentage, bare numbers given are read as percentages too
+}
+
+// ParseNumericAnswer reads a number written as a decimal ("0.1667", "-2.5e3", "1,250"), a fraction ("1/6"),
+// a mixed number ("1 1/2") or a percentage ("16.67%"), optionally followed by the unit of the question.
+// The value of a percentage is already divided by 100, percent tells how the number was written.
+func ParseNumericAnswer(answer, unit string) (value float64, percent bool, err error) {
+	text := strings.TrimSpace(answer)
+	if unit != "" && len(text) > len(unit) && strings.EqualFold(text[len(text)-len(unit):], unit) {
+		text = strings.TrimSpace(text[:len(text)-len(unit)])
+	}
+	if strings.HasSuffix(text, "%") {
+		percent = true
+		text = strings.TrimSpace(strings.TrimSuffix(text, "%"))
+	}
+	if thousandsSeparated.MatchString(text) {
+		text = strings.ReplaceAll(text, ",", "")
+	}
+
+	switch parts := strings.Fields(text); {
+	case len(parts) == 2: // Mixed number, the sign of the whole part applies to the fraction
+		whole, err := parseDecimal(parts[0])
+		if err != nil || math.Trunc(whole) != whole {
+			return 0, false, fmt.Errorf("%q is not a number", answer)
+		}
+		fraction, err := parseFraction(parts[1])
+		if err != nil || fraction < 0 || fraction >= 1 {
+			return 0, false, fmt.Errorf("%q is not a number", answer)
+		}
+		value = whole + fraction
+		if strings.HasPrefix(parts[0], "-") {
+			value = whole - fraction
+		}
+	case len(parts) == 1 && strings.Contains(parts[0], "/"):
+		if value, err = parseFraction(parts[0]); err != nil {
+			return 0, false, fmt.Errorf("%q is not a number: %w", answer, err)
+		}
+	case len(parts) == 1:
+		if value, err = parseDecimal(parts[0]); err != nil {
+			return 0, false, fmt.Errorf("%q is not a number", answer)
+		}
+	default:
+		return 0, false, fmt.Errorf("%q is not a number", answer)
+	}
+
+	if percent {
+		value /= 100
+	}
+	return value, percent, nil
+}
+
+// parseDecimal parses a finite decimal number, "inf" and "nan" are refused
+func parseDecimal(text string) (float64, error) {
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, errors.New("not a finite number")
+	}
+	return value, nil
+}
+
+// parseFraction parses "<numerator>/<denominator>"
+func parseFraction(text string) (float64, error) {
+	numerator, denominator, found := strings.Cut(text, "/")
+	if !found {
+		return parseDecimal(text)
+	}
+	n, err := parseDecimal(numerator)
+	if err != nil {
+		return 0, err
+	}
+	d, err := parseDecimal(denominator)
+	if err != nil {
+		return 0, err
+	}
+	if d == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return n / d, nil
+}
+
+// ParseTolerance reads the tolerance of a numeric question, "0.01" is absolute and "0.5%" is relative
+// to the value. An empty tolerance falls back to DefaultRelativeTolerance.
+func ParseTolerance(tolerance string) (value float64, relative bool, err error) {
+	text := strings.TrimSpace(tolerance)
+	if text == "" {
+		return DefaultRelativeTolerance, true, nil
+	}
+	if strings.HasSuffix(text, "%") {
+		relative = true
+		text = strings.TrimSpace(strings.TrimSuffix(text, "%"))
+	}
+	if value, err = parseDecimal(text); err != nil || value < 0 {
+		return 0, false, fmt.Errorf("tolerance %q must be a positive number, or a percentage of the answer", tolerance)
+	}
+	if relative {
+		value /= 100
+	}
+	return value, relative, nil
+}
+
+// FormatTolerance writes a tolerance the way ParseTolerance reads it
+func FormatTolerance(value float64, relative bool) string {
+	if relative {
+		return strconv.FormatFloat(value*100, 'g', -1, 64) + "%"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// GradeNumericAnswer reports whether the given answer is within the tolerance of the answer key.
+// When the key is a percentage, "16.67" is read as 16.67% just like "16.67%" and "0.1667" are.
+func GradeNumericAnswer(key NumericAnswerKey, givenAnswer string) bool {
+	value, percent, err := ParseNumericAnswer(givenAnswer, key.Unit)
+	if err != nil {
+		return false
+	}
+	if key.withinTolerance(value) {
+		return true
+	}
+	return key.Percent && !percent && key.withinTolerance(value/100)
+}
+
+func (key NumericAnswerKey) withinTolerance(value float64) bool {
+	allowed := key.Tolerance
+	if key.RelativeTolerance {
+		allowed *= math.Abs(key.Value)
+	}
+	// Leaves room for the rounding of the float arithmetic, so that "1/3" matches "0.333...3"
+	allowed += 1e-9 * math.Max(1, math.Abs(key.Value))
+	return math.Abs(value-key.Value) <= allowed
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseNumericAnswer(t *testing.T) {
+	tests := []struct {
+		answer      string
+		unit        string
+		wantValue   float64
+		wantPercent bool
+		wantErr     bool
+	}{
+		{"0.1667", "", 0.1667, false, false},
+		{" -2.5e3 ", "", -2500, false, false},
+		{"1,250.5", "", 1250.5, false, false},
+		{"1/6", "", 1.0 / 6, false, false},
+		{"1 1/2", "", 1.5, false, false},
+		{"-1 1/2", "", -1.5, false, false},
+		{"16.67%", "", 0.1667, true, false},
+		{"12.5 km/h", "km/h", 12.5, false, false},
+		{"12.5KM/H", "km/h", 12.5, false, false},
+		{"1,25", "", 0, false, true},
+		{"1/0", "", 0, false, true},
+		{"1.5 1/2", "", 0, false, true},
+		{"1 3/2", "", 0, false, true},
+		{"inf", "", 0, false, true},
+		{"twelve", "", 0, false, true},
+		{"", "", 0, false, true},
+	}
+	for _, test := range tests {
+		value, percent, err := ParseNumericAnswer(test.answer, test.unit)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseNumericAnswer(%q, %q) error = %v, want error %v", test.answer, test.unit, err, test.wantErr)
+			continue
+		}
+		if math.Abs(value-test.wantValue) > 1e-12 || percent != test.wantPercent {
+			t.Errorf("ParseNumericAnswer(%q, %q) = %v, %v, want %v, %v",
+				test.answer, test.unit, value, percent, test.wantValue, test.wantPercent)
+		}
+	}
+}
+
+func TestParseTolerance(t *testing.T) {
+	tests := []struct {
+		tolerance    string
+		wantValue    float64
+		wantRelative bool
+		wantErr      bool
+	}{
+		{"", DefaultRelativeTolerance, true, false},
+		{"0.01", 0.01, false, false},
+		{"0.5%", 0.005, true, false},
+		{" 2 % ", 0.02, true, false},
+		{"-1", 0, false, true},
+		{"about 1", 0, false, true},
+	}
+	for _, test := range tests {
+		value, relative, err := ParseTolerance(test.tolerance)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseTolerance(%q) error = %v, want error %v", test.tolerance, err, test.wantErr)
+			continue
+		}
+		if math.Abs(value-test.wantValue) > 1e-12 || relative != test.wantRelative {
+			t.Errorf("ParseTolerance(%q) = %v, %v, want %v, %v", test.tolerance, value, relative, test.wantValue, test.wantRelative)
+		}
+		if err != nil || test.tolerance == "" {
+			continue
+		}
+
+		// The tolerance written back reads as the same tolerance
+		formatted := FormatTolerance(value, relative)
+		if again, againRelative, err := ParseTolerance(formatted); err != nil || math.Abs(again-value) > 1e-12 || againRelative != relative {
+			t.Errorf("ParseTolerance(FormatTolerance(%v, %v) = %q) = %v, %v, %v", value, relative, formatted, again, againRelative, err)
+		}
+	}
+}
+
+func TestGradeNumericAnswer(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   NumericAnswerKey
+		given string
+		want  bool
+	}{
+		{"exact value", NumericAnswerKey{Value: 12.5}, "12.5", true},
+		{"within the absolute tolerance", NumericAnswerKey{Value: 12.5, Tolerance: 0.1}, "12.58", true},
+		{"outside the absolute tolerance", NumericAnswerKey{Value: 12.5, Tolerance: 0.1}, "12.7", false},
+		{"within the relative tolerance", NumericAnswerKey{Value: 200, Tolerance: 0.01, RelativeTolerance: true}, "198", true},
+		{"outside the relative tolerance", NumericAnswerKey{Value: 200, Tolerance: 0.01, RelativeTolerance: true}, "197", false},
+		{"relative tolerance of a negative value", NumericAnswerKey{Value: -200, Tolerance: 0.01, RelativeTolerance: true}, "-202", true},
+		{"fraction matches the rounded decimal", NumericAnswerKey{Value: 1.0 / 3}, "0.333333333333", true},
+		{"decimal matches the fraction", NumericAnswerKey{Value: 0.25}, "1/4", true},
+		{"answer with the unit", NumericAnswerKey{Value: 12.5, Unit: "km/h"}, "12.5 km/h", true},
+		{"answer with another unit", NumericAnswerKey{Value: 12.5, Unit: "km/h"}, "12.5 m/s", false},
+		{"percentage key, percentage given", NumericAnswerKey{Value: 0.1667, Percent: true}, "16.67%", true},
+		{"percentage key, fraction given", NumericAnswerKey{Value: 0.1667, Percent: true}, "0.1667", true},
+		{"percentage key, bare percentage given", NumericAnswerKey{Value: 0.1667, Percent: true}, "16.67", true},
+		{"bare percentage without a percentage key", NumericAnswerKey{Value: 0.1667}, "16.67", false},
+		{"not a number", NumericAnswerKey{Value: 1}, "one", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := GradeNumericAnswer(test.key, test.given); got != test.want {
+				t.Errorf("GradeNumericAnswer(%+v, %q) = %v, want %v", test.key, test.given, got, test.want)
+			}
+		})
+	}
+}
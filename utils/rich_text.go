@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	goldmarkHTML "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Rich text is the content format of the question texts, options and explanations: Markdown (CommonMark with
+// tables and strikethrough, line breaks kept) along with LaTeX math, $x^2$ inline and $$\frac{a}{b}$$ on its own.
+// A "$" followed by a space or closed before a digit stays a dollar sign, so "$5 to $10" is plain text; "\$"
+// always is. Math can't span lines.
+//
+// Raw HTML, images (attach them to the question instead) and links other than http, https and mailto are
+// rejected on ingest. The rendered HTML leaves them out too, so content stored before the check is served safely.
+// Math is rendered by the clients (KaTeX or MathJax) from <span class="math math-inline">\(...\)</span> and
+// <span class="math math-display">\[...\]</span>, with its TeX escaped.
+
+// MaxMathLength bounds the TeX of a single math expression
+const MaxMathLength = 1000
+
+// allowedLinkSchemes are the schemes the links of rich text can point to
+var allowedLinkSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// forbiddenTeXCommands are the commands that load external content or define macros, which the clients
+// refuse (or expand without bound) anyway
+var forbiddenTeXCommands = regexp.MustCompile(`\\(href|url|includegraphics|html[A-Za-z]*|def|gdef|edef|xdef|let|newcommand|renewcommand|providecommand)\b`)
+
+// kindMath is the AST node kind of a math expression
+var kindMath = ast.NewNodeKind("Math")
+
+// mathNode is a math expression of rich text
+type mathNode struct {
+	ast.BaseInline
+	TeX     []byte
+	Display bool
+}
+
+func (n *mathNode) Kind() ast.NodeKind {
+	return kindMath
+}
+
+func (n *mathNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"TeX": string(n.TeX)}, nil)
+}
+
+// mathParser reads the math expressions written between dollar signs
+type mathParser struct{}
+
+func (mathParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (mathParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	delimiter := 1
+	if len(line) > 1 && line[1] == '$' {
+		delimiter = 2
+	}
+
+	// The opening delimiter is followed by the expression, not by a space
+	if len(line) <= delimiter || line[delimiter] == ' ' || line[delimiter] == '\t' {
+		return nil
+	}
+
+	for i := delimiter; i < len(line); i++ {
+		switch {
+		case line[i] == '\\':
+			i++ // TeX escapes, "\$" included
+		case line[i] != '$':
+		case delimiter == 2:
+			if i+1 < len(line) && line[i+1] == '$' {
+				block.Advance(i + 2)
+				return &mathNode{TeX: bytes.TrimSpace(line[2:i]), Display: true}
+			}
+		case line[i-1] != ' ' && line[i-1] != '\t' && (i+1 == len(line) || line[i+1] < '0' || line[i+1] > '9'):
+			block.Advance(i + 1)
+			return &mathNode{TeX: line[1:i]}
+		}
+	}
+	return nil
+}
+
+// richTextRenderer renders the math expressions and drops the images of rich text
+type richTextRenderer struct{}
+
+func (richTextRenderer) RegisterFuncs(registerer renderer.NodeRendererFuncRegisterer) {
+	registerer.Register(kindMath, renderMath)
+	registerer.Register(ast.KindImage, renderImageAlt)
+}
+
+func renderMath(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	math := node.(*mathNode)
+	if math.Display {
+		_, _ = w.WriteString(`<span class="math math-display">\[`)
+		_, _ = w.WriteString(html.EscapeString(string(math.TeX)))
+		_, _ = w.WriteString(`\]</span>`)
+	} else {
+		_, _ = w.WriteString(`<span class="math math-inline">\(`)
+		_, _ = w.WriteString(html.EscapeString(string(math.TeX)))
+		_, _ = w.WriteString(`\)</span>`)
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// renderImageAlt renders an image as its alt text, the images of the questions are served as attachments
+func renderImageAlt(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString(html.EscapeString(string(node.Text(source))))
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// richText parses and renders rich text, raw HTML is never rendered
+var richText = goldmark.New(
+	goldmark.WithExtensions(extension.Table, extension.Strikethrough),
+	goldmark.WithParserOptions(parser.WithInlineParsers(util.Prioritized(mathParser{}, 500))),
+	goldmark.WithRendererOptions(
+		goldmarkHTML.WithHardWraps(),
+		renderer.WithNodeRenderers(util.Prioritized(richTextRenderer{}, 100)),
+	),
+)
+
+// ValidateRichText checks that the text only uses the supported rich text: no raw HTML, no images,
+// links to http, https or mailto only and math without the forbidden commands
+func ValidateRichText(content string) error {
+	source := []byte(content)
+	document := richText.Parser().Parse(text.NewReader(source))
+
+	var problem error
+	_ = ast.Walk(document, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || problem != nil {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := node.(type) {
+		case *ast.RawHTML, *ast.HTMLBlock:
+			problem = errors.New(`HTML isn't allowed, write "<" as "\<" when it isn't a tag`)
+		case *ast.Image:
+			problem = errors.New("images can't be embedded in the text, attach them to the question instead")
+		case *ast.Link:
+			problem = validateLinkURL(string(n.Destination))
+		case *ast.AutoLink:
+			if n.AutoLinkType == ast.AutoLinkURL {
+				problem = validateLinkURL(string(n.URL(source)))
+			}
+		case *mathNode:
+			problem = validateMath(string(n.TeX))
+		}
+		return ast.WalkContinue, nil
+	})
+	return problem
+}
+
+// validateLinkURL checks that a link points to an allowed scheme
+func validateLinkURL(destination string) error {
+	link, err := url.Parse(strings.TrimSpace(destination))
+	if err != nil || !allowedLinkSchemes[strings.ToLower(link.Scheme)] {
+		return fmt.Errorf("link %q must be an http, https or mailto URL", destination)
+	}
+	return nil
+}
+
+// validateMath checks the TeX of a math expression
+func validateMath(tex string) error {
+	if len(tex) > MaxMathLength {
+		return fmt.Errorf("math expressions can't be longer than %d characters", MaxMathLength)
+	}
+	if command := forbiddenTeXCommands.FindString(tex); command != "" {
+		return fmt.Errorf("math can't use %s", command)
+	}
+	return nil
+}
+
+// RenderRichText renders rich text to HTML that can be inserted in a page as is.
+// Text that fails to render is returned escaped.
+func RenderRichText(content string) string {
+	var rendered bytes.Buffer
+	if err := richText.Convert([]byte(content), &rendered); err != nil {
+		return html.EscapeString(content)
+	}
+	return strings.TrimSpace(rendered.String())
+}
+
+// RenderRichTexts renders each of the texts, nil when there are none
+func RenderRichTexts(contents []string) []string {
+	if len(contents) == 0 {
+		return nil
+	}
+	rendered := make([]string, 0, len(contents))
+	for _, content := range contents {
+		rendered = append(rendered, RenderRichText(content))
+	}
+	return rendered
+}
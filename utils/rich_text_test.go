@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRichText(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string // Part of the error expected, none when empty
+	}{
+		{"plain text", "What is 2 + 2?", ""},
+		{"markdown", "**Bold**, _italic_, ~~struck~~ and `code`\n\n| a | b |\n|---|---|\n| 1 | 2 |", ""},
+		{"inline and display math", `Solve $x^2 = 4$ and simplify $$\frac{a}{b}$$`, ""},
+		{"dollar amounts aren't math", `It costs $5 to $10, or \$20`, ""},
+		{"escaped tag", `Is 2 \<b\> 3?`, ""},
+		{"http, https and mailto links", "[site](https://example.com), <http://example.com> and [mail](mailto:a@example.com)", ""},
+		{"raw HTML tag", "Click <b>here</b>", "HTML isn't allowed"},
+		{"HTML block", "<div>\nhello\n</div>", "HTML isn't allowed"},
+		{"script", "<script>alert(1)</script>", "HTML isn't allowed"},
+		{"embedded image", "![chart](https://example.com/chart.png)", "images can't be embedded"},
+		{"javascript link", "[click](javascript:alert(1))", "must be an http, https or mailto URL"},
+		{"data link", "[click](data:text/html;base64,PHNjcmlwdD4=)", "must be an http, https or mailto URL"},
+		{"relative link", "[page](/admin)", "must be an http, https or mailto URL"},
+		{"math loading a URL", `$\href{https://example.com}{x}$`, `\href`},
+		{"math defining a macro", `$$\def\x{1}\x$$`, `\def`},
+		{"math too long", "$" + strings.Repeat("x", MaxMathLength+1) + "$", "can't be longer"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateRichText(test.content)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateRichText(%q) = %v, want no error", test.content, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("ValidateRichText(%q) = %v, want an error containing %q", test.content, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderRichText(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		want       []string // Parts of the HTML expected
+		wantAbsent []string // Parts of the HTML that must be left out
+	}{
+		{
+			name:    "markdown and line breaks",
+			content: "**Bold** text\nnext line",
+			want:    []string{"<strong>Bold</strong>", "<br>"},
+		},
+		{
+			name:    "inline math",
+			content: "Solve $x < y$",
+			want:    []string{`<span class="math math-inline">\(x &lt; y\)</span>`},
+		},
+		{
+			name:    "display math",
+			content: `$$\frac{a}{b}$$`,
+			want:    []string{`<span class="math math-display">\[\frac{a}{b}\]</span>`},
+		},
+		{
+			name:       "dollar amounts stay text",
+			content:    "It costs $5 to $10",
+			want:       []string{"It costs $5 to $10"},
+			wantAbsent: []string{"math"},
+		},
+		{
+			name:       "raw HTML stored before the check",
+			content:    `<script>alert(1)</script><img src=x onerror="alert(1)">`,
+			wantAbsent: []string{"<script", "<img", "onerror"},
+		},
+		{
+			name:       "image rendered as its alt text",
+			content:    "![a chart](https://example.com/chart.png)",
+			want:       []string{"a chart"},
+			wantAbsent: []string{"<img", "chart.png"},
+		},
+		{
+			name:       "javascript link stored before the check",
+			content:    "[click](javascript:alert(1))",
+			wantAbsent: []string{"javascript:"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rendered := RenderRichText(test.content)
+			for _, part := range test.want {
+				if !strings.Contains(rendered, part) {
+					t.Errorf("RenderRichText(%q) = %q, want it to contain %q", test.content, rendered, part)
+				}
+			}
+			for _, part := range test.wantAbsent {
+				if strings.Contains(rendered, part) {
+					t.Errorf("RenderRichText(%q) = %q, want it without %q", test.content, rendered, part)
+				}
+			}
+		})
+	}
+
+	if got := RenderRichTexts(nil); got != nil {
+		t.Errorf("RenderRichTexts(nil) = %q, want nil", got)
+	}
+	if got := RenderRichTexts([]string{"a", "*b*"}); len(got) != 2 || got[1] != "<p><em>b</em></p>" {
+		t.Errorf("RenderRichTexts() = %q, want each text rendered", got)
+	}
+}
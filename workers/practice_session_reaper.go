@@ -0,0 +1,222 @@
+// Background worker that expires the practice sessions abandoned by the clients (closed tabs, crashed apps)
+// that were never submitted or forcefully ended.
+package workers
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"server/config"
+	student_psql "server/models/student_psql"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// practiceSessionReaperLockID is the Postgres advisory lock key that makes sure only one
+	// server instance reaps the sessions at a time
+	practiceSessionReaperLockID = 724_001
+
+	defaultPracticeSessionTimeout        = 3 * time.Hour
+	defaultPracticeSessionReaperInterval = 5 * time.Minute
+)
+
+// PracticeSessionReaperConfig holds the timings of the reaper
+type PracticeSessionReaperConfig struct {
+	SessionTimeout time.Duration // Age after which an active session is expired
+	Interval       time.Duration // Time between two runs
+}
+
+// PracticeSessionReaperStatus is the state of the reaper exposed to the admins
+type PracticeSessionReaperStatus struct {
+	SessionTimeoutMinutes int                                         `json:"sessionTimeoutMinutes"`
+	IntervalMinutes       int                                         `json:"intervalMinutes"`
+	LastRun               *student_psql.PracticeSessionReaperRunTable `json:"lastRun"`
+	TotalRuns             int64                                       `json:"totalRuns"`
+	TotalSessionsExpired  int64                                       `json:"totalSessionsExpired"`
+	ActiveSessions        int64                                       `json:"activeSessions"`
+}
+
+// AdaptiveSessionCloser records the questions of an adaptive session left unanswered as skipped and tallies the
+// result of the session from its responses, which were graded (and moved the ability estimates) as they came in
+type AdaptiveSessionCloser func(tx *gorm.DB, record student_psql.StudentPracticeSessionRecordTable) (questionsAttempted, questionsCorrect int, scoreEarned float64, err error)
+
+// durationFromEnv reads a duration in minutes from the environment, falling back to the default
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes <= 0 {
+		log.Printf("Invalid value %q for %s, using default of %s", value, name, fallback)
+		return fallback
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// LoadPracticeSessionReaperConfig reads the reaper timings from the environment
+func LoadPracticeSessionReaperConfig() PracticeSessionReaperConfig {
+	return PracticeSessionReaperConfig{
+		SessionTimeout: durationFromEnv("PRACTICE_SESSION_TIMEOUT_MINUTES", defaultPracticeSessionTimeout),
+		Interval:       durationFromEnv("PRACTICE_SESSION_REAPER_INTERVAL_MINUTES", defaultPracticeSessionReaperInterval),
+	}
+}
+
+// StartPracticeSessionReaper runs the reaper immediately and then at every configured interval.
+// closeAdaptive closes the adaptive sessions it expires. Blocking, run it in its own goroutine.
+func StartPracticeSessionReaper(closeAdaptive AdaptiveSessionCloser) {
+	reaperConfig := LoadPracticeSessionReaperConfig()
+	log.Printf("Practice session reaper started (timeout: %s, interval: %s)", reaperConfig.SessionTimeout, reaperConfig.Interval)
+
+	ticker := time.NewTicker(reaperConfig.Interval)
+	defer ticker.Stop()
+
+	for {
+		expired, err := ReapExpiredPracticeSessions(reaperConfig.SessionTimeout, closeAdaptive)
+		if err != nil {
+			log.Printf("Practice session reaper run failed: %v", err)
+		} else if expired > 0 {
+			log.Printf("Practice session reaper expired %d sessions", expired)
+		}
+		<-ticker.C
+	}
+}
+
+// ReapExpiredPracticeSessions marks the active sessions that started before the timeout as 'Expired'
+// and closes their records. The adaptive sessions are tallied from the answers they were given by closeAdaptive,
+// the others are left unscored. Returns the number of expired sessions, 0 if another instance holds the lock.
+func ReapExpiredPracticeSessions(sessionTimeout time.Duration, closeAdaptive AdaptiveSessionCloser) (int, error) {
+	run := student_psql.PracticeSessionReaperRunTable{StartedAt: time.Now()}
+	run.Instance, _ = os.Hostname()
+
+	lockAcquired := false
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		// Only one instance reaps at a time, the others skip this run.
+		// The lock is released automatically when the transaction ends.
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", practiceSessionReaperLockID).
+			Scan(&lockAcquired).Error; err != nil {
+			return fmt.Errorf("failed to acquire reaper lock: %w", err)
+		}
+		if !lockAcquired {
+			return nil
+		}
+
+		// SKIP LOCKED leaves out the sessions being submitted or ended at this very moment
+		var expiredSessionIDs []uint32
+		if err := tx.Raw(fmt.Sprintf(`
+			UPDATE %[1]s l SET status = 'Expired'
+			WHERE l.practice_session_id IN (
+				SELECT al.practice_session_id
+				FROM %[1]s al
+				JOIN %[2]s r ON r.practice_session_id = al.practice_session_id
+				WHERE al.status = 'Active' AND r.start_time < ?
+				FOR UPDATE OF al SKIP LOCKED
+			)
+			RETURNING l.practice_session_id`,
+			student_psql.StudentPracticeSessionLookupTable{}.TableName(),
+			student_psql.StudentPracticeSessionRecordTable{}.TableName(),
+		), time.Now().Add(-sessionTimeout)).Scan(&expiredSessionIDs).Error; err != nil {
+			return fmt.Errorf("failed to expire practice sessions: %w", err)
+		}
+
+		// Close the records of the expired sessions, nothing was submitted so nothing was scored
+		if len(expiredSessionIDs) > 0 {
+			if err := tx.Model(&student_psql.StudentPracticeSessionRecordTable{}).
+				Where("practice_session_id IN ?", expiredSessionIDs).
+				Updates(map[string]interface{}{
+					"questions_attempted": 0,
+					"questions_correct":   0,
+					"score_earned":        0,
+					"end_time":            time.Now(),
+				}).Error; err != nil {
+				return fmt.Errorf("failed to close expired practice session records: %w", err)
+			}
+		}
+
+		// Adaptive sessions were graded answer by answer instead, their records match their responses
+		var adaptiveRecords []student_psql.StudentPracticeSessionRecordTable
+		if len(expiredSessionIDs) > 0 {
+			if err := tx.Where("practice_session_id IN ? AND mode = ?", expiredSessionIDs, student_psql.PracticeSessionModeAdaptive).
+				Find(&adaptiveRecords).Error; err != nil {
+				return fmt.Errorf("failed to fetch expired adaptive practice sessions: %w", err)
+			}
+		}
+		for _, record := range adaptiveRecords {
+			questionsAttempted, questionsCorrect, scoreEarned, err := closeAdaptive(tx, record)
+			if err != nil {
+				return fmt.Errorf("failed to tally expired practice session %d: %w", record.PracticeSessionID, err)
+			}
+			if err := tx.Model(&record).Updates(map[string]interface{}{
+				"questions_attempted": questionsAttempted,
+				"questions_correct":   questionsCorrect,
+				"score_earned":        scoreEarned,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to close expired practice session %d: %w", record.PracticeSessionID, err)
+			}
+		}
+
+		run.SessionsExpired = len(expiredSessionIDs)
+		return nil
+	})
+
+	if !lockAcquired && err == nil {
+		return 0, nil
+	}
+
+	// Record the run, failed runs included
+	run.FinishedAt = time.Now()
+	if err != nil {
+		run.Error = err.Error()
+		run.SessionsExpired = 0
+	}
+	if createErr := config.GetPostgresDBConnection().Create(&run).Error; createErr != nil {
+		log.Printf("Failed to record practice session reaper run: %v", createErr)
+	}
+
+	return run.SessionsExpired, err
+}
+
+// GetPracticeSessionReaperStatus returns the configuration, the last run and the totals of the reaper
+// across all the server instances
+func GetPracticeSessionReaperStatus() (PracticeSessionReaperStatus, error) {
+	reaperConfig := LoadPracticeSessionReaperConfig()
+	status := PracticeSessionReaperStatus{
+		SessionTimeoutMinutes: int(reaperConfig.SessionTimeout.Minutes()),
+		IntervalMinutes:       int(reaperConfig.Interval.Minutes()),
+	}
+	db := config.GetPostgresDBConnection()
+
+	var lastRun student_psql.PracticeSessionReaperRunTable
+	result := db.Order("started_at DESC").Limit(1).Find(&lastRun)
+	if result.Error != nil {
+		return status, fmt.Errorf("failed to fetch last reaper run: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		status.LastRun = &lastRun
+	}
+
+	var totals struct {
+		TotalRuns            int64
+		TotalSessionsExpired int64
+	}
+	if err := db.Model(&student_psql.PracticeSessionReaperRunTable{}).
+		Select("COUNT(*) AS total_runs", "COALESCE(SUM(sessions_expired), 0) AS total_sessions_expired").
+		Scan(&totals).Error; err != nil {
+		return status, fmt.Errorf("failed to fetch reaper totals: %w", err)
+	}
+	status.TotalRuns = totals.TotalRuns
+	status.TotalSessionsExpired = totals.TotalSessionsExpired
+
+	if err := db.Model(&student_psql.StudentPracticeSessionLookupTable{}).
+		Where("status = ?", "Active").
+		Count(&status.ActiveSessions).Error; err != nil {
+		return status, fmt.Errorf("failed to count active practice sessions: %w", err)
+	}
+
+	return status, nil
+}
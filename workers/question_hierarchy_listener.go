@@ -0,0 +1,74 @@
+// Background worker that listens for the question hierarchy notifications of the database triggers and drops the
+// cached hierarchy of this instance, so that writes made through any instance (or straight to the database) show up.
+package workers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"server/cache"
+	"server/config"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// questionHierarchyListenerRetryInterval is the wait before listening again after the connection is lost
+const questionHierarchyListenerRetryInterval = 10 * time.Second
+
+// StartQuestionHierarchyListener listens for the hierarchy notifications for as long as the server runs.
+// Blocking, run it in its own goroutine.
+func StartQuestionHierarchyListener() {
+	for {
+		err := listenForQuestionHierarchyChanges(context.Background())
+		log.Printf("Question hierarchy listener stopped, retrying in %s: %v", questionHierarchyListenerRetryInterval, err)
+
+		// Notifications sent while disconnected are lost, so the cache can't be trusted anymore
+		cache.InvalidateQuestionHierarchy()
+		time.Sleep(questionHierarchyListenerRetryInterval)
+	}
+}
+
+// listenForQuestionHierarchyChanges holds a connection of the pool to LISTEN on the hierarchy channel
+// and invalidates the cache on every notification. Returns when the connection fails.
+func listenForQuestionHierarchyChanges(ctx context.Context) error {
+	sqlDB, err := config.GetPostgresDBConnection().DB()
+	if err != nil {
+		return fmt.Errorf("failed to get native database handle: %w", err)
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get a database connection: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection %T, LISTEN needs pgx", driverConn)
+		}
+		pgxConn := stdlibConn.Conn()
+
+		if _, err := pgxConn.Exec(ctx, "LISTEN "+pgx.Identifier{cache.QuestionHierarchyChannel}.Sanitize()); err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", cache.QuestionHierarchyChannel, err)
+		}
+		// The connection goes back to the pool when the listener stops, it must not keep listening
+		defer pgxConn.Exec(context.Background(), "UNLISTEN *")
+
+		// Anything written before the LISTEN took effect is dropped too
+		cache.InvalidateQuestionHierarchy()
+		log.Printf("Listening for question hierarchy changes on %s", cache.QuestionHierarchyChannel)
+
+		for {
+			notification, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to wait for notifications: %w", err)
+			}
+			log.Printf("Question hierarchy changed (%s), dropping the cached hierarchy", notification.Payload)
+			cache.InvalidateQuestionHierarchy()
+		}
+	})
+}
@@ -0,0 +1,275 @@
+// Background worker that computes the answer statistics of every question from the responses of the submitted
+// practice sessions, calibrates their difficulty and flags the ones the admins should review.
+package workers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/config"
+	question_hierarchy "server/models/question_bank/question_hierarchy"
+	question_type "server/models/question_bank/question_type"
+	student_psql "server/models/student_psql"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// questionStatisticsLockID is the Postgres advisory lock key that makes sure only one
+	// server instance computes the statistics at a time
+	questionStatisticsLockID = 724_002
+
+	defaultQuestionStatisticsInterval    = time.Hour
+	defaultQuestionStatisticsMinAttempts = 30
+)
+
+// ErrQuestionStatisticsLocked is returned when another instance is computing the statistics
+var ErrQuestionStatisticsLocked = errors.New("question statistics are being computed by another instance")
+
+// QuestionStatisticsConfig holds the settings of the question statistics worker
+type QuestionStatisticsConfig struct {
+	Interval    time.Duration // Time between two computations
+	MinAttempts int           // Attempts below which a question is neither calibrated nor flagged
+}
+
+// QuestionStatisticsRun is the outcome of a computation of the question statistics
+type QuestionStatisticsRun struct {
+	Questions  int       `json:"questions"` // Questions with at least one response to their latest revision
+	Flagged    int       `json:"flagged"`
+	ComputedAt time.Time `json:"computedAt"`
+}
+
+// intFromEnv reads a positive integer from the environment, falling back to the default
+func intFromEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 {
+		log.Printf("Invalid value %q for %s, using default of %d", value, name, fallback)
+		return fallback
+	}
+	return number
+}
+
+// LoadQuestionStatisticsConfig reads the question statistics settings from the environment
+func LoadQuestionStatisticsConfig() QuestionStatisticsConfig {
+	return QuestionStatisticsConfig{
+		Interval:    durationFromEnv("QUESTION_STATISTICS_INTERVAL_MINUTES", defaultQuestionStatisticsInterval),
+		MinAttempts: intFromEnv("QUESTION_STATISTICS_MIN_ATTEMPTS", defaultQuestionStatisticsMinAttempts),
+	}
+}
+
+// StartQuestionStatisticsWorker computes the statistics immediately and then at every configured interval.
+// Blocking, run it in its own goroutine.
+func StartQuestionStatisticsWorker() {
+	statisticsConfig := LoadQuestionStatisticsConfig()
+	log.Printf("Question statistics worker started (interval: %s, minimum attempts: %d)", statisticsConfig.Interval, statisticsConfig.MinAttempts)
+
+	ticker := time.NewTicker(statisticsConfig.Interval)
+	defer ticker.Stop()
+
+	for {
+		run, err := ComputeQuestionStatistics(statisticsConfig.MinAttempts)
+		switch {
+		case errors.Is(err, ErrQuestionStatisticsLocked):
+		case err != nil:
+			log.Printf("Question statistics computation failed: %v", err)
+		default:
+			log.Printf("Question statistics computed for %d questions, %d flagged", run.Questions, run.Flagged)
+		}
+		<-ticker.C
+	}
+}
+
+// questionStatisticsCounts is a row of the aggregated responses of a question
+type questionStatisticsCounts struct {
+	QuestionFormatID  uint32
+	QuestionID        uint32
+	RevisionID        uint32
+	Attempts          int
+	Skips             int
+	GradedAttempts    int
+	CorrectAttempts   int
+	MedianTimeSeconds *float64
+}
+
+// ComputeQuestionStatistics aggregates the responses to the latest revision of every stored question, evaluates them
+// and replaces the stored statistics. Returns ErrQuestionStatisticsLocked if another instance holds the lock.
+func ComputeQuestionStatistics(minAttempts int) (QuestionStatisticsRun, error) {
+	run := QuestionStatisticsRun{ComputedAt: time.Now()}
+
+	err := config.GetPostgresDBConnection().Transaction(func(tx *gorm.DB) error {
+		// Only one instance computes at a time, the lock is released automatically when the transaction ends
+		lockAcquired := false
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", questionStatisticsLockID).
+			Scan(&lockAcquired).Error; err != nil {
+			return fmt.Errorf("failed to acquire question statistics lock: %w", err)
+		}
+		if !lockAcquired {
+			return ErrQuestionStatisticsLocked
+		}
+
+		// The responses of the submitted sessions to the latest revision of the questions that are still stored
+		questionKeys := make([]string, 0, len(question_type.QuestionFormats))
+		for _, format := range question_type.QuestionFormats {
+			questionTable, err := question_type.QuestionTableForFormat(format)
+			if err != nil {
+				return err
+			}
+			questionKeys = append(questionKeys, fmt.Sprintf("SELECT question_format_id, question_id FROM %s", questionTable))
+		}
+		latestResponses := fmt.Sprintf(`
+			WITH latest AS (
+				SELECT DISTINCT ON (question_format_id, question_id) question_format_id, question_id, revision_id
+				FROM %s
+				ORDER BY question_format_id, question_id, revision_number DESC
+			), questions AS (%s)
+			SELECT l.question_format_id, l.question_id, l.revision_id, r.chosen_options, r.is_correct, r.time_spent_seconds,
+				(r.chosen_answer <> '' OR cardinality(r.chosen_options) > 0 OR cardinality(r.arrangement) > 0) AS answered
+			FROM %s r
+			JOIN %s sq ON sq.practice_session_id = r.practice_session_id AND sq.serve_order = r.serve_order
+			JOIN %s s ON s.practice_session_id = r.practice_session_id AND s.status = 'Submitted'
+			JOIN latest l ON l.revision_id = sq.question_revision_id
+			JOIN questions q ON q.question_format_id = l.question_format_id AND q.question_id = l.question_id`,
+			question_type.QuestionRevision{}.TableName(),
+			strings.Join(questionKeys, " UNION ALL "),
+			student_psql.StudentPracticeSessionResponseTable{}.TableName(),
+			student_psql.StudentPracticeSessionQuestionTable{}.TableName(),
+			student_psql.StudentPracticeSessionLookupTable{}.TableName(),
+		)
+
+		var counts []questionStatisticsCounts
+		if err := tx.Raw(fmt.Sprintf(`
+			SELECT question_format_id, question_id, revision_id,
+				COUNT(*) FILTER (WHERE answered) AS attempts,
+				COUNT(*) FILTER (WHERE NOT answered) AS skips,
+				COUNT(*) FILTER (WHERE answered AND is_correct IS NOT NULL) AS graded_attempts,
+				COUNT(*) FILTER (WHERE answered AND is_correct) AS correct_attempts,
+				percentile_cont(0.5) WITHIN GROUP (ORDER BY time_spent_seconds)
+					FILTER (WHERE answered AND time_spent_seconds > 0) AS median_time_seconds
+			FROM (%s) responses
+			GROUP BY question_format_id, question_id, revision_id`, latestResponses,
+		)).Scan(&counts).Error; err != nil {
+			return fmt.Errorf("failed to aggregate question responses: %w", err)
+		}
+
+		var optionCounts []struct {
+			QuestionFormatID uint32
+			QuestionID       uint32
+			OptionIndex      int
+			Choices          int64
+		}
+		if err := tx.Raw(fmt.Sprintf(`
+			SELECT question_format_id, question_id, option_index, COUNT(*) AS choices
+			FROM (%s) responses
+			CROSS JOIN unnest(responses.chosen_options) AS chosen(option_index)
+			GROUP BY question_format_id, question_id, option_index`, latestResponses,
+		)).Scan(&optionCounts).Error; err != nil {
+			return fmt.Errorf("failed to aggregate chosen MCQ options: %w", err)
+		}
+
+		formatIDs := make([]uint32, 0, len(counts))
+		for _, count := range counts {
+			formatIDs = append(formatIDs, count.QuestionFormatID)
+		}
+
+		// The difficulty level of the node of every format node
+		var nodes []struct {
+			QuestionFormatID uint32
+			DifficultyLevel  string
+		}
+		if len(formatIDs) > 0 {
+			if err := tx.Table(question_hierarchy.QuestionFormatTable{}.TableName()+" f").
+				Select("f.question_format_id, dl.difficulty_level").
+				Joins(fmt.Sprintf("JOIN %s dl ON dl.question_difficulty_level_id = f.question_difficulty_level_id", question_hierarchy.QuestionDifficultyLevelTable{}.TableName())).
+				Where("f.question_format_id IN ?", formatIDs).
+				Scan(&nodes).Error; err != nil {
+				return fmt.Errorf("failed to fetch question difficulty levels: %w", err)
+			}
+		}
+		difficultyLevels := make(map[uint32]string, len(nodes))
+		for _, node := range nodes {
+			difficultyLevels[node.QuestionFormatID] = node.DifficultyLevel
+		}
+
+		// The options and the answer key of the MCQ questions, the latest revision holds the same
+		var mcqQuestions []question_type.MCQQuestion
+		if len(formatIDs) > 0 {
+			if err := tx.Select("question_format_id", "question_id", "options", "correct_option_indices").
+				Where("question_format_id IN ?", formatIDs).
+				Find(&mcqQuestions).Error; err != nil {
+				return fmt.Errorf("failed to fetch MCQ answer keys: %w", err)
+			}
+		}
+		type questionKey struct{ formatID, questionID uint32 }
+		mcqByKey := make(map[questionKey]question_type.MCQQuestion, len(mcqQuestions))
+		for _, question := range mcqQuestions {
+			mcqByKey[questionKey{question.QuestionFormatID, question.QuestionID}] = question
+		}
+
+		statistics := make([]question_type.QuestionStatistics, 0, len(counts))
+		statisticsIndex := make(map[questionKey]int, len(counts))
+		for _, count := range counts {
+			key := questionKey{count.QuestionFormatID, count.QuestionID}
+			questionStatistics := question_type.QuestionStatistics{
+				QuestionFormatID:  count.QuestionFormatID,
+				QuestionID:        count.QuestionID,
+				RevisionID:        count.RevisionID,
+				Attempts:          count.Attempts,
+				Skips:             count.Skips,
+				GradedAttempts:    count.GradedAttempts,
+				CorrectAttempts:   count.CorrectAttempts,
+				MedianTimeSeconds: count.MedianTimeSeconds,
+				OptionCounts:      []int64{},
+				ComputedAt:        run.ComputedAt,
+			}
+			if question, isMCQ := mcqByKey[key]; isMCQ {
+				questionStatistics.OptionCounts = make([]int64, len(question.Options))
+			}
+			statisticsIndex[key] = len(statistics)
+			statistics = append(statistics, questionStatistics)
+		}
+
+		// Options that no longer exist can't be chosen from the latest revision, they are ignored anyway
+		for _, optionCount := range optionCounts {
+			index, exists := statisticsIndex[questionKey{optionCount.QuestionFormatID, optionCount.QuestionID}]
+			if !exists || optionCount.OptionIndex < 0 || optionCount.OptionIndex >= len(statistics[index].OptionCounts) {
+				continue
+			}
+			statistics[index].OptionCounts[optionCount.OptionIndex] = optionCount.Choices
+		}
+
+		for i := range statistics {
+			questionStatistics := &statistics[i]
+			key := questionKey{questionStatistics.QuestionFormatID, questionStatistics.QuestionID}
+			questionStatistics.Evaluate(difficultyLevels[key.formatID], mcqByKey[key].CorrectOptionIndices, minAttempts)
+			if len(questionStatistics.Flags) > 0 {
+				run.Flagged++
+			}
+		}
+
+		if err := tx.Where("1 = 1").Delete(&question_type.QuestionStatistics{}).Error; err != nil {
+			return fmt.Errorf("failed to clear question statistics: %w", err)
+		}
+		if len(statistics) > 0 {
+			if err := tx.CreateInBatches(&statistics, 500).Error; err != nil {
+				return fmt.Errorf("failed to store question statistics: %w", err)
+			}
+		}
+
+		run.Questions = len(statistics)
+		return nil
+	})
+	if err != nil {
+		return QuestionStatisticsRun{}, err
+	}
+	return run, nil
+}